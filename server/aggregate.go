@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// aggregateGroupCounts are the GROUP BY cardinalities exercised by the aggregate workload. Each
+// query scans the same rows server-side, so only the result-set size varies between them.
+var aggregateGroupCounts = []int{1, 100, 10000}
+
+// AggregateResult reports the timing of one aggregate query.
+type AggregateResult struct {
+	Groups      int     `json:"groups"`
+	RowsScanned int64   `json:"rows_scanned"`
+	TimeSeconds float64 `json:"time_seconds"`
+}
+
+// runAggregateTest seeds plugin_test_rpc and then runs GROUP BY / COUNT / SUM queries over its
+// first opts.Records rows with increasing group counts, showing whether result-set size or
+// per-row RPC marshaling dominates.
+func (p *Plugin) runAggregateTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeAggregate,
+		Phase:    opts.Phase,
+		RowBytes: opts.RowBytes,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedTestTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+		result.setInsertMethod(opts)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryAggregates(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// aggregateSQL groups the first rows of plugin_test_rpc into a number of buckets, taking the
+// bucket count and the row count.
+const aggregateSQL = `
+	SELECT t.id % ? AS bucket, COUNT(*), SUM(LENGTH(t.data))
+	FROM (SELECT id, data FROM plugin_test_rpc ORDER BY id LIMIT ?) t
+	GROUP BY bucket
+`
+
+// queryAggregates runs one aggregate query per entry of aggregateGroupCounts, recording each
+// timing on result along with the total time and the number of result rows read.
+func queryAggregates(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	querySQL := opts.tagSQL(rebind(driverName, aggregateSQL))
+
+	startTotalQuery := time.Now()
+
+	for _, groups := range aggregateGroupCounts {
+		aggregate := AggregateResult{Groups: groups}
+		start := time.Now()
+
+		rows, err := db.Query(querySQL, groups, opts.Records)
+		if err != nil {
+			return fmt.Errorf("failed to run aggregate over %d groups: %v", groups, err)
+		}
+
+		for rows.Next() {
+			var bucket, count, bytes int64
+			if err := rows.Scan(&bucket, &count, &bytes); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan aggregate: %v", err)
+			}
+			aggregate.RowsScanned += count
+			result.RecordsQueried++
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read aggregate over %d groups: %v", groups, err)
+		}
+		rows.Close()
+
+		aggregate.TimeSeconds = time.Since(start).Seconds()
+		result.Aggregates = append(result.Aggregates, aggregate)
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
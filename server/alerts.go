@@ -0,0 +1,79 @@
+package main
+
+// regressionAlert describes a benchmark run that breached its regression criteria.
+type regressionAlert struct {
+	// Reason explains, in Markdown, which criteria the run breached.
+	Reason string
+
+	// Result is the run that triggered the alert.
+	Result TestResult
+}
+
+// regressionSeries identifies the runs whose consecutive regressions are counted together.
+func regressionSeries(result TestResult) string {
+	series := result.ConnType + "-" + result.Mode
+	if result.Label != "" {
+		series += "-" + result.Label
+	}
+	return series
+}
+
+// recordRegressionCheck tracks the outcome of checking result for regressions, where alert is
+// nil for a run that passed. Regressed runs fire an alert, and a series that regresses for the
+// configured number of consecutive runs also opens an issue, once per streak. Only scheduled runs
+// count towards a streak: ad-hoc runs of a series still alert, but neither advance nor reset it.
+func (p *Plugin) recordRegressionCheck(result TestResult, alert *regressionAlert, scheduled bool) {
+	if !scheduled {
+		if alert != nil {
+			p.fireRegressionAlert(*alert)
+		}
+		return
+	}
+
+	series := regressionSeries(result)
+
+	streak, err := p.kvstore.GetRegressionStreak(series)
+	if err != nil {
+		p.API.LogError("Failed to get regression streak", "series", series, "error", err)
+	}
+
+	if alert == nil {
+		if streak > 0 {
+			if err := p.kvstore.SetRegressionStreak(series, 0); err != nil {
+				p.API.LogError("Failed to reset regression streak", "series", series, "error", err)
+			}
+		}
+		return
+	}
+
+	streak++
+	if err := p.kvstore.SetRegressionStreak(series, streak); err != nil {
+		p.API.LogError("Failed to set regression streak", "series", series, "error", err)
+	}
+
+	p.fireRegressionAlert(*alert)
+
+	threshold := p.getConfiguration().IssueConsecutiveRuns
+	if threshold <= 0 {
+		threshold = defaultIssueConsecutiveRuns
+	}
+	if streak == threshold {
+		if err := p.openRegressionIssue(*alert, streak); err != nil {
+			p.API.LogError("Failed to open regression issue", "series", series, "error", err)
+		}
+	}
+}
+
+// fireRegressionAlert hands alert to every configured regression response integration. Failures
+// are logged rather than returned, since one broken integration must not silence the others.
+func (p *Plugin) fireRegressionAlert(alert regressionAlert) {
+	p.API.LogWarn("Benchmark regression detected", "reason", alert.Reason, "conn_type", alert.Result.ConnType, "label", alert.Result.Label)
+
+	if err := p.startRegressionPlaybook(alert); err != nil {
+		p.API.LogError("Failed to start regression playbook", "error", err)
+	}
+
+	if err := p.postRegressionAlert(alert); err != nil {
+		p.API.LogError("Failed to post regression alert", "error", err)
+	}
+}
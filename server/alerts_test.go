@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeAlertStore tracks regression streaks in memory, with no baselines set and runs discarded.
+type fakeAlertStore struct {
+	kvstore.KVStore
+
+	mu      sync.Mutex
+	streaks map[string]int
+}
+
+func (s *fakeAlertStore) GetBaseline(string) (*kvstore.Baseline, error) {
+	return nil, nil
+}
+
+func (s *fakeAlertStore) GetRegressionStreak(series string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.streaks[series], nil
+}
+
+func (s *fakeAlertStore) SetRegressionStreak(series string, streak int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.streaks[series] = streak
+	return nil
+}
+
+func (s *fakeAlertStore) SaveRun(kvstore.Run) error {
+	return nil
+}
+
+func TestRecordRegressionCheck(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	store := &fakeAlertStore{streaks: map[string]int{}}
+	p := Plugin{kvstore: store}
+	p.SetAPI(api)
+	p.setConfiguration(&configuration{})
+
+	result := TestResult{ConnType: "rpc", Mode: modeScan}
+	alert := &regressionAlert{Reason: "slower", Result: result}
+	series := regressionSeries(result)
+
+	// An ad-hoc run alerts without advancing the streak.
+	p.recordRegressionCheck(result, alert, false)
+	assert.Equal(t, 0, store.streaks[series])
+	api.AssertNumberOfCalls(t, "LogWarn", 1)
+
+	p.recordRegressionCheck(result, alert, true)
+	p.recordRegressionCheck(result, alert, true)
+	assert.Equal(t, 2, store.streaks[series])
+
+	// Nor does a passing ad-hoc run reset it.
+	p.recordRegressionCheck(result, nil, false)
+	assert.Equal(t, 2, store.streaks[series])
+
+	p.recordRegressionCheck(result, nil, true)
+	assert.Equal(t, 0, store.streaks[series])
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// analyzeStatistics refreshes planner statistics with ANALYZE or ANALYZE TABLE.
+	analyzeStatistics = "analyze"
+
+	// analyzeOptimize also reclaims space, with VACUUM ANALYZE or OPTIMIZE TABLE.
+	analyzeOptimize = "optimize"
+)
+
+// modeTables returns the tables the workload selected by opts seeds and queries.
+func modeTables(opts testOptions) []string {
+	switch opts.Mode {
+	case modeBlob:
+		return []string{"plugin_test_rpc_blob"}
+	case modeJoin:
+		return []string{"plugin_test_rpc", "plugin_test_rpc_detail"}
+	case modeJSON:
+		return []string{"plugin_test_rpc_json"}
+	case modeSavepoint:
+		return []string{"plugin_test_rpc_savepoint"}
+	case modeDeadlock:
+		return []string{"plugin_test_rpc_deadlock"}
+	case modeRowLock:
+		return []string{"plugin_test_rpc_hot"}
+	case modeTimeout, modeNoop:
+		return nil
+	case modeWide:
+		return []string{wideTable(opts.Columns)}
+	case modeNulls:
+		return []string{"plugin_test_rpc_nulls"}
+	case modeText:
+		return []string{"plugin_test_rpc_text"}
+	case modeTimestamps:
+		return []string{"plugin_test_rpc_time"}
+	case modeNumeric:
+		return []string{"plugin_test_rpc_numeric"}
+	}
+
+	return []string{"plugin_test_rpc"}
+}
+
+// analyzeSQL returns the statement running opts.Analyze against table.
+func analyzeSQL(driverName string, opts testOptions, table string) string {
+	switch {
+	case driverName == "postgres" && opts.Analyze == analyzeOptimize:
+		return "VACUUM ANALYZE " + table
+	case driverName == "postgres":
+		return "ANALYZE " + table
+	case driverName == "sqlite" && opts.Analyze == analyzeOptimize:
+		// SQLite only vacuums the whole database.
+		return "VACUUM"
+	case driverName == "sqlite":
+		return "ANALYZE " + table
+	case opts.Analyze == analyzeOptimize:
+		return "OPTIMIZE TABLE " + table
+	default:
+		return "ANALYZE TABLE " + table
+	}
+}
+
+// analyzeTables runs opts.Analyze against every table of the workload, so that the query phase
+// is planned from accurate statistics, recording the time taken on result.
+func analyzeTables(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	start := time.Now()
+	for _, table := range modeTables(opts) {
+		statement := opts.tagSQL(analyzeSQL(driverName, opts, table))
+		if driverName == "postgres" || driverName == "sqlite" {
+			// VACUUM refuses to run in a transaction, so it is executed on its own.
+			if _, err := db.Exec(statement); err != nil {
+				return fmt.Errorf("failed to %s %s: %v", opts.Analyze, table, err)
+			}
+			continue
+		}
+
+		if err := checkTableMaintenance(db, statement); err != nil {
+			return fmt.Errorf("failed to %s %s: %v", opts.Analyze, table, err)
+		}
+	}
+
+	result.Analyze = opts.Analyze
+	result.AnalyzeTimeSeconds = time.Since(start).Seconds()
+
+	return nil
+}
+
+// checkTableMaintenance runs a MySQL ANALYZE TABLE or OPTIMIZE TABLE statement, which reports
+// failures as rows of its result set rather than as an error.
+func checkTableMaintenance(db *sql.DB, statement string) error {
+	rows, err := db.Query(statement)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, op, msgType, msgText string
+		if err := rows.Scan(&table, &op, &msgType, &msgText); err != nil {
+			return err
+		}
+		if strings.EqualFold(msgType, "error") {
+			return fmt.Errorf("%s", msgText)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeSQL(t *testing.T) {
+	analyze := testOptions{Analyze: analyzeStatistics}
+	optimize := testOptions{Analyze: analyzeOptimize}
+
+	assert.Equal(t, "ANALYZE plugin_test_rpc", analyzeSQL("postgres", analyze, "plugin_test_rpc"))
+	assert.Equal(t, "VACUUM ANALYZE plugin_test_rpc", analyzeSQL("postgres", optimize, "plugin_test_rpc"))
+	assert.Equal(t, "ANALYZE TABLE plugin_test_rpc", analyzeSQL("mysql", analyze, "plugin_test_rpc"))
+	assert.Equal(t, "OPTIMIZE TABLE plugin_test_rpc", analyzeSQL("mysql", optimize, "plugin_test_rpc"))
+}
+
+func TestModeTables(t *testing.T) {
+	assert.Equal(t, []string{"plugin_test_rpc"}, modeTables(testOptions{Mode: modeScan}))
+	assert.Equal(t, []string{"plugin_test_rpc", "plugin_test_rpc_detail"}, modeTables(testOptions{Mode: modeJoin}))
+	assert.Equal(t, []string{"plugin_test_rpc_wide_64"}, modeTables(testOptions{Mode: modeWide, Columns: 64}))
+	assert.Empty(t, modeTables(testOptions{Mode: modeTimeout}))
+	assert.Empty(t, modeTables(testOptions{Mode: modeNoop}))
+}
@@ -3,16 +3,15 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
-	_ "github.com/lib/pq"
-	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/mattermost/mattermost/server/public/shared/driver"
 )
 
 // ServeHTTP demonstrates a plugin that handles HTTP requests by greeting the world.
@@ -20,9 +19,42 @@ import (
 func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Request) {
 	router := mux.NewRouter()
 
-	publicRouter := router.PathPrefix("/api/v1").Subrouter()
-	publicRouter.HandleFunc("/test", p.TestDatabase).Methods(http.MethodGet)
-	publicRouter.HandleFunc("/test_raw", p.TestDatabaseRaw).Methods(http.MethodGet)
+	// The status route is the only one open to everyone.
+	router.HandleFunc("/api/v1/status", p.Status).Methods(http.MethodGet)
+
+	// Benchmarks hold the cluster-wide run lock, so only one runs at a time.
+	benchmarkRouter := router.PathPrefix("/api/v1").Subrouter()
+	benchmarkRouter.Use(p.SystemAdminRequired, p.RunLockRequired)
+	benchmarkRouter.HandleFunc("/test", p.TestDatabase).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/test_raw", p.TestDatabaseRaw).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/ping_db", p.PingDatabase).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/test_connect", p.TestDatabaseConnect).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/test_growth", p.TestDatabaseGrowth).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/test_rest", p.TestDatabaseREST).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/test_posts", p.TestDatabasePosts).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/quick", p.QuickCheck).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/test_saturation", p.TestDatabaseSaturation).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/test_scaling", p.TestDatabaseScaling).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/test_cluster", p.TestDatabaseCluster).Methods(http.MethodGet)
+	benchmarkRouter.HandleFunc("/runs/{id}/replay", p.ReplayRun).Methods(http.MethodPost)
+	benchmarkRouter.HandleFunc("/admin/teardown", p.Teardown).Methods(http.MethodPost)
+
+	adminRouter := router.PathPrefix("/api/v1").Subrouter()
+	adminRouter.Use(p.SystemAdminRequired)
+	adminRouter.HandleFunc("/nodes", p.ListNodes).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/datasets", p.ListDatasets).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/baseline", p.SetBaseline).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/results", p.ListResults).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/results/compare", p.CompareResults).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/results/export.xlsx", p.ExportResults).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/results/{id}", p.AnnotateResult).Methods(http.MethodPatch)
+	adminRouter.HandleFunc("/results/{id}/report", p.RunReport).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/results/{id}/profile", p.RunProfile).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/results/{id}/queries.ndjson", p.RunQueryLog).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/results/{id}/latency.svg", p.LatencyTrendChart).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/dialog/configure", p.SubmitConfigureDialog).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/jobs", p.ListJobs).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/jobs/{id}", p.CancelJob).Methods(http.MethodDelete)
 
 	// Protected routes
 	secureRouter := router.PathPrefix("/api/v1").Subrouter()
@@ -44,6 +76,37 @@ func (p *Plugin) MattermostAuthorizationRequired(next http.Handler) http.Handler
 	})
 }
 
+// SystemAdminRequired refuses requests from anyone but a system admin. Benchmarks create
+// tables, fill them with data and load the database, and raw connections use the database
+// credentials or DSN options that can point TLS at arbitrary certificate files on the server,
+// so no other user may run them.
+func (p *Plugin) SystemAdminRequired(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Mattermost-User-ID") == "" {
+			http.Error(w, "Not authorized", http.StatusUnauthorized)
+			return
+		}
+		if !p.isSystemAdmin(r) {
+			http.Error(w, "Benchmarks require a system admin", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// StatusResponse reports that the plugin is up, without revealing anything about the database.
+type StatusResponse struct {
+	Status   string `json:"status"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+// Status reports that the plugin is running and whether it is in read-only mode. It is the only
+// endpoint open to any user, so it never touches the database.
+func (p *Plugin) Status(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, StatusResponse{Status: "ok", ReadOnly: p.readOnly()})
+}
+
 func (p *Plugin) HelloWorld(w http.ResponseWriter, r *http.Request) {
 	if _, err := w.Write([]byte("Hello, world!")); err != nil {
 		p.API.LogError("Failed to write response", "error", err)
@@ -52,256 +115,586 @@ func (p *Plugin) HelloWorld(w http.ResponseWriter, r *http.Request) {
 }
 
 type TestResult struct {
-	InsertTimeSeconds     float64 `json:"insert_time_seconds"`
-	TotalQueryTimeSeconds float64 `json:"total_query_time_seconds"`
-	Error                 string  `json:"error,omitempty"`
-	ConnType              string  `json:"conn_type"`
-	RecordsQueried        int     `json:"records_queried"`
-	PageSize              int     `json:"page_size"`
+	SchemaVersion          int              `json:"schema_version"`
+	InsertTimeSeconds      float64          `json:"insert_time_seconds"`
+	RecordsInserted        int              `json:"records_inserted,omitempty"`
+	InsertBatch            int              `json:"insert_batch,omitempty"`
+	Bulk                   string           `json:"bulk,omitempty"`
+	InsertRowsPerSecond    float64          `json:"insert_rows_per_second,omitempty"`
+	IndexDropTimeSeconds   float64          `json:"index_drop_time_seconds,omitempty"`
+	IndexBuildTimeSeconds  float64          `json:"index_build_time_seconds,omitempty"`
+	Analyze                string           `json:"analyze,omitempty"`
+	AnalyzeTimeSeconds     float64          `json:"analyze_time_seconds,omitempty"`
+	TotalQueryTimeSeconds  float64          `json:"total_query_time_seconds"`
+	Error                  string           `json:"error,omitempty"`
+	ConnType               string           `json:"conn_type"`
+	RecordsQueried         int              `json:"records_queried"`
+	PageSize               int              `json:"page_size"`
+	Pagination             string           `json:"pagination,omitempty"`
+	StatementCache         bool             `json:"statement_cache,omitempty"`
+	QueryRetries           int              `json:"query_retries,omitempty"`
+	Label                  string           `json:"label,omitempty"`
+	Tags                   []string         `json:"tags,omitempty"`
+	Mode                   string           `json:"mode,omitempty"`
+	Phase                  string           `json:"phase,omitempty"`
+	Reset                  bool             `json:"reset,omitempty"`
+	DatasetFingerprint     string           `json:"dataset_fingerprint,omitempty"`
+	PayloadBytes           int              `json:"payload_bytes,omitempty"`
+	RowBytes               int              `json:"row_bytes,omitempty"`
+	BytesQueried           int64            `json:"bytes_queried,omitempty"`
+	CharsQueried           int64            `json:"chars_queried,omitempty"`
+	QueryRowsPerSecond     float64          `json:"query_rows_per_second,omitempty"`
+	QueryBytesPerSecond    float64          `json:"query_bytes_per_second,omitempty"`
+	TimeToFirstRowSeconds  float64          `json:"time_to_first_row_seconds,omitempty"`
+	BuilderTimeSeconds     float64          `json:"builder_time_seconds,omitempty"`
+	MaxOpenConns           int              `json:"max_open_conns,omitempty"`
+	MaxIdleConns           int              `json:"max_idle_conns,omitempty"`
+	ConnMaxLifetimeSeconds float64          `json:"conn_max_lifetime_seconds,omitempty"`
+	Lookups                int              `json:"lookups,omitempty"`
+	LookupsPerSecond       float64          `json:"lookups_per_second,omitempty"`
+	TargetQPS              int              `json:"target_qps,omitempty"`
+	ThinkTimeSeconds       float64          `json:"think_time_seconds,omitempty"`
+	Savepoints             int              `json:"savepoints,omitempty"`
+	SavepointsPerSecond    float64          `json:"savepoints_per_second,omitempty"`
+	Deadlocks              int              `json:"deadlocks,omitempty"`
+	DeadlockRetries        int              `json:"deadlock_retries,omitempty"`
+	DeadlockError          string           `json:"deadlock_error,omitempty"`
+	LockWorkers            int              `json:"lock_workers,omitempty"`
+	HotRows                int              `json:"hot_rows,omitempty"`
+	Columns                int              `json:"columns,omitempty"`
+	NullFraction           float64          `json:"null_fraction,omitempty"`
+	NullValues             int              `json:"null_values,omitempty"`
+	Charset                string           `json:"charset,omitempty"`
+	Locks                  int              `json:"locks,omitempty"`
+	LocksPerSecond         float64          `json:"locks_per_second,omitempty"`
+	NoiseOpsPerSecond      int              `json:"noise_ops_per_second,omitempty"`
+	NoiseOps               int64            `json:"noise_ops,omitempty"`
+	NoiseErrors            int64            `json:"noise_errors,omitempty"`
+	Isolation              string           `json:"isolation,omitempty"`
+	TxAborts               int              `json:"tx_aborts,omitempty"`
+	TxRetries              int              `json:"tx_retries,omitempty"`
+	Iterations             int              `json:"iterations,omitempty"`
+	DurationSeconds        float64          `json:"duration_seconds,omitempty"`
+	WarmupBatches          int              `json:"warmup_batches,omitempty"`
+	WarmupTimeSeconds      float64          `json:"warmup_time_seconds,omitempty"`
+	DatabaseFlavor         string           `json:"database_flavor,omitempty"`
+	DatabaseVersion        string           `json:"database_version,omitempty"`
+	Environment            *Environment     `json:"environment,omitempty"`
+	ServerStats            map[string]int64 `json:"server_stats,omitempty"`
+	TableSizes             []TableSize      `json:"table_sizes,omitempty"`
+	DroppedTables          []string         `json:"dropped_tables,omitempty"`
+	RunID                  string           `json:"run_id,omitempty"`
+	ReplayOf               string           `json:"replay_of,omitempty"`
+
+	// Profile is the kind of profile captured during the run, which is stored with it.
+	Profile string `json:"profile,omitempty"`
+
+	// profileData is the captured profile, until it is stored with the run.
+	profileData []byte
+
+	// QueryLog summarizes the query log recorded during the run, which is stored with it.
+	QueryLog *QueryLogSummary `json:"query_log,omitempty"`
+
+	// queryLogData is the gzipped query log, until it is stored with the run.
+	queryLogData []byte
+
+	Regression        *Regression        `json:"regression,omitempty"`
+	ThresholdBreaches []ThresholdBreach  `json:"threshold_breaches,omitempty"`
+	Node              *NodeInfo          `json:"node,omitempty"`
+	LookupLatency     *LatencyMillis     `json:"lookup_latency,omitempty"`
+	SavepointLatency  *LatencyMillis     `json:"savepoint_latency,omitempty"`
+	DeadlockLatency   *LatencyMillis     `json:"deadlock_latency,omitempty"`
+	LockWaitLatency   *LatencyMillis     `json:"lock_wait_latency,omitempty"`
+	LatencyHistogram  *LatencyHistogram  `json:"latency_histogram,omitempty"`
+	LatencyTrend      []LatencyPoint     `json:"latency_trend,omitempty"`
+	Memory            []PhaseMemory      `json:"memory,omitempty"`
+	QueryTime         *Variability       `json:"query_time_seconds_stats,omitempty"`
+	QueryRate         *Variability       `json:"query_rows_per_second_stats,omitempty"`
+	Aggregates        []AggregateResult  `json:"aggregates,omitempty"`
+	Searches          []SearchResult     `json:"searches,omitempty"`
+	JSONFilters       []JSONFilterResult `json:"json_filters,omitempty"`
+	Plans             []PlanResult       `json:"plans,omitempty"`
+	Explains          []ExplainResult    `json:"explains,omitempty"`
+	Timeouts          []TimeoutResult    `json:"timeouts,omitempty"`
+	Timestamps        []TimestampResult  `json:"timestamps,omitempty"`
+	Noops             []NoopResult       `json:"noops,omitempty"`
 }
 
-// TestDatabase uses the StoreService to access the Mattermost database
-func (p *Plugin) TestDatabase(w http.ResponseWriter, r *http.Request) {
-	// Parse page size from query param
-	pageSize := 100 // Default page size
-	pageSizeParam := r.URL.Query().Get("page_size")
-	if pageSizeParam != "" {
-		if size, err := strconv.Atoi(pageSizeParam); err == nil && size > 0 {
-			pageSize = size
+// setInsertThroughput records the outcome of the insert phase.
+func (r *TestResult) setInsertThroughput(inserted int, elapsed time.Duration) {
+	r.RecordsInserted = inserted
+	r.InsertTimeSeconds = elapsed.Seconds()
+	if inserted > 0 && r.InsertTimeSeconds > 0 {
+		r.InsertRowsPerSecond = float64(inserted) / r.InsertTimeSeconds
+	}
+}
+
+// setInsertMethod records how plugin_test_rpc was seeded according to opts.
+func (r *TestResult) setInsertMethod(opts testOptions) {
+	if opts.Bulk != "" {
+		r.Bulk = opts.Bulk
+	} else if opts.InsertBatch > 1 {
+		r.InsertBatch = opts.InsertBatch
+	}
+}
+
+// setQueryThroughput derives the query throughput from the rows and bytes read and the total
+// query time.
+func (r *TestResult) setQueryThroughput() {
+	if r.TotalQueryTimeSeconds <= 0 {
+		return
+	}
+
+	r.QueryRowsPerSecond = float64(r.RecordsQueried) / r.TotalQueryTimeSeconds
+	r.QueryBytesPerSecond = float64(r.BytesQueried) / r.TotalQueryTimeSeconds
+}
+
+// poolSettings captures the connection pool tuning applied to a raw database connection.
+// Zero values leave the database/sql defaults in place.
+type poolSettings struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// defaultMaxIdleConns mirrors the idle connection limit database/sql applies when none is set.
+const defaultMaxIdleConns = 2
+
+// parsePoolSettings reads the optional pool tuning query params. Invalid values are ignored.
+func parsePoolSettings(r *http.Request) poolSettings {
+	settings := poolSettings{
+		MaxIdleConns: defaultMaxIdleConns,
+	}
+
+	query := r.URL.Query()
+	if value := query.Get("max_open_conns"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			settings.MaxOpenConns = n
+		}
+	}
+	if value := query.Get("max_idle_conns"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			settings.MaxIdleConns = n
+		}
+	}
+	if value := query.Get("conn_max_lifetime"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			settings.ConnMaxLifetime = d
 		}
 	}
 
-	// Get database from StoreService
-	store := p.client.Store
-	db, err := store.GetMasterDB()
+	return settings
+}
+
+// apply configures the connection pool on db.
+func (s poolSettings) apply(db *sql.DB) {
+	db.SetMaxOpenConns(s.MaxOpenConns)
+	db.SetMaxIdleConns(s.MaxIdleConns)
+	db.SetConnMaxLifetime(s.ConnMaxLifetime)
+}
+
+// report copies the effective pool settings onto result.
+func (s poolSettings) report(db *sql.DB, result *TestResult) {
+	result.MaxOpenConns = db.Stats().MaxOpenConnections
+	result.MaxIdleConns = s.MaxIdleConns
+	if s.MaxOpenConns > 0 && s.MaxIdleConns > s.MaxOpenConns {
+		// database/sql clamps idle connections to the open connection limit.
+		result.MaxIdleConns = s.MaxOpenConns
+	}
+	result.ConnMaxLifetimeSeconds = s.ConnMaxLifetime.Seconds()
+}
+
+// TestDatabase uses the StoreService to access the Mattermost database
+func (p *Plugin) TestDatabase(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseTestOptions(r)
 	if err != nil {
-		p.API.LogError("Failed to get database", "error", err)
-		respondWithJSON(w, http.StatusInternalServerError, TestResult{
-			Error:    fmt.Sprintf("Failed to get database: %v", err),
+		respondWithJSON(w, http.StatusBadRequest, TestResult{
+			Error:    err.Error(),
+			ConnType: "rpc",
+		})
+		return
+	}
+	if opts.Bulk != "" {
+		respondWithJSON(w, http.StatusBadRequest, TestResult{
+			Error:    "bulk loading is only supported on raw connections",
+			ConnType: "rpc",
+		})
+		return
+	}
+	if opts.DSN != "" {
+		respondWithJSON(w, http.StatusBadRequest, TestResult{
+			Error:    "an alternate dsn is only supported on raw connections",
+			ConnType: "rpc",
+		})
+		return
+	}
+	if err := p.checkReadOnly(opts); err != nil {
+		respondWithJSON(w, http.StatusForbidden, TestResult{
+			Error:    err.Error(),
 			ConnType: "rpc",
 		})
 		return
 	}
 
-	// Run test through helper method
-	result, err := p.runDatabaseTest(db, store.DriverName(), pageSize)
+	var result TestResult
+	if opts.NodeID != "" {
+		result, err = p.runPinned("rpc", opts, r)
+	} else {
+		result, err = p.runRPCTest(opts)
+	}
 	if err != nil {
-		p.API.LogError("Test failed", "error", err)
-		respondWithJSON(w, http.StatusInternalServerError, TestResult{
+		status := http.StatusInternalServerError
+		if errors.Is(err, errUnknownNode) {
+			status = http.StatusNotFound
+		}
+		respondWithJSON(w, status, TestResult{
 			Error:    err.Error(),
 			ConnType: "rpc",
 		})
 		return
 	}
 
-	// Set connection type
-	result.ConnType = "rpc"
+	p.finishRun(requestRunID(r), "rpc", r.URL.Query(), "", false, &result)
+	p.publishResult(result)
 
 	respondWithJSON(w, http.StatusOK, result)
 }
 
 // TestDatabaseRaw establishes a direct connection to the database using config
 func (p *Plugin) TestDatabaseRaw(w http.ResponseWriter, r *http.Request) {
-	// Parse page size from query param
-	pageSize := 100 // Default page size
-	pageSizeParam := r.URL.Query().Get("page_size")
-	if pageSizeParam != "" {
-		if size, err := strconv.Atoi(pageSizeParam); err == nil && size > 0 {
-			pageSize = size
-		}
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, TestResult{
+			Error:    err.Error(),
+			ConnType: "raw",
+		})
+		return
 	}
-
-	// Get unsanitized config to access database credentials
-	config := p.API.GetUnsanitizedConfig()
-	if config == nil {
-		respondWithJSON(w, http.StatusInternalServerError, TestResult{
-			Error:    "Failed to get server configuration",
+	if err := p.checkReadOnly(opts); err != nil {
+		respondWithJSON(w, http.StatusForbidden, TestResult{
+			Error:    err.Error(),
 			ConnType: "raw",
 		})
 		return
 	}
-
-	var db *sql.DB
-	var err error
-	var driverName string
-
-	// Connect based on database type
-	switch *config.SqlSettings.DriverName {
-	case model.DatabaseDriverMysql:
-		driverName = "mysql"
-		dataSource := *config.SqlSettings.DataSource
-		db, err = sql.Open(driverName, dataSource)
-	case model.DatabaseDriverPostgres:
-		driverName = "postgres"
-		dataSource := *config.SqlSettings.DataSource
-		db, err = sql.Open(driverName, dataSource)
-	default:
-		respondWithJSON(w, http.StatusInternalServerError, TestResult{
-			Error:    fmt.Sprintf("Unsupported database driver: %s", *config.SqlSettings.DriverName),
+	// Raw runs read the database credentials, so they are authorized separately from the other
+	// benchmarks rather than relying on the router alone.
+	if !p.isSystemAdmin(r) {
+		respondWithJSON(w, http.StatusForbidden, TestResult{
+			Error:    "raw mode requires a system admin",
 			ConnType: "raw",
 		})
 		return
 	}
-
-	if err != nil {
-		p.API.LogError("Failed to connect to database directly", "error", err)
-		respondWithJSON(w, http.StatusInternalServerError, TestResult{
-			Error:    fmt.Sprintf("Failed to connect to database: %v", err),
+	// The run is audited once it opens its raw connection, but is refused here already so that
+	// the admin is told it is forbidden.
+	if !p.rawModeEnabled() {
+		respondWithJSON(w, http.StatusForbidden, TestResult{
+			Error:    errRawModeDisabled.Error(),
 			ConnType: "raw",
 		})
 		return
 	}
-	defer db.Close()
 
-	// Run test through helper method
-	result, err := p.runDatabaseTest(db, driverName, pageSize)
+	var result TestResult
+	if opts.NodeID != "" {
+		result, err = p.runPinned("raw", opts, r)
+	} else {
+		result, err = p.runRawTest(opts, parsePoolSettings(r))
+	}
 	if err != nil {
-		p.API.LogError("Test failed", "error", err)
-		respondWithJSON(w, http.StatusInternalServerError, TestResult{
+		status := http.StatusInternalServerError
+		if errors.Is(err, errUnknownNode) {
+			status = http.StatusNotFound
+		}
+		respondWithJSON(w, status, TestResult{
 			Error:    err.Error(),
 			ConnType: "raw",
 		})
 		return
 	}
 
-	// Set connection type
-	result.ConnType = "raw"
+	p.finishRun(requestRunID(r), "raw", r.URL.Query(), "", false, &result)
+	p.publishResult(result)
 
 	respondWithJSON(w, http.StatusOK, result)
 }
 
-// runDatabaseTest is a helper method that runs the database test with a given DB connection
-func (p *Plugin) runDatabaseTest(db *sql.DB, driverName string, batchSize int) (TestResult, error) {
-	result := TestResult{}
-	const totalRecords = 50000
-
-	p.API.LogInfo("Database driver", "name", driverName)
-
-	// Create test table (no timing metrics)
-	var createTableSQL string
-	if driverName == "postgres" {
-		createTableSQL = `
-			CREATE TABLE IF NOT EXISTS plugin_test_rpc (
-				id SERIAL PRIMARY KEY,
-				data VARCHAR(255) NOT NULL,
-				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-			)
-		`
-	} else {
-		// MySQL syntax
-		createTableSQL = `
-			CREATE TABLE IF NOT EXISTS plugin_test_rpc (
-				id INT AUTO_INCREMENT PRIMARY KEY,
-				data VARCHAR(255) NOT NULL,
-				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-			)
-		`
+// runRPCTest runs the workload over the StoreService connection to the Mattermost database
+func (p *Plugin) runRPCTest(opts testOptions) (TestResult, error) {
+	// Get database from StoreService
+	store := p.client.Store
+	var db *sql.DB
+	var err error
+	if opts.QueryLog {
+		// The run gets a pool of its own over the same RPC driver, so that its query log holds
+		// only its own statements.
+		opts.queryLog = newQueryLog()
+		db = sql.OpenDB(p.instrumentConnector(driver.NewConnector(p.Driver, true), opts.queryLog))
+		defer db.Close()
+	} else if db, err = p.rpcDB(); err != nil {
+		p.API.LogError("Failed to get database", "error", err)
+		return TestResult{}, fmt.Errorf("failed to get database: %v", err)
 	}
 
-	_, err := db.Exec(createTableSQL)
+	// Run test through helper method
+	result, err := p.runWorkload(db, store.DriverName(), opts)
 	if err != nil {
-		return result, fmt.Errorf("failed to create table: %v", err)
+		p.API.LogError("Test failed", "error", err)
+		return result, err
 	}
 
-	// Check if we need to insert data
-	var count int
-	countSQL := "SELECT COUNT(*) FROM plugin_test_rpc"
-	err = db.QueryRow(countSQL).Scan(&count)
+	// Set connection type
+	result.ConnType = "rpc"
+
+	return result, nil
+}
+
+// runRawTest runs the workload over a direct connection to the database, tuned by pool
+func (p *Plugin) runRawTest(opts testOptions, pool poolSettings) (TestResult, error) {
+	if opts.QueryLog {
+		opts.queryLog = newQueryLog()
+	}
+	db, driverName, err := p.openRawConnection(opts)
 	if err != nil {
-		return result, fmt.Errorf("failed to check record count: %v", err)
+		p.API.LogError("Failed to connect to database directly", "error", err)
+		return TestResult{}, fmt.Errorf("failed to connect to database: %v", err)
 	}
+	defer db.Close()
 
-	// Insert records if needed
-	if count < totalRecords {
-		p.API.LogInfo(fmt.Sprintf("Inserting records: %d of %d", count, totalRecords))
-		startInsert := time.Now()
+	pool.apply(db)
 
-		// Use transaction for faster inserts
-		tx, err := db.Begin()
-		if err != nil {
-			return result, fmt.Errorf("failed to begin transaction: %v", err)
-		}
+	// Run test through helper method
+	result, err := p.runWorkload(db, driverName, opts)
+	if err != nil {
+		p.API.LogError("Test failed", "error", err)
+		return result, err
+	}
 
-		var insertStmt *sql.Stmt
-		if driverName == "postgres" {
-			insertStmt, err = tx.Prepare("INSERT INTO plugin_test_rpc (data) VALUES ($1)")
-		} else {
-			insertStmt, err = tx.Prepare("INSERT INTO plugin_test_rpc (data) VALUES (?)")
+	// Set connection type
+	result.ConnType = "raw"
+	pool.report(db, &result)
+
+	return result, nil
+}
+
+// runTest runs one benchmark over connType with the options carried by r's query params, as the
+// corresponding endpoint would.
+func (p *Plugin) runTest(connType string, r *http.Request) (TestResult, error) {
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		return TestResult{}, err
+	}
+
+	switch connType {
+	case "rpc":
+		if opts.Bulk != "" {
+			return TestResult{}, fmt.Errorf("bulk loading is only supported on raw connections")
+		}
+		if opts.DSN != "" {
+			return TestResult{}, fmt.Errorf("an alternate dsn is only supported on raw connections")
 		}
+		return p.runRPCTest(opts)
+	case "raw":
+		if !p.rawModeEnabled() {
+			return TestResult{}, errRawModeDisabled
+		}
+		return p.runRawTest(opts, parsePoolSettings(r))
+	default:
+		return TestResult{}, fmt.Errorf("unknown connection type %q", connType)
+	}
+}
+
+// runWorkload runs the workload selected by opts.Mode with a given DB connection, alongside any
+// requested background noise, unless read-only mode forbids it. Seeded data is recorded in the
+// dataset registry, and query-phase runs referencing a dataset are refused unless the tables
+// still hold exactly that data. Unless opts.KeepData is set, phase=all runs drop their tables
+// afterwards, whether or not they succeed, and cancelled seeding runs always drop the tables
+// they were filling.
+func (p *Plugin) runWorkload(db *sql.DB, driverName string, opts testOptions) (result TestResult, err error) {
+	if err := p.checkReadOnly(opts); err != nil {
+		return TestResult{}, err
+	}
+	if err := checkSQLiteMode(driverName, opts); err != nil {
+		return TestResult{}, err
+	}
 
+	if opts.Profile == profileCPU {
+		stop, err := startCPUProfile()
 		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				p.API.LogError("Failed to rollback transaction", "error", rbErr)
-			}
-			return result, fmt.Errorf("failed to prepare statement: %v", err)
+			return TestResult{}, err
 		}
-		defer insertStmt.Close()
-
-		for i := count; i < totalRecords; i++ {
-			_, err = insertStmt.Exec(fmt.Sprintf("Test data %d", i))
-			if err != nil {
-				if rbErr := tx.Rollback(); rbErr != nil {
-					p.API.LogError("Failed to rollback transaction", "error", rbErr)
-				}
-				return result, fmt.Errorf("failed to insert row %d: %v", i, err)
+		defer func() {
+			result.Profile, result.profileData = profileCPU, stop()
+		}()
+	}
+	if opts.queryLog != nil {
+		defer func() {
+			result.QueryLog, result.queryLogData = opts.queryLog.finish()
+		}()
+	}
+
+	if opts.writes() {
+		p.trackTables(opts, cleanupTables(opts)...)
+	}
+	if opts.dropsData() {
+		defer func() {
+			result.DroppedTables = p.dropTables(db, opts)
+		}()
+	} else if opts.Phase != phaseQuery {
+		// A cancelled seeding run leaves a partial dataset that no later run could use.
+		defer func() {
+			if errors.Is(err, errRunCancelled) {
+				result.DroppedTables = p.dropTables(db, opts)
 			}
-		}
+		}()
+	}
 
-		err = tx.Commit()
+	if opts.Dataset != "" {
+		resolved, err := p.resolveDataset(db, driverName, opts)
 		if err != nil {
-			return result, fmt.Errorf("failed to commit transaction: %v", err)
+			return TestResult{}, err
 		}
-
-		result.InsertTimeSeconds = time.Since(startInsert).Seconds()
-	} else {
-		p.API.LogInfo(fmt.Sprintf("Table already has %d or more records", totalRecords))
+		opts = resolved
 	}
 
-	// Query the table in batches and measure total time
-	startTotalQuery := time.Now()
+	flavor, version, err := detectFlavor(db, driverName)
+	if err != nil {
+		return TestResult{}, err
+	}
+	opts.Flavor = flavor
 
-	// Add page size to result for reference
-	result.PageSize = batchSize
+	if opts.Reset {
+		if err := resetTables(db, driverName, opts); err != nil {
+			return TestResult{}, err
+		}
+	}
 
-	for offset := 0; offset < totalRecords; offset += batchSize {
-		var rows *sql.Rows
-		var err error
+	// Server statistics are informational, so a database refusing them does not fail the run.
+	statsBefore, err := snapshotServerStats(db, driverName, opts)
+	if err != nil {
+		p.API.LogWarn("Failed to snapshot server statistics", "error", err)
+	}
 
-		// Calculate limit - ensure we don't exceed total records
-		limit := batchSize
-		if offset+batchSize > totalRecords {
-			limit = totalRecords - offset
+	var noise *noiseGenerator
+	if opts.NoiseOps > 0 {
+		noise, err = p.startNoise(opts)
+		if err != nil {
+			return TestResult{}, err
 		}
+	}
 
-		if driverName == "postgres" {
-			rows, err = db.Query("SELECT id, data FROM plugin_test_rpc ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	result, err = p.runModeWithRetries(db, driverName, opts)
+	if err == nil && opts.Phase != phaseSeed {
+		if opts.Iterations > 1 {
+			err = p.repeatQueries(db, driverName, opts, &result)
+		} else if opts.Duration > 0 {
+			err = p.repeatQueriesFor(db, driverName, opts, &result)
+		}
+	}
+	if noise != nil {
+		noise.stop(&result)
+	}
+	// Workloads wrap the errors of their checkpoints, so report cancellation plainly.
+	if err != nil && opts.cancelled() != nil {
+		err = errRunCancelled
+	}
+	if statsBefore != nil {
+		if statsAfter, statsErr := snapshotServerStats(db, driverName, opts); statsErr != nil {
+			p.API.LogWarn("Failed to snapshot server statistics", "error", statsErr)
 		} else {
-			rows, err = db.Query("SELECT id, data FROM plugin_test_rpc ORDER BY id LIMIT ? OFFSET ?", limit, offset)
+			result.ServerStats = serverStatsDelta(statsBefore, statsAfter)
 		}
+	}
+	result.DatabaseFlavor = flavor
+	result.DatabaseVersion = version
+	result.Reset = opts.Reset
+	result.Tags = opts.Tags
+	result.Environment = p.environment(driverName)
+	if err != nil {
+		return result, err
+	}
 
-		if err != nil {
-			return result, fmt.Errorf("failed to query rows at offset %d: %v", offset, err)
-		}
+	if opts.Explain && opts.Phase != phaseSeed {
+		result.Explains = p.explainWorkload(db, driverName, opts)
+	}
 
-		// Read all rows to measure full query time
-		for rows.Next() {
-			var id int
-			var data string
-			if err := rows.Scan(&id, &data); err != nil {
-				rows.Close()
-				return result, fmt.Errorf("failed to scan row: %v", err)
-			}
+	result.DatasetFingerprint = opts.Dataset
+	if opts.Phase != phaseQuery && opts.seedsDataset() && !opts.dropsData() {
+		fingerprint, err := p.registerDataset(db, driverName, opts)
+		if err != nil {
+			p.API.LogError("Failed to register dataset", "error", err)
 		}
-		rows.Close()
+		result.DatasetFingerprint = fingerprint
 	}
 
-	// Calculate total query time
-	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
-	result.RecordsQueried = totalRecords
+	sizes, err := measureTableSizes(db, driverName, opts)
+	if err != nil {
+		p.API.LogWarn("Failed to measure table sizes", "error", err)
+	}
+	result.TableSizes = sizes
 
 	return result, nil
 }
 
+// runMode runs the workload selected by opts.Mode once.
+func (p *Plugin) runMode(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	switch opts.Mode {
+	case modeBlob:
+		return p.runBlobTest(db, driverName, opts)
+	case modeJoin:
+		return p.runJoinTest(db, driverName, opts)
+	case modeAggregate:
+		return p.runAggregateTest(db, driverName, opts)
+	case modeSearch:
+		return p.runSearchTest(db, driverName, opts)
+	case modeJSON:
+		return p.runJSONTest(db, driverName, opts)
+	case modePointLookup:
+		return p.runPointLookupTest(db, driverName, opts)
+	case modePlanCompare:
+		return p.runPlanCompareTest(db, driverName, opts)
+	case modeSavepoint:
+		return p.runSavepointTest(db, driverName, opts)
+	case modeDeadlock:
+		return p.runDeadlockTest(db, driverName, opts)
+	case modeRowLock:
+		return p.runRowLockTest(db, driverName, opts)
+	case modeTimeout:
+		return p.runTimeoutTest(db, driverName, opts)
+	case modeFullScan:
+		return p.runFullScanTest(db, driverName, opts)
+	case modeWide:
+		return p.runWideTest(db, driverName, opts)
+	case modeNulls:
+		return p.runNullsTest(db, driverName, opts)
+	case modeText:
+		return p.runTextTest(db, driverName, opts)
+	case modeTimestamps:
+		return p.runTimestampsTest(db, driverName, opts)
+	case modeNumeric:
+		return p.runNumericTest(db, driverName, opts)
+	case modeNoop:
+		return p.runNoopTest(db, driverName, opts)
+	case modeSquirrel:
+		return p.runSquirrelTest(db, driverName, opts)
+	case modeGorm:
+		return p.runGormTest(db, driverName, opts)
+	default:
+		return p.runDatabaseTest(db, driverName, opts)
+	}
+}
+
+// publishResult hands a completed run to the configured integrations in the background, so
+// slow or unavailable integrations never delay the response.
+func (p *Plugin) publishResult(result TestResult) {
+	go func() {
+		if err := p.publishResultToBoards(result); err != nil {
+			p.API.LogWarn("Failed to publish result to Boards", "error", err)
+		}
+	}()
+}
+
 func respondWithJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
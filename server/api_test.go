@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminAPI returns an API mock granting system admin to the user "admin" only.
+func adminAPI() *plugintest.API {
+	api := &plugintest.API{}
+	api.On("HasPermissionTo", "admin", model.PermissionManageSystem).Return(true)
+	api.On("HasPermissionTo", "user", model.PermissionManageSystem).Return(false)
+	return api
+}
+
+// adminRequest returns a request to target from the system admin "admin".
+func adminRequest(method, target string) *http.Request {
+	r := httptest.NewRequest(method, target, nil)
+	r.Header.Set("Mattermost-User-ID", "admin")
+	return r
+}
+
+func TestSystemAdminRequired(t *testing.T) {
+	p := Plugin{}
+	p.SetAPI(adminAPI())
+	p.setConfiguration(&configuration{})
+
+	serve := func(target, userID string) int {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if userID != "" {
+			r.Header.Set("Mattermost-User-ID", userID)
+		}
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("/api/v1/test", ""))
+	assert.Equal(t, http.StatusForbidden, serve("/api/v1/test", "user"))
+	assert.Equal(t, http.StatusForbidden, serve("/api/v1/test_raw?dsn_options=connect_timeout%3D5", "user"))
+	assert.Equal(t, http.StatusForbidden, serve("/api/v1/datasets", "user"))
+
+	// Status is open to everyone.
+	assert.Equal(t, http.StatusOK, serve("/api/v1/status", ""))
+	assert.Equal(t, http.StatusOK, serve("/api/v1/status", "user"))
+}
+
+func TestParsePoolSettings(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test_raw", nil)
+
+		settings := parsePoolSettings(r)
+
+		assert.Equal(t, poolSettings{MaxIdleConns: defaultMaxIdleConns}, settings)
+	})
+
+	t.Run("valid values", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test_raw?max_open_conns=10&max_idle_conns=0&conn_max_lifetime=30s", nil)
+
+		settings := parsePoolSettings(r)
+
+		assert.Equal(t, poolSettings{MaxOpenConns: 10, MaxIdleConns: 0, ConnMaxLifetime: 30 * time.Second}, settings)
+	})
+
+	t.Run("invalid values are ignored", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test_raw?max_open_conns=-1&max_idle_conns=abc&conn_max_lifetime=soon", nil)
+
+		settings := parsePoolSettings(r)
+
+		assert.Equal(t, poolSettings{MaxIdleConns: defaultMaxIdleConns}, settings)
+	})
+}
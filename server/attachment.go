@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Colors of result attachments: red for a run that failed or regressed, green for one that held
+// up against its baseline, and blue for one with nothing to compare against.
+const (
+	attachmentColorRegressed = "#d24b4e"
+	attachmentColorPassed    = "#3db887"
+	attachmentColorNeutral   = "#1c58d9"
+)
+
+// resultAttachment summarizes result as a message attachment, colored by its regression status,
+// with its key parameters and timings as fields so it can be read at a glance. The title links
+// to reportURL, the full report of the run, when it is not empty.
+func resultAttachment(result TestResult, reportURL string) *model.SlackAttachment {
+	title := fmt.Sprintf("%s benchmark", result.ConnType)
+	if result.Mode != "" {
+		title += ": " + result.Mode
+	}
+	if result.Label != "" {
+		title += " (" + result.Label + ")"
+	}
+
+	attachment := &model.SlackAttachment{
+		Fallback:  title,
+		Color:     attachmentColorNeutral,
+		Title:     title,
+		TitleLink: reportURL,
+		Text:      result.Error,
+	}
+	if result.RunID != "" {
+		attachment.Footer = "Run " + result.RunID
+	}
+
+	addField := func(title, value string, short bool) {
+		attachment.Fields = append(attachment.Fields, &model.SlackAttachmentField{Title: title, Value: value, Short: model.SlackCompatibleBool(short)})
+	}
+	if result.RecordsQueried > 0 {
+		addField("Records queried", fmt.Sprint(result.RecordsQueried), true)
+	}
+	if result.PageSize > 0 {
+		addField("Page size", fmt.Sprint(result.PageSize), true)
+	}
+	for _, timing := range resultTimings(result) {
+		addField(timing[0], timing[1], true)
+	}
+	if result.LookupLatency != nil {
+		addField("Lookup p99", fmt.Sprintf("%.2f ms", result.LookupLatency.P99), true)
+	}
+
+	switch {
+	case result.Error != "":
+		attachment.Color = attachmentColorRegressed
+	case result.Regression != nil && result.Regression.Regressed:
+		attachment.Color = attachmentColorRegressed
+		attachment.Fallback += ": regressed"
+	case result.Regression != nil:
+		attachment.Color = attachmentColorPassed
+	}
+
+	if regression := result.Regression; regression != nil && len(regression.Metrics) > 0 {
+		var regressed []string
+		for _, metric := range regression.Metrics {
+			if metric.Regressed {
+				regressed = append(regressed, fmt.Sprintf("`%s` %+.1f%%", metric.Name, metric.PercentSlower))
+			}
+		}
+
+		summary := fmt.Sprintf("No metric slower than baseline run `%s` by more than %g%%.", regression.BaselineRunID, regression.ThresholdPercent)
+		if len(regressed) > 0 {
+			summary = fmt.Sprintf("Slower than baseline run `%s` by more than %g%%: %s", regression.BaselineRunID, regression.ThresholdPercent, strings.Join(regressed, ", "))
+		}
+		addField("Regression", summary, false)
+	}
+
+	return attachment
+}
+
+// resultPost returns a post of message with result attached, linking to the report of the run.
+func (p *Plugin) resultPost(message string, result TestResult) *model.Post {
+	post := &model.Post{UserId: p.botUserID, Message: message}
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{resultAttachment(result, p.reportURL(result.RunID))})
+	return post
+}
+
+// reportURL returns the absolute URL of the HTML report of the stored run runID, or an empty
+// string if the run was not stored or the URL cannot be determined, such as when the server
+// Site URL is not configured.
+func (p *Plugin) reportURL(runID string) string {
+	if runID == "" {
+		return ""
+	}
+
+	config := p.API.GetConfig()
+	if config == nil || config.ServiceSettings.SiteURL == nil || *config.ServiceSettings.SiteURL == "" {
+		return ""
+	}
+	manifest := p.pluginManifest()
+	if manifest == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/plugins/%s/api/v1/results/%s/report",
+		strings.TrimSuffix(*config.ServiceSettings.SiteURL, "/"), manifest.Id, url.PathEscape(runID))
+}
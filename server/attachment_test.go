@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultAttachment(t *testing.T) {
+	t.Run("no baseline", func(t *testing.T) {
+		attachment := resultAttachment(TestResult{
+			ConnType: "rpc", Mode: "scan", Label: "nightly", RunID: "run1",
+			RecordsQueried: 1000, TotalQueryTimeSeconds: 1.5, LookupLatency: &LatencyMillis{P99: 2.5},
+		}, "https://example.com/report")
+
+		assert.Equal(t, "rpc benchmark: scan (nightly)", attachment.Title)
+		assert.Equal(t, "https://example.com/report", attachment.TitleLink)
+		assert.Equal(t, attachmentColorNeutral, attachment.Color)
+		assert.Equal(t, "Run run1", attachment.Footer)
+		assert.Equal(t, []*model.SlackAttachmentField{
+			{Title: "Records queried", Value: "1000", Short: true},
+			{Title: "Total query time", Value: "1.500 s", Short: true},
+			{Title: "Lookup p99", Value: "2.50 ms", Short: true},
+		}, attachment.Fields)
+	})
+
+	t.Run("regressed", func(t *testing.T) {
+		attachment := resultAttachment(TestResult{ConnType: "raw", Regression: &Regression{
+			BaselineRunID: "base", ThresholdPercent: 20, Regressed: true,
+			Metrics: []RegressionMetric{
+				{Name: "total_query_time_seconds", PercentSlower: 50, Regressed: true},
+				{Name: "insert_time_seconds", PercentSlower: -10},
+			},
+		}}, "")
+
+		assert.Equal(t, attachmentColorRegressed, attachment.Color)
+		assert.Equal(t, "raw benchmark: regressed", attachment.Fallback)
+		require.Len(t, attachment.Fields, 1)
+		assert.Equal(t, "Slower than baseline run `base` by more than 20%: `total_query_time_seconds` +50.0%", attachment.Fields[0].Value)
+	})
+
+	t.Run("passed", func(t *testing.T) {
+		attachment := resultAttachment(TestResult{ConnType: "raw", Regression: &Regression{
+			BaselineRunID: "base", ThresholdPercent: 20, Metrics: []RegressionMetric{{Name: "insert_time_seconds", PercentSlower: 5}},
+		}}, "")
+
+		assert.Equal(t, attachmentColorPassed, attachment.Color)
+		assert.Equal(t, "No metric slower than baseline run `base` by more than 20%.", attachment.Fields[0].Value)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		attachment := resultAttachment(TestResult{ConnType: "raw", Error: "connection refused"}, "")
+		assert.Equal(t, attachmentColorRegressed, attachment.Color)
+		assert.Equal(t, "connection refused", attachment.Text)
+	})
+}
+
+func TestReportURL(t *testing.T) {
+	bundlePath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bundlePath, "plugin.json"), []byte(`{"id": "com.mattermost.test-rpc-database"}`), 0600))
+
+	api := adminAPI()
+	api.On("GetBundlePath").Return(bundlePath, nil)
+	api.On("GetConfig").Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewPointer("https://chat.example.com/")}}).Once()
+	api.On("GetConfig").Return(&model.Config{})
+	p := Plugin{}
+	p.SetAPI(api)
+
+	assert.Equal(t, "", p.reportURL(""))
+	assert.Equal(t, "https://chat.example.com/plugins/com.mattermost.test-rpc-database/api/v1/results/run1/report", p.reportURL("run1"))
+	assert.Equal(t, "", p.reportURL("run1"), "no Site URL")
+}
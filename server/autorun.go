@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Environment variables driving the headless autorun mode, which lets the plugin be dropped into
+// automated load-test environments without any HTTP interaction.
+const (
+	// autorunPresetEnv names the preset to run once on activation.
+	autorunPresetEnv = "TEST_RPC_DATABASE_AUTORUN"
+
+	// autorunParamsEnv optionally holds query params applied on top of every leg of the preset.
+	autorunParamsEnv = "TEST_RPC_DATABASE_AUTORUN_PARAMS"
+
+	// autorunOutputEnv is the file the report is written to. When unset, the report is printed to
+	// stdout on a single line prefixed with autorunMarker.
+	autorunOutputEnv = "TEST_RPC_DATABASE_AUTORUN_OUTPUT"
+
+	// autorunMarker prefixes the report line printed to stdout so it can be grepped from logs.
+	autorunMarker = "TEST_RPC_DATABASE_AUTORUN_RESULT"
+)
+
+// autorunLeg is one benchmark run within a preset.
+type autorunLeg struct {
+	// Name identifies the leg in the report and in the DependsOn of later legs.
+	Name string
+
+	ConnType string
+	Params   string
+
+	// DependsOn names earlier legs that must succeed for this one to run, typically the leg
+	// seeding the data it queries.
+	DependsOn []string
+}
+
+// Outcomes of an autorun step.
+const (
+	stepSucceeded = "succeeded"
+	stepFailed    = "failed"
+	stepSkipped   = "skipped"
+)
+
+// seededComparison returns the legs seeding a dataset over the RPC connection once and then
+// querying it over both connection types, with params applied to all three. The dataset is seeded
+// over RPC so that it is available even while raw mode is disabled.
+func seededComparison(name, params string) []autorunLeg {
+	seed := "seed-" + name
+	seedParams := "phase=seed"
+	if params != "" {
+		seedParams = params + "&" + seedParams
+	}
+
+	return append([]autorunLeg{{Name: seed, ConnType: "rpc", Params: seedParams}}, queryComparison(name, seed, params)...)
+}
+
+// queryComparison returns the legs querying the dataset seeded by the seed leg over both
+// connection types, with params applied to both.
+func queryComparison(name, seed, params string) []autorunLeg {
+	if params != "" {
+		params += "&"
+	}
+
+	return []autorunLeg{
+		{Name: "rpc-" + name, ConnType: "rpc", Params: params + "phase=query", DependsOn: []string{seed}},
+		{Name: "raw-" + name, ConnType: "raw", Params: params + "phase=query", DependsOn: []string{seed}},
+	}
+}
+
+// autorunPresets are the benchmark suites available to the autorun mode.
+var autorunPresets = map[string][]autorunLeg{
+	"quick":   seededComparison("scan", "records=10000&page_size=1000"),
+	"default": seededComparison("scan", ""),
+	"full": slices.Concat(
+		seededComparison("scan", "mode=scan"),
+		queryComparison("scan_keyset", "seed-scan", "mode=scan&pagination=keyset"),
+		queryComparison("scan_random", "seed-scan", "mode=scan&pagination=random"),
+		// Cursor pagination is Postgres-only, so these legs fail on MySQL.
+		queryComparison("scan_cursor", "seed-scan", "mode=scan&pagination=cursor"),
+		seededComparison("blob", "mode=blob"),
+		seededComparison("join", "mode=join"),
+		seededComparison("aggregate", "mode=aggregate"),
+		seededComparison("search", "mode=search"),
+		seededComparison("json", "mode=json"),
+		seededComparison("point_lookup", "mode=point_lookup"),
+		seededComparison("plan_compare", "mode=plan_compare"),
+		seededComparison("fullscan", "mode=fullscan"),
+		seededComparison("wide", "mode=wide"),
+		seededComparison("nulls", "mode=nulls"),
+		seededComparison("text", "mode=text"),
+		seededComparison("text_utf8mb4", "mode=text&charset=utf8mb4"),
+		seededComparison("timestamps", "mode=timestamps"),
+		seededComparison("numeric", "mode=numeric"),
+		seededComparison("savepoint", "mode=savepoint"),
+		seededComparison("deadlock", "mode=deadlock"),
+		seededComparison("row_lock", "mode=row_lock"),
+	),
+}
+
+// AutorunStep reports the outcome of one leg of an autorun.
+type AutorunStep struct {
+	Name      string      `json:"name"`
+	ConnType  string      `json:"conn_type"`
+	DependsOn []string    `json:"depends_on,omitempty"`
+	Status    string      `json:"status"`
+	Error     string      `json:"error,omitempty"`
+	Result    *TestResult `json:"result,omitempty"`
+}
+
+// AutorunReport is the output of an autorun.
+type AutorunReport struct {
+	Preset     string        `json:"preset"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Steps      []AutorunStep `json:"steps"`
+}
+
+// startAutorun runs the preset named by the environment, if any, in the background. The plugin
+// is otherwise left idle.
+func (p *Plugin) startAutorun() {
+	preset := strings.TrimSpace(os.Getenv(autorunPresetEnv))
+	if preset == "" {
+		return
+	}
+
+	legs, ok := autorunPresets[preset]
+	if !ok {
+		names := make([]string, 0, len(autorunPresets))
+		for name := range autorunPresets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		p.API.LogError("Unknown autorun preset", "preset", preset, "available", strings.Join(names, ", "))
+		return
+	}
+
+	go func() {
+		report := p.runAutorun(preset, legs, os.Getenv(autorunParamsEnv))
+		if err := p.writeAutorunReport(report, os.Getenv(autorunOutputEnv)); err != nil {
+			p.API.LogError("Failed to write autorun report", "error", err)
+		}
+	}()
+}
+
+// runAutorun runs every leg of a preset in order, applying extraParams on top of each.
+func (p *Plugin) runAutorun(preset string, legs []autorunLeg, extraParams string) AutorunReport {
+	report := AutorunReport{
+		Preset:    preset,
+		StartedAt: time.Now(),
+	}
+
+	p.API.LogInfo("Starting autorun", "preset", preset)
+	report.Steps = runAutorunLegs(legs, p.rawModeEnabled(), func(leg autorunLeg) (TestResult, error) {
+		r, err := autorunRequest(leg.Params, extraParams)
+		if err != nil {
+			return TestResult{}, err
+		}
+
+		runID := model.NewId()
+		ctx, end, err := p.beginRun(context.Background(), kvstore.Job{ID: runID, Endpoint: "autorun:" + leg.Name, Params: r.URL.RawQuery})
+		if err != nil {
+			return TestResult{}, err
+		}
+
+		result, err := p.runTest(leg.ConnType, r.WithContext(ctx))
+		if err == nil {
+			p.saveRun(runID, leg.ConnType, r.URL.Query(), "", &result)
+		}
+		end(err)
+
+		return result, err
+	})
+	report.FinishedAt = time.Now()
+	p.API.LogInfo("Finished autorun", "preset", preset, "duration", report.FinishedAt.Sub(report.StartedAt).String())
+
+	return report
+}
+
+// runAutorunLegs runs each leg in order with run. A failed leg never stops the autorun: legs
+// depending on it are skipped, while independent legs still run. Unless rawMode is set, raw legs
+// are skipped too, so that the RPC legs still report while raw mode is disabled.
+func runAutorunLegs(legs []autorunLeg, rawMode bool, run func(autorunLeg) (TestResult, error)) []AutorunStep {
+	status := make(map[string]string, len(legs))
+	steps := make([]AutorunStep, 0, len(legs))
+
+	for _, leg := range legs {
+		step := AutorunStep{
+			Name:      leg.Name,
+			ConnType:  leg.ConnType,
+			DependsOn: leg.DependsOn,
+		}
+
+		if leg.ConnType == "raw" && !rawMode {
+			step.Status = stepSkipped
+			step.Error = errRawModeDisabled.Error()
+		}
+		for _, dependency := range leg.DependsOn {
+			if step.Status == "" && status[dependency] != stepSucceeded {
+				step.Status = stepSkipped
+				step.Error = fmt.Sprintf("dependency %s did not succeed", dependency)
+				break
+			}
+		}
+
+		if step.Status == "" {
+			result, err := run(leg)
+			if err != nil {
+				step.Status = stepFailed
+				step.Error = err.Error()
+			} else {
+				step.Status = stepSucceeded
+				step.Result = &result
+			}
+		}
+
+		status[leg.Name] = step.Status
+		steps = append(steps, step)
+	}
+
+	return steps
+}
+
+// autorunRequest builds a request carrying params overridden by extraParams, so the usual option
+// parsing applies unchanged.
+func autorunRequest(params, extraParams string) (*http.Request, error) {
+	query, err := url.ParseQuery(params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid preset params: %v", err)
+	}
+
+	extra, err := url.ParseQuery(extraParams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extra params: %v", err)
+	}
+	for key, values := range extra {
+		query[key] = values
+	}
+
+	return &http.Request{Method: http.MethodGet, URL: &url.URL{RawQuery: query.Encode()}}, nil
+}
+
+// writeAutorunReport writes report as JSON to path, or to stdout behind autorunMarker when path
+// is empty.
+func (p *Plugin) writeAutorunReport(report AutorunReport, path string) error {
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %v", err)
+	}
+
+	if path == "" {
+		fmt.Println(autorunMarker + " " + string(encoded))
+		return nil
+	}
+
+	if err := os.WriteFile(path, encoded, 0600); err != nil {
+		return fmt.Errorf("failed to write report to %s: %v", path, err)
+	}
+	p.API.LogInfo("Wrote autorun report", "path", path)
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutorunRequest(t *testing.T) {
+	r, err := autorunRequest("mode=blob&records=10", "records=20&label=loadtest")
+	require.NoError(t, err)
+
+	opts, err := parseTestOptions(r)
+	require.NoError(t, err)
+	assert.Equal(t, modeBlob, opts.Mode)
+	assert.Equal(t, 20, opts.Records)
+	assert.Equal(t, "loadtest", opts.Label)
+
+	_, err = autorunRequest("", "records=%zz")
+	assert.Error(t, err)
+}
+
+func TestAutorunPresetsParse(t *testing.T) {
+	for name, legs := range autorunPresets {
+		seen := map[string]bool{}
+		for _, leg := range legs {
+			r, err := autorunRequest(leg.Params, "")
+			require.NoError(t, err, name)
+			_, err = parseTestOptions(r)
+			assert.NoError(t, err, name)
+			assert.Contains(t, []string{"rpc", "raw"}, leg.ConnType, name)
+
+			for _, dependency := range leg.DependsOn {
+				assert.True(t, seen[dependency], "%s: %s depends on later or unknown leg %s", name, leg.Name, dependency)
+			}
+			assert.False(t, seen[leg.Name], "%s: duplicate leg %s", name, leg.Name)
+			seen[leg.Name] = true
+		}
+	}
+}
+
+func TestRunAutorunLegs(t *testing.T) {
+	legs := slices.Concat(seededComparison("scan", "mode=scan"), seededComparison("blob", "mode=blob"))
+	legs[1].Params = "invalid"
+
+	steps := runAutorunLegs(legs, true, func(leg autorunLeg) (TestResult, error) {
+		if leg.Name == "seed-blob" || leg.Params == "invalid" {
+			return TestResult{}, errors.New("broken")
+		}
+		return TestResult{ConnType: leg.ConnType}, nil
+	})
+
+	require.Len(t, steps, len(legs))
+	statuses := make([]string, 0, len(steps))
+	for _, step := range steps {
+		statuses = append(statuses, step.Status)
+	}
+	assert.Equal(t, []string{stepSucceeded, stepFailed, stepSucceeded, stepFailed, stepSkipped, stepSkipped}, statuses)
+
+	assert.Equal(t, "broken", steps[1].Error)
+	assert.Nil(t, steps[1].Result)
+	assert.Equal(t, "raw", steps[2].Result.ConnType)
+	assert.Equal(t, "dependency seed-blob did not succeed", steps[4].Error)
+}
+
+func TestRunAutorunLegsWithoutRawMode(t *testing.T) {
+	// Raw mode is disabled by default, which must still leave the default preset reporting RPC
+	// results.
+	steps := runAutorunLegs(autorunPresets["default"], (&configuration{}).EnableRawMode, func(leg autorunLeg) (TestResult, error) {
+		if leg.ConnType == "raw" {
+			return TestResult{}, errRawModeDisabled
+		}
+		return TestResult{ConnType: leg.ConnType}, nil
+	})
+
+	require.Len(t, steps, 3)
+	assert.Equal(t, "seed-scan", steps[0].Name)
+	assert.Equal(t, stepSucceeded, steps[0].Status)
+	assert.Equal(t, "rpc-scan", steps[1].Name)
+	assert.Equal(t, stepSucceeded, steps[1].Status)
+	assert.Equal(t, "rpc", steps[1].Result.ConnType)
+	assert.Equal(t, "raw-scan", steps[2].Name)
+	assert.Equal(t, stepSkipped, steps[2].Status)
+	assert.Equal(t, errRawModeDisabled.Error(), steps[2].Error)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	// defaultBlobRecords keeps the default blob dataset small enough to seed in reasonable time.
+	defaultBlobRecords = 1000
+
+	// defaultPayloadBytes is the size of each blob when payload_bytes is not given.
+	defaultPayloadBytes = 64 * 1024
+
+	// minPayloadBytes and maxPayloadBytes bound the payload_bytes query param.
+	minPayloadBytes = 1024
+	maxPayloadBytes = 1024 * 1024
+)
+
+// runBlobTest seeds plugin_test_rpc_blob with opts.Records binary payloads of opts.PayloadBytes
+// each and pages through them, measuring how each connection type handles large values.
+// Rows of different payload sizes share the table and are told apart by payload_bytes.
+func (p *Plugin) runBlobTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:        opts.Label,
+		Mode:         modeBlob,
+		Phase:        opts.Phase,
+		PageSize:     opts.PageSize,
+		PayloadBytes: opts.PayloadBytes,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedBlobTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryBlobTable(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// seedBlobTable creates plugin_test_rpc_blob if needed and tops it up to opts.Records rows of
+// opts.PayloadBytes, returning the number of rows inserted and the time spent inserting.
+func (p *Plugin) seedBlobTable(db *sql.DB, driverName string, opts testOptions) (int, time.Duration, error) {
+	var createTableSQL []string
+	if driverName == "postgres" {
+		createTableSQL = []string{`
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_blob (
+				id SERIAL PRIMARY KEY,
+				payload_bytes INTEGER NOT NULL,
+				payload BYTEA NOT NULL
+			)
+		`,
+			"CREATE INDEX IF NOT EXISTS idx_plugin_test_rpc_blob_size ON plugin_test_rpc_blob (payload_bytes, id)",
+		}
+	} else {
+		createTableSQL = []string{`
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_blob (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				payload_bytes INT NOT NULL,
+				payload LONGBLOB NOT NULL,
+				INDEX idx_plugin_test_rpc_blob_size (payload_bytes, id)
+			)
+		`}
+	}
+
+	for _, statement := range createTableSQL {
+		if _, err := db.Exec(opts.tagSQL(statement)); err != nil {
+			return 0, 0, fmt.Errorf("failed to create blob table: %v", err)
+		}
+	}
+
+	var count int
+	countSQL := rebind(driverName, "SELECT COUNT(*) FROM plugin_test_rpc_blob WHERE payload_bytes = ?")
+	if err := db.QueryRow(opts.tagSQL(countSQL), opts.PayloadBytes).Scan(&count); err != nil {
+		return 0, 0, fmt.Errorf("failed to check blob count: %v", err)
+	}
+
+	if count >= opts.Records {
+		return 0, 0, nil
+	}
+
+	p.API.LogInfo(fmt.Sprintf("Inserting blobs: %d of %d", count, opts.Records), "payload_bytes", opts.PayloadBytes)
+	startInsert := time.Now()
+
+	if err := p.insertBlobs(db, driverName, opts, count); err != nil {
+		return 0, 0, err
+	}
+
+	return opts.Records - count, time.Since(startInsert), nil
+}
+
+// blobPageSQL reads one page of the blobs of a payload size.
+const blobPageSQL = "SELECT id, payload FROM plugin_test_rpc_blob WHERE payload_bytes = ? ORDER BY id LIMIT ? OFFSET ?"
+
+// queryBlobTable pages through the blobs of opts.PayloadBytes, recording the rows and bytes
+// read and the resulting throughput on result.
+func queryBlobTable(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+
+	querySQL := opts.tagSQL(rebind(driverName, blobPageSQL))
+	for offset := 0; offset < opts.Records; offset += opts.PageSize {
+		limit := min(opts.PageSize, opts.Records-offset)
+
+		rows, err := db.Query(querySQL, opts.PayloadBytes, limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to query blobs at offset %d: %v", offset, err)
+		}
+
+		for rows.Next() {
+			var id int
+			var payload []byte
+			if err := rows.Scan(&id, &payload); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan blob: %v", err)
+			}
+			result.RecordsQueried++
+			result.BytesQueried += int64(len(payload))
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read blobs at offset %d: %v", offset, err)
+		}
+		rows.Close()
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
+
+// insertBlobs inserts random payloads into plugin_test_rpc_blob in a single transaction until it
+// holds opts.Records rows of opts.PayloadBytes, starting from the existing count.
+func (p *Plugin) insertBlobs(db *sql.DB, driverName string, opts testOptions, count int) error {
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	insertStmt, err := tx.Prepare(opts.tagSQL(rebind(driverName, "INSERT INTO plugin_test_rpc_blob (payload_bytes, payload) VALUES (?, ?)")))
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			p.API.LogError("Failed to rollback transaction", "error", rbErr)
+		}
+		return fmt.Errorf("failed to prepare statement: %v", err)
+	}
+	defer insertStmt.Close()
+
+	// Random bytes defeat compression (e.g. Postgres TOAST), so the full payload is transferred.
+	// Each row is stamped with its index to keep values distinct.
+	payload := make([]byte, opts.PayloadBytes)
+	random := rand.New(rand.NewSource(int64(opts.PayloadBytes)))
+	_, _ = random.Read(payload)
+
+	for i := count; i < opts.Records; i++ {
+		binary.BigEndian.PutUint64(payload, uint64(i))
+		if _, err := insertStmt.Exec(opts.PayloadBytes, payload); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				p.API.LogError("Failed to rollback transaction", "error", rbErr)
+			}
+			return fmt.Errorf("failed to insert blob %d: %v", i, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
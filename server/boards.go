@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// boardsPluginID is the plugin ID of Mattermost Boards (formerly Focalboard).
+const boardsPluginID = "focalboard"
+
+// boardsCardProperty is the subset of a Boards card property template needed to map metrics onto
+// a board's properties.
+type boardsCardProperty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// boardsBoard is the subset of a Boards board needed to create cards on it.
+type boardsBoard struct {
+	ID             string               `json:"id"`
+	CardProperties []boardsCardProperty `json:"cardProperties"`
+}
+
+// boardsCard is the payload for creating a card on a board.
+type boardsCard struct {
+	BoardID    string         `json:"boardId"`
+	Title      string         `json:"title"`
+	Icon       string         `json:"icon"`
+	Properties map[string]any `json:"properties"`
+}
+
+// boardsMetrics returns the result's key metrics keyed by the board property names they are
+// written to. Board properties are matched by name, case-insensitively, and metrics without a
+// matching property are skipped, so admins choose which metrics to track by shaping the board.
+func boardsMetrics(result TestResult) map[string]string {
+	return map[string]string{
+		"connection type":     result.ConnType,
+		"mode":                result.Mode,
+		"label":               result.Label,
+		"records":             fmt.Sprintf("%d", result.RecordsQueried),
+		"page size":           fmt.Sprintf("%d", result.PageSize),
+		"insert time (s)":     fmt.Sprintf("%.3f", result.InsertTimeSeconds),
+		"query time (s)":      fmt.Sprintf("%.3f", result.TotalQueryTimeSeconds),
+		"rows per second":     fmt.Sprintf("%.0f", result.QueryRowsPerSecond),
+		"dataset fingerprint": result.DatasetFingerprint,
+	}
+}
+
+// publishResultToBoards creates a card for result on the configured board, if any.
+func (p *Plugin) publishResultToBoards(result TestResult) error {
+	boardID := strings.TrimSpace(p.getConfiguration().BoardsBoardID)
+	if boardID == "" {
+		return nil
+	}
+
+	var board boardsBoard
+	if err := p.pluginRequest(boardsPluginID, http.MethodGet, "/api/v2/boards/"+boardID, nil, &board); err != nil {
+		return errors.Wrap(err, "failed to get board")
+	}
+
+	metrics := boardsMetrics(result)
+	properties := map[string]any{}
+	for _, property := range board.CardProperties {
+		if value, ok := metrics[strings.ToLower(property.Name)]; ok && value != "" {
+			properties[property.ID] = value
+		}
+	}
+
+	title := fmt.Sprintf("%s %s benchmark %s", result.ConnType, result.Mode, time.Now().UTC().Format(time.RFC3339))
+	if result.Label != "" {
+		title = result.Label + ": " + title
+	}
+
+	card := boardsCard{
+		BoardID:    boardID,
+		Title:      title,
+		Icon:       "⏱️",
+		Properties: properties,
+	}
+	if err := p.pluginRequest(boardsPluginID, http.MethodPost, "/api/v2/boards/"+boardID+"/cards", card, nil); err != nil {
+		return errors.Wrap(err, "failed to create card")
+	}
+
+	return nil
+}
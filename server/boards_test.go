@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishResultToBoards(t *testing.T) {
+	t.Run("disabled without a board", func(t *testing.T) {
+		p := Plugin{}
+		p.setConfiguration(&configuration{})
+
+		assert.NoError(t, p.publishResultToBoards(TestResult{}))
+	})
+
+	t.Run("maps metrics onto board properties", func(t *testing.T) {
+		api := &plugintest.API{}
+		p := Plugin{botUserID: "bot-id"}
+		p.SetAPI(api)
+		p.setConfiguration(&configuration{BoardsBoardID: "board-id"})
+
+		board := `{"id": "board-id", "cardProperties": [{"id": "p1", "name": "Mode"}, {"id": "p2", "name": "query time (s)"}, {"id": "p3", "name": "Owner"}]}`
+		api.On("PluginHTTP", mock.MatchedBy(func(r *http.Request) bool {
+			return r.Method == http.MethodGet && r.URL.Path == "/focalboard/api/v2/boards/board-id"
+		})).Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(board))})
+
+		var card boardsCard
+		api.On("PluginHTTP", mock.MatchedBy(func(r *http.Request) bool {
+			return r.Method == http.MethodPost && r.URL.Path == "/focalboard/api/v2/boards/board-id/cards"
+		})).Run(func(args mock.Arguments) {
+			r := args.Get(0).(*http.Request)
+			assert.Equal(t, "bot-id", r.Header.Get("Mattermost-User-Id"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&card))
+		}).Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))})
+
+		err := p.publishResultToBoards(TestResult{ConnType: "rpc", Mode: modeScan, TotalQueryTimeSeconds: 1.5})
+
+		require.NoError(t, err)
+		assert.Equal(t, "board-id", card.BoardID)
+		assert.Equal(t, map[string]any{"p1": modeScan, "p2": "1.500"}, card.Properties)
+	})
+}
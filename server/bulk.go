@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Bulk load paths selectable with the bulk query param. They need driver-specific protocol
+// support and are therefore only available on raw connections.
+const (
+	// bulkCopy streams rows with the Postgres COPY FROM STDIN protocol.
+	bulkCopy = "copy"
+
+	// bulkLoadData streams rows with MySQL LOAD DATA LOCAL INFILE from an in-memory reader.
+	bulkLoadData = "load_data"
+)
+
+// bulkLoadTestRows tops plugin_test_rpc up to opts.Records rows from count using the bulk load
+// path selected by opts.Bulk.
+func bulkLoadTestRows(tx *sql.Tx, driverName string, opts testOptions, count int) error {
+	switch opts.Bulk {
+	case bulkCopy:
+		if driverName != "postgres" {
+			return fmt.Errorf("bulk=%s requires a postgres database", bulkCopy)
+		}
+		return copyTestRows(tx, opts, count)
+	case bulkLoadData:
+		if driverName != "mysql" {
+			return fmt.Errorf("bulk=%s requires a mysql database", bulkLoadData)
+		}
+		return loadDataTestRows(tx, opts, count)
+	default:
+		return fmt.Errorf("unsupported bulk load path %q", opts.Bulk)
+	}
+}
+
+// copyTestRows streams the missing rows into plugin_test_rpc with COPY FROM STDIN.
+func copyTestRows(tx *sql.Tx, opts testOptions, count int) error {
+	copyStmt, err := tx.Prepare(pq.CopyIn("plugin_test_rpc", "data"))
+	if err != nil {
+		return fmt.Errorf("failed to prepare copy: %v", err)
+	}
+	defer copyStmt.Close()
+
+	for i := count; i < opts.Records; i++ {
+		if err := opts.cancelled(); err != nil {
+			return err
+		}
+		if _, err := copyStmt.Exec(testData(i, opts.RowBytes)); err != nil {
+			return fmt.Errorf("failed to copy row %d: %v", i, err)
+		}
+	}
+
+	// An Exec without arguments flushes the buffered rows and completes the COPY.
+	if _, err := copyStmt.Exec(); err != nil {
+		return fmt.Errorf("failed to complete copy: %v", err)
+	}
+
+	return nil
+}
+
+// loadDataTestRows streams the missing rows into plugin_test_rpc with LOAD DATA LOCAL INFILE,
+// generating them on the fly through a registered reader so they never sit in memory at once.
+// The MySQL server must have local_infile enabled.
+func loadDataTestRows(tx *sql.Tx, opts testOptions, count int) error {
+	readerName := "plugin_test_rpc_" + model.NewId()
+
+	pipeReader, pipeWriter := io.Pipe()
+	mysql.RegisterReaderHandler(readerName, func() io.Reader { return pipeReader })
+	defer mysql.DeregisterReaderHandler(readerName)
+
+	go func() {
+		// Generated values never contain tabs, newlines or backslashes, so no escaping is needed.
+		// Failing the reader aborts the load, which is how a cancelled run stops it.
+		writer := bufio.NewWriter(pipeWriter)
+		for i := count; i < opts.Records; i++ {
+			if err := opts.cancelled(); err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+			if _, err := writer.WriteString(testData(i, opts.RowBytes) + "\n"); err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+		}
+		pipeWriter.CloseWithError(writer.Flush())
+	}()
+
+	loadSQL := fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE plugin_test_rpc FIELDS TERMINATED BY '\\t' LINES TERMINATED BY '\\n' (data)", readerName)
+	_, err := tx.Exec(opts.tagSQL(loadSQL))
+
+	// Unblock the generator if the server stopped reading early.
+	pipeReader.Close()
+
+	if err != nil {
+		return fmt.Errorf("failed to load data: %v", err)
+	}
+
+	return nil
+}
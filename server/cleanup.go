@@ -0,0 +1,42 @@
+package main
+
+import (
+	"database/sql"
+)
+
+// dropsData reports whether a run with opts drops its tables once finished. Only self-contained
+// phase=all runs clean up after themselves by default: phase=seed exists to leave data for later
+// phase=query runs, which in turn must not remove it from under each other.
+func (o testOptions) dropsData() bool {
+	return o.Phase == phaseAll && !o.KeepData
+}
+
+// cleanupTables returns the tables a run with opts creates, including the noise table.
+func cleanupTables(opts testOptions) []string {
+	tables := modeTables(opts)
+	if opts.NoiseOps > 0 {
+		tables = append(tables, "plugin_test_rpc_noise")
+	}
+
+	return tables
+}
+
+// dropTables drops the tables a run with opts created, so benchmarks do not permanently pollute
+// the schema, returning those dropped. Failures are logged rather than failing the run, whose
+// numbers are already measured.
+func (p *Plugin) dropTables(db *sql.DB, opts testOptions) []string {
+	var dropped []string
+	for _, table := range cleanupTables(opts) {
+		if _, err := db.Exec(opts.tagSQL("DROP TABLE IF EXISTS " + table)); err != nil {
+			p.API.LogError("Failed to drop test table", "table", table, "error", err)
+			continue
+		}
+		dropped = append(dropped, table)
+
+		if err := p.kvstore.DeleteObject(objectKindTable, table); err != nil {
+			p.API.LogWarn("Failed to remove dropped table from the registry", "table", table, "error", err)
+		}
+	}
+
+	return dropped
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropsData(t *testing.T) {
+	assert.True(t, testOptions{Phase: phaseAll}.dropsData())
+	assert.False(t, testOptions{Phase: phaseAll, KeepData: true}.dropsData())
+	assert.False(t, testOptions{Phase: phaseSeed}.dropsData())
+	assert.False(t, testOptions{Phase: phaseQuery}.dropsData())
+}
+
+func TestCleanupTables(t *testing.T) {
+	assert.Equal(t, []string{"plugin_test_rpc_blob"}, cleanupTables(testOptions{Mode: modeBlob}))
+	assert.Equal(t, []string{"plugin_test_rpc", "plugin_test_rpc_noise"}, cleanupTables(testOptions{Mode: modeScan, NoiseOps: 10}))
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+)
+
+// Plugin cluster event IDs used to fan benchmarks out to every node of an HA deployment.
+const (
+	// clusterEventPing asks every node to identify itself.
+	clusterEventPing = "benchmark_node_ping"
+
+	// clusterEventPong identifies a node in reply to a ping.
+	clusterEventPong = "benchmark_node_pong"
+
+	// clusterEventRun asks one node to run a benchmark.
+	clusterEventRun = "benchmark_node_run"
+
+	// clusterEventResult carries a node's benchmark results in reply to a run.
+	clusterEventResult = "benchmark_node_result"
+
+	// clusterEventCancel asks one node to cancel a job it is running.
+	clusterEventCancel = "benchmark_node_cancel"
+)
+
+const (
+	// clusterDiscoveryWindow is how long pongs are collected after a ping. Nodes that reply
+	// later are left out of the run.
+	clusterDiscoveryWindow = 2 * time.Second
+
+	// clusterRunTimeout bounds how long a node may take to return its results.
+	clusterRunTimeout = 15 * time.Minute
+)
+
+// NodeInfo identifies a cluster node running the plugin.
+type NodeInfo struct {
+	// ID is generated when the plugin activates, so it changes whenever the plugin restarts.
+	ID       string `json:"node_id"`
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// clusterMessage is the payload of every benchmark cluster event.
+type clusterMessage struct {
+	RequestID string   `json:"request_id"`
+	Node      NodeInfo `json:"node"`
+
+	// TargetNodeID is the node that must run the benchmark of a run event.
+	TargetNodeID string `json:"target_node_id,omitempty"`
+	Params       string `json:"params,omitempty"`
+
+	// ConnType restricts a run to one connection type, rather than both.
+	ConnType string `json:"conn_type,omitempty"`
+
+	// UserID is the user who requested a run, on whose behalf raw connections are audited.
+	UserID string `json:"user_id,omitempty"`
+
+	// RunID is the job a cancel event cancels, or the job a run event is a leg of, so that the
+	// node running the leg can be asked to cancel it.
+	RunID string `json:"run_id,omitempty"`
+
+	Results []TestResult `json:"results,omitempty"`
+}
+
+// NodeResult reports a benchmark run on one cluster node over both connection types.
+type NodeResult struct {
+	NodeInfo
+	Results []TestResult `json:"results,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// ClusterComparison reports a benchmark run on every node of the cluster.
+type ClusterComparison struct {
+	Label string       `json:"label,omitempty"`
+	Nodes []NodeResult `json:"nodes"`
+	Error string       `json:"error,omitempty"`
+}
+
+// newNodeInfo identifies the node the plugin is activating on.
+func newNodeInfo() NodeInfo {
+	hostname, _ := os.Hostname()
+	return NodeInfo{ID: model.NewId(), Hostname: hostname}
+}
+
+// TestDatabaseCluster runs the benchmark on every node of the cluster in turn, over both
+// connection types, since RPC overhead can differ per node depending on its placement relative
+// to the database. Nodes run one at a time so they never compete for the database.
+func (p *Plugin) TestDatabaseCluster(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, ClusterComparison{Error: err.Error()})
+		return
+	}
+	if err := p.checkReadOnly(opts); err != nil {
+		respondWithJSON(w, http.StatusForbidden, ClusterComparison{Error: err.Error()})
+		return
+	}
+
+	nodes, err := p.discoverNodes()
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, ClusterComparison{Error: err.Error()})
+		return
+	}
+
+	comparison := ClusterComparison{Label: opts.Label, Nodes: make([]NodeResult, 0, len(nodes))}
+	for _, node := range nodes {
+		nodeResult := NodeResult{NodeInfo: node}
+		if results, err := p.runOnNode(r, node, ""); err != nil {
+			nodeResult.Error = err.Error()
+		} else {
+			nodeResult.Results = results
+		}
+		comparison.Nodes = append(comparison.Nodes, nodeResult)
+	}
+
+	respondWithJSON(w, http.StatusOK, comparison)
+}
+
+// OnPluginClusterEvent answers the benchmark cluster events of other nodes.
+func (p *Plugin) OnPluginClusterEvent(_ *plugin.Context, ev model.PluginClusterEvent) {
+	var message clusterMessage
+	if err := json.Unmarshal(ev.Data, &message); err != nil {
+		p.API.LogWarn("Ignoring malformed cluster event", "id", ev.Id, "error", err)
+		return
+	}
+
+	switch ev.Id {
+	case clusterEventPing:
+		p.publishClusterMessage(clusterEventPong, clusterMessage{RequestID: message.RequestID, Node: p.nodeInfo})
+	case clusterEventRun:
+		if message.TargetNodeID != p.nodeInfo.ID {
+			return
+		}
+		// Runs take far longer than a hook should block.
+		go func() {
+			ctx, end := p.beginClusterLeg(message.RunID, message.Node.ID)
+			defer end()
+
+			p.publishClusterMessage(clusterEventResult, clusterMessage{
+				RequestID: message.RequestID,
+				Node:      p.nodeInfo,
+				Results:   p.runNodeBenchmark(ctx, message.Params, message.ConnType, message.UserID),
+			})
+		}()
+	case clusterEventCancel:
+		if message.TargetNodeID == p.nodeInfo.ID && !p.cancelLocalJob(message.RunID) {
+			p.API.LogWarn("Ignoring cancellation of unknown job", "run_id", message.RunID)
+		}
+	case clusterEventPong, clusterEventResult:
+		p.deliverClusterReply(message)
+	}
+}
+
+// publishClusterMessage broadcasts message to every other node as the event id.
+func (p *Plugin) publishClusterMessage(id string, message clusterMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		p.API.LogError("Failed to encode cluster event", "id", id, "error", err)
+		return
+	}
+
+	ev := model.PluginClusterEvent{Id: id, Data: data}
+	if err := p.API.PublishPluginClusterEvent(ev, model.PluginClusterEventSendOptions{SendType: model.PluginClusterEventSendTypeReliable}); err != nil {
+		p.API.LogError("Failed to publish cluster event", "id", id, "error", err)
+	}
+}
+
+// awaitClusterReplies registers a new request, returning its ID, the channel its replies are
+// delivered to, and a function that must be called once no more replies are wanted.
+func (p *Plugin) awaitClusterReplies() (string, <-chan clusterMessage, func()) {
+	requestID := model.NewId()
+	replies := make(chan clusterMessage, 64)
+
+	p.clusterLock.Lock()
+	if p.clusterReplies == nil {
+		p.clusterReplies = make(map[string]chan clusterMessage)
+	}
+	p.clusterReplies[requestID] = replies
+	p.clusterLock.Unlock()
+
+	return requestID, replies, func() {
+		p.clusterLock.Lock()
+		delete(p.clusterReplies, requestID)
+		p.clusterLock.Unlock()
+	}
+}
+
+// deliverClusterReply hands message to the request awaiting it. Replies to requests of other
+// nodes, or that arrive too late, are dropped.
+func (p *Plugin) deliverClusterReply(message clusterMessage) {
+	p.clusterLock.Lock()
+	defer p.clusterLock.Unlock()
+
+	replies, ok := p.clusterReplies[message.RequestID]
+	if !ok {
+		return
+	}
+	select {
+	case replies <- message:
+	default:
+	}
+}
+
+// discoverNodes returns this node and every other node that answers a ping within
+// clusterDiscoveryWindow, ordered by hostname.
+func (p *Plugin) discoverNodes() ([]NodeInfo, error) {
+	requestID, replies, done := p.awaitClusterReplies()
+	defer done()
+
+	data, err := json.Marshal(clusterMessage{RequestID: requestID, Node: p.nodeInfo})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ping: %v", err)
+	}
+	ev := model.PluginClusterEvent{Id: clusterEventPing, Data: data}
+	if err := p.API.PublishPluginClusterEvent(ev, model.PluginClusterEventSendOptions{SendType: model.PluginClusterEventSendTypeReliable}); err != nil {
+		return nil, fmt.Errorf("failed to ping cluster nodes: %v", err)
+	}
+
+	nodes := []NodeInfo{p.nodeInfo}
+	deadline := time.After(clusterDiscoveryWindow)
+	for {
+		select {
+		case reply := <-replies:
+			nodes = append(nodes, reply.Node)
+		case <-deadline:
+			sort.Slice(nodes, func(i, j int) bool { return nodes[i].Hostname < nodes[j].Hostname })
+			return nodes, nil
+		}
+	}
+}
+
+// runOnNode runs the benchmark requested by r on node, over connType or both connection types
+// when empty, on behalf of the user who requested it. Once the context of r, carrying the ID of
+// the run the benchmark is a leg of, is cancelled, the node is asked to cancel the leg, and its
+// results are still awaited so the run lock is held until it has stopped.
+func (p *Plugin) runOnNode(r *http.Request, node NodeInfo, connType string) ([]TestResult, error) {
+	ctx := r.Context()
+	if ctx.Err() != nil {
+		return nil, errRunCancelled
+	}
+	userID := r.Header.Get("Mattermost-User-ID")
+	if node.ID == p.nodeInfo.ID {
+		return p.runNodeBenchmark(ctx, r.URL.RawQuery, connType, userID), nil
+	}
+
+	requestID, replies, done := p.awaitClusterReplies()
+	defer done()
+
+	runID, _ := ctx.Value(runIDContextKey{}).(string)
+	p.publishClusterMessage(clusterEventRun, clusterMessage{
+		RequestID:    requestID,
+		Node:         p.nodeInfo,
+		TargetNodeID: node.ID,
+		Params:       r.URL.RawQuery,
+		ConnType:     connType,
+		UserID:       userID,
+		RunID:        runID,
+	})
+
+	cancelled := ctx.Done()
+	timeout := time.After(clusterRunTimeout)
+	for {
+		select {
+		case reply := <-replies:
+			return reply.Results, nil
+		case <-cancelled:
+			cancelled = nil
+			if runID != "" {
+				p.publishClusterMessage(clusterEventCancel, clusterMessage{Node: p.nodeInfo, TargetNodeID: node.ID, RunID: runID})
+			}
+		case <-timeout:
+			return nil, fmt.Errorf("node %s did not return results within %s", node.ID, clusterRunTimeout)
+		}
+	}
+}
+
+// runNodeBenchmark runs the benchmark given by params on this node over connType, or both
+// connection types when empty, on behalf of userID and stopping once ctx is cancelled. Failures
+// are reported on each connection type's result.
+func (p *Plugin) runNodeBenchmark(ctx context.Context, params, connType, userID string) []TestResult {
+	connTypes := []string{"rpc", "raw"}
+	if connType != "" {
+		connTypes = []string{connType}
+	}
+
+	results := make([]TestResult, 0, len(connTypes))
+	for _, connType := range connTypes {
+		r := (&http.Request{Method: http.MethodGet, URL: &url.URL{RawQuery: params}, Header: http.Header{}}).WithContext(ctx)
+		r.Header.Set("Mattermost-User-ID", userID)
+		result, err := p.runTest(connType, r)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.ConnType = connType
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// beginClusterLeg registers the leg of the run runID that this node runs on behalf of the node
+// holderNodeID, which holds the run lock and records the job, so that the leg can be cancelled
+// here. It returns a context cancelled along with the leg and a function unregistering it. Legs
+// of requests that are not running as a job cannot be cancelled, and are not registered.
+func (p *Plugin) beginClusterLeg(runID, holderNodeID string) (context.Context, func()) {
+	if runID == "" {
+		return context.Background(), func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), runIDContextKey{}, runID))
+	leg := &runningJob{RunID: runID, StartedAt: time.Now(), holderNodeID: holderNodeID, cancel: cancel}
+
+	p.jobsLock.Lock()
+	if p.jobs == nil {
+		p.jobs = make(map[string]*runningJob)
+	}
+	p.jobs[runID] = leg
+	p.jobsLock.Unlock()
+
+	return ctx, func() {
+		p.jobsLock.Lock()
+		if p.jobs[runID] == leg {
+			delete(p.jobs, runID)
+		}
+		p.jobsLock.Unlock()
+
+		cancel()
+	}
+}
+
+// NodeList reports the cluster nodes running the plugin.
+type NodeList struct {
+	Nodes []NodeInfo `json:"nodes"`
+	Error string     `json:"error,omitempty"`
+}
+
+// ListNodes returns the cluster nodes that answer a ping, whose IDs can be passed as node_id to
+// run a benchmark on a chosen node.
+func (p *Plugin) ListNodes(w http.ResponseWriter, r *http.Request) {
+	nodes, err := p.discoverNodes()
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, NodeList{Error: err.Error()})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, NodeList{Nodes: nodes})
+}
+
+// errUnknownNode is returned for a node_id that no cluster node answers to.
+var errUnknownNode = errors.New("unknown node_id")
+
+// runPinned runs the benchmark given by r over connType on the node chosen by opts.NodeID,
+// which may be this one, recording the node on the result.
+func (p *Plugin) runPinned(connType string, opts testOptions, r *http.Request) (TestResult, error) {
+	node := p.nodeInfo
+	if opts.NodeID != p.nodeInfo.ID {
+		nodes, err := p.discoverNodes()
+		if err != nil {
+			return TestResult{}, err
+		}
+
+		i := slices.IndexFunc(nodes, func(node NodeInfo) bool { return node.ID == opts.NodeID })
+		if i < 0 {
+			return TestResult{}, errUnknownNode
+		}
+		node = nodes[i]
+	}
+
+	results, err := p.runOnNode(r, node, connType)
+	if err != nil {
+		return TestResult{}, err
+	}
+	if len(results) != 1 {
+		return TestResult{}, fmt.Errorf("node %s returned %d results", node.ID, len(results))
+	}
+
+	result := results[0]
+	result.Node = &node
+	if result.Error != "" {
+		return result, errors.New(result.Error)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func clusterEvent(t *testing.T, id string, message clusterMessage) model.PluginClusterEvent {
+	data, err := json.Marshal(message)
+	require.NoError(t, err)
+	return model.PluginClusterEvent{Id: id, Data: data}
+}
+
+func TestOnPluginClusterEvent(t *testing.T) {
+	self := NodeInfo{ID: "self-id", Hostname: "app-1"}
+
+	t.Run("ping is answered with this node", func(t *testing.T) {
+		api := &plugintest.API{}
+		p := Plugin{nodeInfo: self}
+		p.SetAPI(api)
+
+		var pong clusterMessage
+		api.On("PublishPluginClusterEvent", mock.MatchedBy(func(ev model.PluginClusterEvent) bool {
+			return ev.Id == clusterEventPong
+		}), mock.Anything).Run(func(args mock.Arguments) {
+			require.NoError(t, json.Unmarshal(args.Get(0).(model.PluginClusterEvent).Data, &pong))
+		}).Return(nil)
+
+		p.OnPluginClusterEvent(nil, clusterEvent(t, clusterEventPing, clusterMessage{RequestID: "request-id"}))
+
+		assert.Equal(t, clusterMessage{RequestID: "request-id", Node: self}, pong)
+	})
+
+	t.Run("runs for other nodes are ignored", func(t *testing.T) {
+		api := &plugintest.API{}
+		p := Plugin{nodeInfo: self}
+		p.SetAPI(api)
+
+		p.OnPluginClusterEvent(nil, clusterEvent(t, clusterEventRun, clusterMessage{RequestID: "request-id", TargetNodeID: "other-id"}))
+
+		api.AssertNotCalled(t, "PublishPluginClusterEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("replies reach the awaiting request", func(t *testing.T) {
+		p := Plugin{nodeInfo: self}
+		requestID, replies, done := p.awaitClusterReplies()
+
+		p.OnPluginClusterEvent(nil, clusterEvent(t, clusterEventResult, clusterMessage{RequestID: "unknown-id"}))
+		p.OnPluginClusterEvent(nil, clusterEvent(t, clusterEventResult, clusterMessage{RequestID: requestID, Results: []TestResult{{ConnType: "rpc"}}}))
+
+		reply := <-replies
+		assert.Equal(t, "rpc", reply.Results[0].ConnType)
+		assert.Empty(t, replies)
+
+		done()
+		assert.Empty(t, p.clusterReplies)
+	})
+}
+
+func TestRunOnNodeCancelled(t *testing.T) {
+	api := &plugintest.API{}
+	p := Plugin{nodeInfo: NodeInfo{ID: "self"}}
+	p.SetAPI(api)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), runIDContextKey{}, "run1"))
+	defer cancel()
+
+	published := make(chan model.PluginClusterEvent, 2)
+	api.On("PublishPluginClusterEvent", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		published <- args.Get(0).(model.PluginClusterEvent)
+	}).Return(nil)
+
+	type outcome struct {
+		results []TestResult
+		err     error
+	}
+	request := adminRequest(http.MethodGet, "/api/v1/test_cluster?records=10")
+	done := make(chan outcome, 1)
+	go func() {
+		results, err := p.runOnNode(request.WithContext(ctx), NodeInfo{ID: "other"}, "rpc")
+		done <- outcome{results, err}
+	}()
+
+	var run clusterMessage
+	ev := <-published
+	require.Equal(t, clusterEventRun, ev.Id)
+	require.NoError(t, json.Unmarshal(ev.Data, &run))
+	assert.Equal(t, "run1", run.RunID)
+	assert.Equal(t, "other", run.TargetNodeID)
+	assert.Equal(t, "records=10", run.Params)
+	assert.Equal(t, "admin", run.UserID)
+
+	cancel()
+
+	var cancellation clusterMessage
+	ev = <-published
+	require.Equal(t, clusterEventCancel, ev.Id)
+	require.NoError(t, json.Unmarshal(ev.Data, &cancellation))
+	assert.Equal(t, "run1", cancellation.RunID)
+	assert.Equal(t, "other", cancellation.TargetNodeID)
+
+	// The results of the cancelled leg are still awaited.
+	p.deliverClusterReply(clusterMessage{RequestID: run.RequestID, Results: []TestResult{{ConnType: "rpc", Error: errRunCancelled.Error()}}})
+	result := <-done
+	require.NoError(t, result.err)
+	assert.Equal(t, errRunCancelled.Error(), result.results[0].Error)
+
+	_, err := p.runOnNode(request.WithContext(ctx), NodeInfo{ID: "other"}, "rpc")
+	assert.ErrorIs(t, err, errRunCancelled)
+}
+
+func TestBeginClusterLeg(t *testing.T) {
+	p := Plugin{kvstore: &fakeRunLockStore{}, nodeInfo: NodeInfo{ID: "self"}}
+	p.SetAPI(adminAPI())
+
+	ctx, end := p.beginClusterLeg("run1", "holder")
+	assert.Equal(t, "holder", p.localJobHolder("run1"))
+
+	opts, err := parseTestOptions((&http.Request{Method: http.MethodGet, URL: &url.URL{}}).WithContext(ctx))
+	require.NoError(t, err)
+	assert.NoError(t, opts.cancelled())
+
+	p.OnPluginClusterEvent(nil, clusterEvent(t, clusterEventCancel, clusterMessage{TargetNodeID: "self", RunID: "run1"}))
+	assert.ErrorIs(t, opts.cancelled(), errRunCancelled)
+
+	end()
+	assert.NotContains(t, p.jobs, "run1")
+
+	ctx, end = p.beginClusterLeg("", "holder")
+	defer end()
+	assert.Empty(t, p.jobs)
+	assert.Nil(t, ctx.Done())
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// compareIgnoredFields are fields that identify a run or describe how its result is recorded,
+// rather than how it performed.
+var compareIgnoredFields = map[string]bool{
+	"schema_version":            true,
+	"run_id":                    true,
+	"replay_of":                 true,
+	"latency_histogram.encoded": true,
+}
+
+// RunComparison is a field-by-field diff of two stored runs, from run A to run B.
+type RunComparison struct {
+	A       string        `json:"a"`
+	B       string        `json:"b"`
+	Metrics []MetricDelta `json:"metrics"`
+	Changed []FieldChange `json:"changed"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// MetricDelta compares a number reported by both runs, named by its path within the result.
+type MetricDelta struct {
+	Name  string  `json:"name"`
+	A     float64 `json:"a"`
+	B     float64 `json:"b"`
+	Delta float64 `json:"delta"`
+
+	// PercentChange is Delta as a percentage of A, left out when A is zero.
+	PercentChange *float64 `json:"percent_change,omitempty"`
+}
+
+// FieldChange is a field whose value differs between the runs other than as numbers both
+// report, such as the mode or database version, with null on the side of a run lacking it.
+type FieldChange struct {
+	Name string `json:"name"`
+	A    any    `json:"a"`
+	B    any    `json:"b"`
+}
+
+// compareResults diffs the results of two runs field by field. Lists, such as the latency
+// trend, are left out, since their elements do not pair up between runs.
+func compareResults(a, b TestResult) (RunComparison, error) {
+	fieldsOf := func(result TestResult) (map[string]any, []string, error) {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, err
+		}
+		flattened, err := flattenResult(encoded)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fields := map[string]any{}
+		var paths []string
+		for _, field := range flattened {
+			if field.InList || compareIgnoredFields[field.Path] {
+				continue
+			}
+			fields[field.Path] = field.Value
+			paths = append(paths, field.Path)
+		}
+		return fields, paths, nil
+	}
+
+	fieldsA, pathsA, err := fieldsOf(a)
+	if err != nil {
+		return RunComparison{}, err
+	}
+	fieldsB, pathsB, err := fieldsOf(b)
+	if err != nil {
+		return RunComparison{}, err
+	}
+
+	comparison := RunComparison{Metrics: []MetricDelta{}, Changed: []FieldChange{}}
+	for _, path := range pathsA {
+		valueA, valueB := fieldsA[path], fieldsB[path]
+		numberA, isNumberA := valueA.(float64)
+		numberB, isNumberB := valueB.(float64)
+
+		switch {
+		case isNumberA && isNumberB:
+			if numberA == 0 && numberB == 0 {
+				continue
+			}
+			delta := MetricDelta{Name: path, A: numberA, B: numberB, Delta: numberB - numberA}
+			if numberA != 0 {
+				percent := delta.Delta / numberA * 100
+				delta.PercentChange = &percent
+			}
+			comparison.Metrics = append(comparison.Metrics, delta)
+		case !reflect.DeepEqual(valueA, valueB):
+			comparison.Changed = append(comparison.Changed, FieldChange{Name: path, A: valueA, B: valueB})
+		}
+	}
+	for _, path := range pathsB {
+		if _, ok := fieldsA[path]; !ok {
+			comparison.Changed = append(comparison.Changed, FieldChange{Name: path, B: fieldsB[path]})
+		}
+	}
+
+	return comparison, nil
+}
+
+// CompareResults diffs the stored runs given by the a and b query params, reporting the change
+// from a to b of every number both report, and every other field that differs, for comparing
+// runs from before and after a change such as an upgrade.
+func (p *Plugin) CompareResults(w http.ResponseWriter, r *http.Request) {
+	idA, idB := r.URL.Query().Get("a"), r.URL.Query().Get("b")
+	if idA == "" || idB == "" {
+		respondWithJSON(w, http.StatusBadRequest, RunComparison{A: idA, B: idB, Error: "a and b must both name stored runs"})
+		return
+	}
+
+	_, resultA, status, err := p.getStoredResult(idA)
+	if err != nil {
+		respondWithJSON(w, status, RunComparison{A: idA, B: idB, Error: err.Error()})
+		return
+	}
+	_, resultB, status, err := p.getStoredResult(idB)
+	if err != nil {
+		respondWithJSON(w, status, RunComparison{A: idA, B: idB, Error: err.Error()})
+		return
+	}
+
+	comparison, err := compareResults(resultA, resultB)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, RunComparison{A: idA, B: idB, Error: fmt.Sprintf("failed to compare runs: %v", err)})
+		return
+	}
+	comparison.A, comparison.B = idA, idB
+
+	respondWithJSON(w, http.StatusOK, comparison)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareResults(t *testing.T) {
+	comparison, err := compareResults(
+		TestResult{RunID: "a", ConnType: "raw", Mode: "scan", DatabaseVersion: "14.2", TotalQueryTimeSeconds: 2, QueryRowsPerSecond: 1000,
+			LookupLatency: &LatencyMillis{P99: 4}, LatencyTrend: []LatencyPoint{{Avg: 1}}},
+		TestResult{RunID: "b", ConnType: "raw", Mode: "scan", DatabaseVersion: "15.1", TotalQueryTimeSeconds: 3, QueryRowsPerSecond: 800,
+			InsertTimeSeconds: 5, LatencyTrend: []LatencyPoint{{Avg: 2}}},
+	)
+	require.NoError(t, err)
+
+	metrics := map[string]MetricDelta{}
+	for _, metric := range comparison.Metrics {
+		metrics[metric.Name] = metric
+	}
+	require.Contains(t, metrics, "total_query_time_seconds")
+	assert.Equal(t, 1.0, metrics["total_query_time_seconds"].Delta)
+	assert.InDelta(t, 50, *metrics["total_query_time_seconds"].PercentChange, 1e-9)
+	assert.InDelta(t, -20, *metrics["query_rows_per_second"].PercentChange, 1e-9)
+
+	// A number only one run reports has no percent change from zero.
+	assert.Equal(t, 5.0, metrics["insert_time_seconds"].Delta)
+	assert.Nil(t, metrics["insert_time_seconds"].PercentChange)
+
+	assert.NotContains(t, metrics, "records_queried", "unreported by both")
+	assert.NotContains(t, metrics, "latency_trend.0.avg_ms", "lists are left out")
+
+	assert.ElementsMatch(t, []FieldChange{
+		{Name: "database_version", A: "14.2", B: "15.1"},
+		{Name: "lookup_latency.p99_ms", A: 4.0},
+		{Name: "lookup_latency.min_ms", A: 0.0},
+		{Name: "lookup_latency.avg_ms", A: 0.0},
+		{Name: "lookup_latency.p50_ms", A: 0.0},
+		{Name: "lookup_latency.p95_ms", A: 0.0},
+		{Name: "lookup_latency.max_ms", A: 0.0},
+	}, comparison.Changed)
+}
+
+func TestCompareResultsEndpoint(t *testing.T) {
+	p := Plugin{kvstore: fakeRunStore{KVStore: &fakeRunLockStore{}, runs: map[string]kvstore.Run{
+		"before": {ID: "before", Result: []byte(`{"conn_type": "rpc", "total_query_time_seconds": 4}`)},
+		"after":  {ID: "after", Result: []byte(`{"conn_type": "rpc", "total_query_time_seconds": 3}`)},
+	}}}
+	p.SetAPI(adminAPI())
+
+	compare := func(target string) (int, RunComparison) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, target))
+
+		var comparison RunComparison
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comparison))
+		return w.Code, comparison
+	}
+
+	status, comparison := compare("/api/v1/results/compare?a=before&b=after")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "before", comparison.A)
+	assert.Equal(t, "after", comparison.B)
+	require.Len(t, comparison.Metrics, 1)
+	assert.Equal(t, "total_query_time_seconds", comparison.Metrics[0].Name)
+	assert.InDelta(t, -25, *comparison.Metrics[0].PercentChange, 1e-9)
+	assert.Empty(t, comparison.Changed)
+
+	status, _ = compare("/api/v1/results/compare?a=before")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, comparison = compare("/api/v1/results/compare?a=before&b=missing")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "unknown run missing", comparison.Error)
+}
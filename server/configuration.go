@@ -2,6 +2,7 @@ package main
 
 import (
 	"reflect"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -18,8 +19,81 @@ import (
 // If you add non-reference types to your configuration struct, be sure to rewrite Clone as a deep
 // copy appropriate for your types.
 type configuration struct {
+	// ApplicationName is reported to the database by every raw connection the plugin opens.
+	ApplicationName string
+
+	// BoardsBoardID is the Boards board on which a card is created for each completed run.
+	BoardsBoardID string
+
+	// PlaybookID is the playbook started whenever a regression alert fires.
+	PlaybookID string
+
+	// PlaybooksTeamID is the team in which regression playbook runs are started.
+	PlaybooksTeamID string
+
+	// IssueEndpoint is the issue tracker URL to which a new issue is posted on sustained regression.
+	IssueEndpoint string
+
+	// IssueAuthorization is sent verbatim as the Authorization header of issue requests.
+	IssueAuthorization string
+
+	// IssueTemplate renders the JSON request body for a new issue.
+	IssueTemplate string
+
+	// IssueConsecutiveRuns is the number of consecutive regressed runs that opens an issue.
+	IssueConsecutiveRuns int
+
+	// RegressionThreshold is the percentage by which a run may be slower than the baseline of its
+	// series before it counts as regressed.
+	RegressionThreshold int
+
+	// SlowQueryThresholdMs is the number of milliseconds a benchmark statement may take before it
+	// is logged as a slow query, or zero to not log slow queries.
+	SlowQueryThresholdMs int
+
+	// AlertThresholds are absolute limits on the metrics of every run, such as
+	// total_query_time_seconds>30, breaching which fires a regression alert.
+	AlertThresholds string
+
+	// AlertChannelID is the channel regression alerts are posted to.
+	AlertChannelID string
+
+	// AlertUsernames are the comma-separated admins sent regression alerts as direct messages.
+	AlertUsernames string
+
+	// ScheduleIntervalHours is the number of hours between scheduled benchmarks, or zero to
+	// disable them.
+	ScheduleIntervalHours int
+
+	// SchedulePreset is the autorun preset run by scheduled benchmarks.
+	SchedulePreset string
+
+	// ScheduleParams are query params applied on top of every run of a scheduled benchmark.
+	ScheduleParams string
+
+	// ResultRetentionCount is the number of stored runs kept, or zero to keep any number.
+	ResultRetentionCount int
+
+	// ResultRetentionDays is the number of days stored runs are kept, or zero to keep them
+	// indefinitely.
+	ResultRetentionDays int
+
+	// RESTAccessToken authenticates the REST API leg of the REST comparison, as a remote
+	// integration would.
+	RESTAccessToken string
+
+	// ReadOnlyMode disables every code path that issues DDL or DML, restricting the plugin to
+	// read-only workloads.
+	ReadOnlyMode bool
+
+	// EnableRawMode allows raw connection runs, which read the database credentials from the
+	// unsanitized server configuration.
+	EnableRawMode bool
 }
 
+// defaultApplicationName is used when ApplicationName is left blank.
+const defaultApplicationName = "test-rpc-database"
+
 // Clone shallow copies the configuration. Your implementation may require a deep copy if
 // your configuration has reference types.
 func (c *configuration) Clone() *configuration {
@@ -27,6 +101,19 @@ func (c *configuration) Clone() *configuration {
 	return &clone
 }
 
+// applicationName returns the name raw connections report to the database, suffixed with the
+// run label when one is given.
+func (c *configuration) applicationName(label string) string {
+	name := strings.TrimSpace(c.ApplicationName)
+	if name == "" {
+		name = defaultApplicationName
+	}
+	if label != "" {
+		name += ":" + label
+	}
+	return name
+}
+
 // getConfiguration retrieves the active configuration under lock, making it safe to use
 // concurrently. The active configuration may change underneath the client of this method, but
 // the struct returned by this API call is considered immutable.
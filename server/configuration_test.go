@@ -0,0 +1,13 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurationApplicationName(t *testing.T) {
+	assert.Equal(t, defaultApplicationName, (&configuration{}).applicationName(""))
+	assert.Equal(t, defaultApplicationName+":nightly", (&configuration{}).applicationName("nightly"))
+	assert.Equal(t, "bench:nightly", (&configuration{ApplicationName: " bench "}).applicationName("nightly"))
+}
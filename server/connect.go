@@ -0,0 +1,121 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/shared/driver"
+)
+
+const (
+	// defaultConnectIterations is the number of connections established per method.
+	defaultConnectIterations = 100
+
+	// maxConnectIterations bounds the work a single connect request can trigger.
+	maxConnectIterations = 10000
+)
+
+// Ways of acquiring a database connection compared by the connect benchmark.
+const (
+	// connectGetMasterDB calls StoreService.GetMasterDB and pings the pooled handle it returns,
+	// as a plugin acquiring the database per request does.
+	connectGetMasterDB = "rpc_get_master_db"
+
+	// connectRPCFresh establishes a new connection through the RPC driver every time.
+	connectRPCFresh = "rpc_fresh"
+
+	// connectRawFresh establishes a new direct connection to the database every time.
+	connectRawFresh = "raw_fresh"
+)
+
+// ConnectResult reports the latency of acquiring a usable connection one way.
+type ConnectResult struct {
+	Method     string        `json:"method"`
+	Iterations int           `json:"iterations"`
+	Latency    LatencyMillis `json:"latency"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// ConnectResponse collects the connect results for every method.
+type ConnectResponse struct {
+	Label   string          `json:"label,omitempty"`
+	Results []ConnectResult `json:"results"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// TestDatabaseConnect measures the cost of acquiring a database connection, which plugins that
+// connect per request pay constantly. Fresh connections are forced by disabling idle connection
+// reuse, so every ping establishes and then tears down a connection.
+func (p *Plugin) TestDatabaseConnect(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, ConnectResponse{Error: err.Error()})
+		return
+	}
+
+	iterations := defaultConnectIterations
+	if value := r.URL.Query().Get("iterations"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			iterations = min(n, maxConnectIterations)
+		}
+	}
+
+	response := ConnectResponse{
+		Label: opts.Label,
+	}
+
+	getMasterDB := ConnectResult{Method: connectGetMasterDB, Iterations: iterations}
+	if err := timeConnections(iterations, &getMasterDB, func() error {
+		db, err := p.client.Store.GetMasterDB()
+		if err != nil {
+			return err
+		}
+		return db.Ping()
+	}); err != nil {
+		getMasterDB.Error = err.Error()
+	}
+	response.Results = append(response.Results, getMasterDB)
+
+	rpcFresh := ConnectResult{Method: connectRPCFresh, Iterations: iterations}
+	rpcDB := sql.OpenDB(driver.NewConnector(p.Driver, true))
+	rpcDB.SetMaxIdleConns(0)
+	if err := timeConnections(iterations, &rpcFresh, rpcDB.Ping); err != nil {
+		rpcFresh.Error = err.Error()
+	}
+	rpcDB.Close()
+	response.Results = append(response.Results, rpcFresh)
+
+	rawFresh := ConnectResult{Method: connectRawFresh, Iterations: iterations}
+	if rawDB, _, err := p.openRawConnection(opts); err != nil {
+		rawFresh.Error = fmt.Sprintf("Failed to connect to database: %v", err)
+	} else {
+		rawDB.SetMaxIdleConns(0)
+		if err := timeConnections(iterations, &rawFresh, rawDB.Ping); err != nil {
+			rawFresh.Error = err.Error()
+		}
+		rawDB.Close()
+	}
+	response.Results = append(response.Results, rawFresh)
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// timeConnections times iterations calls of connect and records the summary on result.
+func timeConnections(iterations int, result *ConnectResult, connect func() error) error {
+	durations := make([]time.Duration, 0, iterations)
+
+	for i := 0; i < iterations; i++ {
+		start := time.Now()
+		if err := connect(); err != nil {
+			return fmt.Errorf("failed to connect on iteration %d: %v", i, err)
+		}
+		durations = append(durations, time.Since(start))
+	}
+
+	result.Latency = summarizeLatencies(durations).millis()
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeConnections(t *testing.T) {
+	var result ConnectResult
+	calls := 0
+	require.NoError(t, timeConnections(5, &result, func() error {
+		calls++
+		return nil
+	}))
+	assert.Equal(t, 5, calls)
+	assert.GreaterOrEqual(t, result.Latency.Max, result.Latency.Min)
+
+	err := timeConnections(5, &result, func() error { return errors.New("refused") })
+	assert.EqualError(t, err, "failed to connect on iteration 0: refused")
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+)
+
+// datasetGeneratorVersion must be bumped whenever the seeded values change for the same
+// options, so stale fingerprints stop matching.
+const datasetGeneratorVersion = 1
+
+// fingerprintLength is the number of hex characters kept from the dataset hash.
+const fingerprintLength = 16
+
+// seedsDataset reports whether seeding with opts leaves data behind that later runs can read.
+func (o testOptions) seedsDataset() bool {
+	switch o.Mode {
+	case modeSavepoint, modeDeadlock, modeRowLock, modeTimeout, modeWide, modeNulls, modeText, modeTimestamps, modeNumeric, modeNoop:
+		return false
+	}
+
+	return true
+}
+
+// datasetProfile describes the data that seeding with opts produces. The fingerprint and
+// observed size are filled in by measureDataset.
+func datasetProfile(opts testOptions) kvstore.Dataset {
+	dataset := kvstore.Dataset{
+		Table:            "plugin_test_rpc",
+		Mode:             opts.Mode,
+		Records:          opts.Records,
+		RowBytes:         opts.RowBytes,
+		GeneratorVersion: datasetGeneratorVersion,
+	}
+
+	if opts.Mode == modeBlob {
+		dataset.Table = "plugin_test_rpc_blob"
+		dataset.RowBytes = 0
+		dataset.PayloadBytes = opts.PayloadBytes
+		dataset.GeneratorSeed = int64(opts.PayloadBytes)
+	} else if opts.Mode == modeJSON {
+		dataset.Table = "plugin_test_rpc_json"
+		dataset.RowBytes = 0
+	}
+
+	return dataset
+}
+
+// measureDataset counts the rows and bytes the workload described by dataset would read.
+func measureDataset(db *sql.DB, driverName string, dataset kvstore.Dataset) (int, int64, error) {
+	var query string
+	var args []any
+	if dataset.Mode == modeBlob {
+		query = "SELECT COUNT(*), COALESCE(SUM(LENGTH(payload)), 0) FROM (SELECT payload FROM plugin_test_rpc_blob WHERE payload_bytes = ? ORDER BY id LIMIT ?) seeded"
+		args = []any{dataset.PayloadBytes, dataset.Records}
+	} else if dataset.Mode == modeJSON {
+		// Documents are measured in their text form, as the workload reads them.
+		textType := "CHAR"
+		if driverName == "postgres" {
+			textType = "TEXT"
+		}
+		query = "SELECT COUNT(*), COALESCE(SUM(LENGTH(CAST(props AS " + textType + "))), 0) FROM (SELECT props FROM plugin_test_rpc_json ORDER BY id LIMIT ?) seeded"
+		args = []any{dataset.Records}
+	} else {
+		query = "SELECT COUNT(*), COALESCE(SUM(LENGTH(data)), 0) FROM (SELECT data FROM plugin_test_rpc ORDER BY id LIMIT ?) seeded"
+		args = []any{dataset.Records}
+	}
+
+	var rows int
+	var bytes int64
+	if err := db.QueryRow(rebind(driverName, query), args...).Scan(&rows, &bytes); err != nil {
+		return 0, 0, fmt.Errorf("failed to measure dataset: %v", err)
+	}
+
+	return rows, bytes, nil
+}
+
+// datasetFingerprint hashes every field of dataset that determines the data read.
+func datasetFingerprint(dataset kvstore.Dataset) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%d|%d|%d|%d|%d",
+		dataset.Table,
+		dataset.Mode,
+		dataset.Records,
+		dataset.RowBytes,
+		dataset.PayloadBytes,
+		dataset.GeneratorSeed,
+		dataset.GeneratorVersion,
+		dataset.TotalBytes,
+	)))
+
+	return hex.EncodeToString(sum[:])[:fingerprintLength]
+}
+
+// registerDataset measures the data just seeded for opts and records it in the registry,
+// returning its fingerprint.
+func (p *Plugin) registerDataset(db *sql.DB, driverName string, opts testOptions) (string, error) {
+	dataset := datasetProfile(opts)
+
+	rows, bytes, err := measureDataset(db, driverName, dataset)
+	if err != nil {
+		return "", err
+	}
+	if rows < dataset.Records {
+		return "", fmt.Errorf("seeded table holds %d rows, expected %d", rows, dataset.Records)
+	}
+
+	dataset.TotalBytes = bytes
+	dataset.Fingerprint = datasetFingerprint(dataset)
+	dataset.SeededAt = time.Now().UnixMilli()
+
+	if err := p.kvstore.SaveDataset(dataset); err != nil {
+		return "", err
+	}
+
+	return dataset.Fingerprint, nil
+}
+
+// resolveDataset loads the dataset referenced by opts.Dataset, verifies the tables still hold
+// exactly that data, and returns opts adjusted to read it.
+func (p *Plugin) resolveDataset(db *sql.DB, driverName string, opts testOptions) (testOptions, error) {
+	dataset, err := p.kvstore.GetDataset(opts.Dataset)
+	if err != nil {
+		return opts, err
+	}
+	if dataset == nil {
+		return opts, fmt.Errorf("unknown dataset %s", opts.Dataset)
+	}
+
+	rows, bytes, err := measureDataset(db, driverName, *dataset)
+	if err != nil {
+		return opts, err
+	}
+	if rows != dataset.Records || bytes != dataset.TotalBytes {
+		return opts, fmt.Errorf("dataset %s does not match the table contents: expected %d rows of %d bytes, found %d rows of %d bytes",
+			dataset.Fingerprint, dataset.Records, dataset.TotalBytes, rows, bytes)
+	}
+
+	opts.Mode = dataset.Mode
+	opts.Records = dataset.Records
+	opts.RowBytes = dataset.RowBytes
+	opts.PayloadBytes = dataset.PayloadBytes
+
+	return opts, nil
+}
+
+// ListDatasets returns the registry of seeded datasets.
+func (p *Plugin) ListDatasets(w http.ResponseWriter, r *http.Request) {
+	datasets, err := p.kvstore.ListDatasets()
+	if err != nil {
+		p.API.LogError("Failed to list datasets", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, datasets)
+}
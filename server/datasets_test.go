@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatasetFingerprint(t *testing.T) {
+	scan := datasetProfile(testOptions{Mode: modeScan, Records: defaultRecords})
+	scan.TotalBytes = 700000
+
+	blob := datasetProfile(testOptions{Mode: modeBlob, Records: defaultBlobRecords, PayloadBytes: defaultPayloadBytes})
+	blob.TotalBytes = defaultBlobRecords * defaultPayloadBytes
+
+	assert.Len(t, datasetFingerprint(scan), fingerprintLength)
+	assert.Regexp(t, fingerprintPattern, datasetFingerprint(scan))
+	assert.Equal(t, datasetFingerprint(scan), datasetFingerprint(scan))
+	assert.NotEqual(t, datasetFingerprint(scan), datasetFingerprint(blob))
+
+	grown := scan
+	grown.TotalBytes++
+	assert.NotEqual(t, datasetFingerprint(scan), datasetFingerprint(grown))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	dbtestCommandTrigger = "dbtest"
+
+	// dbtestResultCommand shows a stored run, or the most recent one.
+	dbtestResultCommand = "result"
+
+	// dbtestConfigureCommand opens a dialog to configure and run a benchmark.
+	dbtestConfigureCommand = "configure"
+)
+
+// registerDBTestCommand registers the /dbtest slash command, through which system admins run
+// benchmarks and read their results from chat.
+func (p *Plugin) registerDBTestCommand() error {
+	autocomplete := model.NewAutocompleteData(dbtestCommandTrigger, "[command]", "Database benchmarks")
+	result := model.NewAutocompleteData(dbtestResultCommand, "[run_id]", "Show a stored run, or the most recent one")
+	result.AddTextArgument("ID of the run to show", "[run_id]", "")
+	autocomplete.AddCommand(result)
+	autocomplete.AddCommand(model.NewAutocompleteData(dbtestConfigureCommand, "", "Configure and run a benchmark"))
+
+	return p.client.SlashCommand.Register(&model.Command{
+		Trigger:          dbtestCommandTrigger,
+		AutoComplete:     true,
+		AutoCompleteDesc: "Database benchmarks",
+		AutoCompleteHint: "[result [run_id] | configure]",
+		AutocompleteData: autocomplete,
+	})
+}
+
+// executeDBTestCommand runs /dbtest for a system admin, responding only to them.
+func (p *Plugin) executeDBTestCommand(args *model.CommandArgs) *model.CommandResponse {
+	respond := func(text string) *model.CommandResponse {
+		return &model.CommandResponse{ResponseType: model.CommandResponseTypeEphemeral, Text: text}
+	}
+
+	if !p.API.HasPermissionTo(args.UserId, model.PermissionManageSystem) {
+		return respond("Only system admins can use /" + dbtestCommandTrigger + ".")
+	}
+
+	fields := strings.Fields(args.Command)
+	switch {
+	case len(fields) > 1 && fields[1] == dbtestConfigureCommand:
+		if err := p.openConfigureDialog(args.TriggerId); err != nil {
+			p.API.LogError("Failed to open configure dialog", "error", err)
+			return respond("Failed to open the dialog: " + err.Error())
+		}
+		return &model.CommandResponse{}
+	case len(fields) < 2 || fields[1] != dbtestResultCommand:
+		return respond(fmt.Sprintf("Usage: /%s %s [run_id] | /%s %s", dbtestCommandTrigger, dbtestResultCommand, dbtestCommandTrigger, dbtestConfigureCommand))
+	}
+
+	var run *kvstore.Run
+	var err error
+	if len(fields) > 2 {
+		run, err = p.kvstore.GetRun(fields[2])
+	} else {
+		run, err = p.latestRun()
+	}
+	if err != nil {
+		p.API.LogError("Failed to get run", "error", err)
+		return respond("Failed to get the run: " + err.Error())
+	}
+	if run == nil {
+		return respond("No such run is stored.")
+	}
+
+	result, err := decodeTestResult(run.Result)
+	if err != nil {
+		return respond(fmt.Sprintf("Run `%s` has an invalid stored result: %v", run.ID, err))
+	}
+	return respond(fmt.Sprintf("#### Run `%s`\n\n%s", run.ID, resultMarkdown(result)))
+}
+
+// latestRun returns the most recently stored run, or nil if there is none.
+func (p *Plugin) latestRun() (*kvstore.Run, error) {
+	runs, err := p.kvstore.ListRuns()
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].CreatedAt > runs[j].CreatedAt })
+	return &runs[0], nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBTestResultCommand(t *testing.T) {
+	runs := map[string]kvstore.Run{
+		"older": {ID: "older", CreatedAt: 1000, Result: []byte(`{"conn_type":"rpc","mode":"scan"}`)},
+		"newer": {ID: "newer", CreatedAt: 2000, Result: []byte(`{"conn_type":"raw","total_query_time_seconds":2}`)},
+	}
+	p := Plugin{kvstore: fakeRetentionStore{KVStore: fakeRunStore{runs: runs}, runs: runs}}
+	p.SetAPI(adminAPI())
+
+	execute := func(userID, command string) string {
+		response, appErr := p.ExecuteCommand(nil, &model.CommandArgs{UserId: userID, Command: command})
+		require.Nil(t, appErr)
+		assert.Equal(t, model.CommandResponseTypeEphemeral, response.ResponseType)
+		return response.Text
+	}
+
+	assert.Contains(t, execute("user", "/dbtest result older"), "Only system admins")
+	assert.Contains(t, execute("admin", "/dbtest"), "Usage: /dbtest result [run_id]")
+	assert.Equal(t, "No such run is stored.", execute("admin", "/dbtest result missing"))
+
+	text := execute("admin", "/dbtest result older")
+	assert.Contains(t, text, "#### Run `older`")
+	assert.Contains(t, text, "| Mode | scan |")
+
+	text = execute("admin", "/dbtest result")
+	assert.Contains(t, text, "#### Run `newer`")
+	assert.Contains(t, text, "| Total query time | 2.000 s |")
+}
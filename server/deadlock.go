@@ -0,0 +1,234 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDeadlockRecords is the number of deadlock rounds when records is not given. Postgres
+	// only checks for deadlocks after deadlock_timeout, one second by default, per round.
+	defaultDeadlockRecords = 10
+
+	// maxDeadlockRetries is the number of times a worker retries a deadlocked transaction before
+	// the run fails.
+	maxDeadlockRetries = 5
+
+	// deadlockBackoff is the delay before a worker's first retry, doubling with each further one.
+	deadlockBackoff = 10 * time.Millisecond
+)
+
+// deadlockOrders are the orders in which the two workers update the rows of
+// plugin_test_rpc_deadlock, opposite to one another.
+var deadlockOrders = [2][2]int{{1, 2}, {2, 1}}
+
+// runDeadlockTest provokes opts.Records deadlocks between two workers updating the two rows of
+// plugin_test_rpc_deadlock in opposite orders, retrying the aborted transactions with backoff.
+// It reports how the connection type surfaces deadlock errors and how long recovering takes.
+func (p *Plugin) runDeadlockTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label: opts.Label,
+		Mode:  modeDeadlock,
+		Phase: opts.Phase,
+	}
+
+	if opts.Phase != phaseQuery {
+		if err := seedDeadlockTable(db, driverName, opts); err != nil {
+			return result, err
+		}
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.provokeDeadlocks(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// seedDeadlockTable creates plugin_test_rpc_deadlock if needed along with its two rows.
+func seedDeadlockTable(db *sql.DB, driverName string, opts testOptions) error {
+	createTableSQL := "CREATE TABLE IF NOT EXISTS plugin_test_rpc_deadlock (id INT PRIMARY KEY, counter BIGINT NOT NULL)"
+	if _, err := db.Exec(opts.tagSQL(createTableSQL)); err != nil {
+		return fmt.Errorf("failed to create deadlock table: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(opts.tagSQL("SELECT COUNT(*) FROM plugin_test_rpc_deadlock")).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check deadlock rows: %v", err)
+	}
+	if count >= len(deadlockOrders[0]) {
+		return nil
+	}
+
+	insertSQL := opts.tagSQL(multiRowInsert(driverName, "plugin_test_rpc_deadlock", []string{"id", "counter"}, 2))
+	if _, err := db.Exec(insertSQL, 1, 0, 2, 0); err != nil {
+		return fmt.Errorf("failed to insert deadlock rows: %v", err)
+	}
+
+	return nil
+}
+
+// deadlockRound is what a round of two workers observed.
+type deadlockRound struct {
+	mu        sync.Mutex
+	deadlocks int
+	retries   int
+	message   string
+}
+
+// provokeDeadlocks runs the deadlock rounds, recording the deadlocks seen, the retries made, a
+// sample error message and the latency of each round until both workers committed on result.
+// It fails if a worker sees any other error, or if the counters show lost or repeated updates.
+func (p *Plugin) provokeDeadlocks(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	before, err := deadlockCounters(db, opts)
+	if err != nil {
+		return err
+	}
+
+	updateSQL := opts.tagSQL(rebind(driverName, "UPDATE plugin_test_rpc_deadlock SET counter = counter + 1 WHERE id = ?"))
+	durations := make([]time.Duration, 0, opts.Records)
+	startTotalQuery := time.Now()
+
+	for i := 0; i < opts.Records; i++ {
+		var round deadlockRound
+		var locked sync.WaitGroup
+		locked.Add(len(deadlockOrders))
+
+		errs := make([]error, len(deadlockOrders))
+		var workers sync.WaitGroup
+		start := time.Now()
+		for worker, order := range deadlockOrders {
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				errs[worker] = p.deadlockWorker(db, opts, updateSQL, order, &locked, &round)
+			}()
+		}
+		workers.Wait()
+		durations = append(durations, time.Since(start))
+
+		for worker, err := range errs {
+			if err != nil {
+				return fmt.Errorf("worker %d failed in round %d: %v", worker, i, err)
+			}
+		}
+
+		result.Deadlocks += round.deadlocks
+		result.DeadlockRetries += round.retries
+		if result.DeadlockError == "" {
+			result.DeadlockError = round.message
+		}
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	latency := summarizeLatencies(durations).millis()
+	result.DeadlockLatency = &latency
+	if err := result.setLatencyHistogram(durations); err != nil {
+		return err
+	}
+
+	after, err := deadlockCounters(db, opts)
+	if err != nil {
+		return err
+	}
+	for id, counter := range after {
+		// Each round commits one update of each row per worker.
+		if updates := counter - before[id]; updates != int64(len(deadlockOrders)*opts.Records) {
+			return fmt.Errorf("row %d was updated %d times, expected %d", id, updates, len(deadlockOrders)*opts.Records)
+		}
+	}
+
+	return nil
+}
+
+// deadlockWorker updates the rows in order in one transaction, retrying with backoff whenever it
+// is chosen as the deadlock victim. On its first attempt it waits after locking its first row
+// until the other worker has locked its own, guaranteeing the deadlock.
+func (p *Plugin) deadlockWorker(db *sql.DB, opts testOptions, updateSQL string, order [2]int, locked *sync.WaitGroup, round *deadlockRound) error {
+	var once sync.Once
+	defer once.Do(locked.Done)
+
+	for attempt := 0; ; attempt++ {
+		err := p.deadlockAttempt(db, opts, updateSQL, order, func() {
+			once.Do(locked.Done)
+			if attempt == 0 {
+				locked.Wait()
+			}
+		})
+		if err == nil {
+			return nil
+		}
+		if !isSerializationFailure(err) {
+			return err
+		}
+
+		round.mu.Lock()
+		round.deadlocks++
+		if round.message == "" {
+			round.message = err.Error()
+		}
+		round.mu.Unlock()
+
+		if attempt == maxDeadlockRetries {
+			// The cause is deliberately left out, so the run is not retried as a whole.
+			return fmt.Errorf("still deadlocked after %d retries", maxDeadlockRetries)
+		}
+
+		round.mu.Lock()
+		round.retries++
+		round.mu.Unlock()
+		time.Sleep(deadlockBackoff << attempt)
+	}
+}
+
+// deadlockAttempt runs one transaction updating the rows in order, calling between after the
+// first update.
+func (p *Plugin) deadlockAttempt(db *sql.DB, opts testOptions, updateSQL string, order [2]int, between func()) error {
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		between()
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	_, err = tx.Exec(updateSQL, order[0])
+	between()
+	if err == nil {
+		_, err = tx.Exec(updateSQL, order[1])
+	}
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			p.API.LogError("Failed to rollback transaction", "error", rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deadlockCounters reads the counter of each row of plugin_test_rpc_deadlock.
+func deadlockCounters(db *sql.DB, opts testOptions) (map[int]int64, error) {
+	rows, err := db.Query(opts.tagSQL("SELECT id, counter FROM plugin_test_rpc_deadlock"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deadlock counters: %v", err)
+	}
+	defer rows.Close()
+
+	counters := make(map[int]int64)
+	for rows.Next() {
+		var id int
+		var counter int64
+		if err := rows.Scan(&id, &counter); err != nil {
+			return nil, fmt.Errorf("failed to scan deadlock counter: %v", err)
+		}
+		counters[id] = counter
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read deadlock counters: %v", err)
+	}
+
+	return counters, nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// configureDialogPath is the path, under the plugin's URL, that the configure dialog submits to.
+const configureDialogPath = "/api/v1/dialog/configure"
+
+// Names of the elements of the configure dialog, which match the query params of the benchmark.
+const (
+	configureConnType = "conn_type"
+	configureRecords  = "records"
+	configurePageSize = "page_size"
+	configureMode     = "mode"
+	configureTags     = "tags"
+	configureNotes    = "notes"
+)
+
+// configureEndpoints maps the connection types offered by the configure dialog to the
+// benchmark each runs.
+var configureEndpoints = map[string]string{
+	"rpc": "/api/v1/test",
+	"raw": "/api/v1/test_raw",
+}
+
+// configureDialog returns the dialog through which /dbtest configure sets up a benchmark run.
+func configureDialog() model.Dialog {
+	modes := make([]*model.PostActionOptions, 0, len(workloadModes))
+	for _, mode := range workloadModes {
+		modes = append(modes, &model.PostActionOptions{Text: mode, Value: mode})
+	}
+
+	return model.Dialog{
+		CallbackId:       "configure",
+		Title:            "Run a database benchmark",
+		IntroductionText: "The run is queued, and you will get a direct message with its result once it completes.",
+		SubmitLabel:      "Run",
+		Elements: []model.DialogElement{{
+			DisplayName: "Connection type",
+			Name:        configureConnType,
+			Type:        "select",
+			Default:     "rpc",
+			HelpText:    "RPC goes through the Mattermost server's database connection; raw connects to the database directly.",
+			Options: []*model.PostActionOptions{
+				{Text: "RPC", Value: "rpc"},
+				{Text: "Raw", Value: "raw"},
+			},
+		}, {
+			DisplayName: "Records",
+			Name:        configureRecords,
+			Type:        "text",
+			SubType:     "number",
+			Default:     strconv.Itoa(defaultRecords),
+			HelpText:    "Size of the test dataset.",
+		}, {
+			DisplayName: "Page size",
+			Name:        configurePageSize,
+			Type:        "text",
+			SubType:     "number",
+			Default:     strconv.Itoa(defaultPageSize),
+			HelpText:    "Records fetched per query.",
+		}, {
+			DisplayName: "Mode",
+			Name:        configureMode,
+			Type:        "select",
+			Default:     modeScan,
+			Options:     modes,
+		}, {
+			DisplayName: "Tags",
+			Name:        configureTags,
+			Type:        "text",
+			Optional:    true,
+			Placeholder: "before-upgrade, postgres-15",
+			HelpText:    "Comma-separated tags grouping the run with related runs in the run history.",
+		}, {
+			DisplayName: "Notes",
+			Name:        configureNotes,
+			Type:        "textarea",
+			Optional:    true,
+			MaxLength:   maxNotesLength,
+			Placeholder: "Run during the nightly backup window.",
+			HelpText:    "Stored with the run's result.",
+		}},
+	}
+}
+
+// openConfigureDialog opens the configure dialog for the user who triggered triggerID.
+func (p *Plugin) openConfigureDialog(triggerID string) error {
+	manifest := p.pluginManifest()
+	if manifest == nil {
+		return errors.New("failed to read the plugin manifest")
+	}
+
+	return p.client.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: triggerID,
+		URL:       "/plugins/" + manifest.Id + configureDialogPath,
+		Dialog:    configureDialog(),
+	})
+}
+
+// configureSubmission returns the benchmark endpoint and query params chosen in a submission
+// of the configure dialog, or the error of each element submitted with an invalid value.
+func configureSubmission(submission map[string]any) (string, url.Values, map[string]string) {
+	errs := map[string]string{}
+	query := url.Values{}
+
+	connType := fmt.Sprint(submission[configureConnType])
+	endpoint, ok := configureEndpoints[connType]
+	if !ok {
+		errs[configureConnType] = fmt.Sprintf("Unknown connection type %q.", connType)
+	}
+
+	for _, name := range []string{configureRecords, configurePageSize} {
+		value, ok := submission[name]
+		if !ok || value == nil {
+			continue
+		}
+
+		// Number elements are submitted as numbers by some clients and as strings by others.
+		n, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(value)))
+		if err != nil || n <= 0 {
+			errs[name] = "Must be a positive whole number."
+			continue
+		}
+		query.Set(name, strconv.Itoa(n))
+	}
+
+	if mode, ok := submission[configureMode].(string); ok && mode != "" {
+		if !slices.Contains(workloadModes, mode) {
+			errs[configureMode] = fmt.Sprintf("Unknown mode %q.", mode)
+		}
+		query.Set(configureMode, mode)
+	}
+
+	if value, ok := submission[configureTags].(string); ok && value != "" {
+		tags, err := parseTags(value)
+		if err != nil {
+			errs[configureTags] = err.Error()
+		} else if len(tags) > 0 {
+			query.Set(configureTags, strings.Join(tags, ","))
+		}
+	}
+
+	if notes, ok := submission[configureNotes].(string); ok && notes != "" {
+		if err := validateNotes(notes); err != nil {
+			errs[configureNotes] = err.Error()
+		}
+		query.Set(configureNotes, notes)
+	}
+
+	if len(errs) > 0 {
+		return "", nil, errs
+	}
+	return endpoint, query, nil
+}
+
+// SubmitConfigureDialog queues the benchmark configured in the configure dialog for the user
+// who submitted it, who is told its run ID in the channel they opened the dialog from and sent
+// its result by direct message once it completes.
+func (p *Plugin) SubmitConfigureDialog(w http.ResponseWriter, r *http.Request) {
+	var request model.SubmitDialogRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, model.SubmitDialogResponse{Error: "invalid dialog submission: " + err.Error()})
+		return
+	}
+	if request.Cancelled {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	endpoint, query, errs := configureSubmission(request.Submission)
+	if errs != nil {
+		respondWithJSON(w, http.StatusOK, model.SubmitDialogResponse{Errors: errs})
+		return
+	}
+
+	userID := r.Header.Get("Mattermost-User-ID")
+	run := queuedRun{
+		RunID:    model.NewId(),
+		UserID:   userID,
+		Method:   http.MethodGet,
+		URL:      &url.URL{Path: endpoint, RawQuery: query.Encode()},
+		QueuedAt: time.Now(),
+	}
+	position, err := p.pushQueuedRun(run)
+	if err != nil {
+		respondWithJSON(w, http.StatusOK, model.SubmitDialogResponse{Error: err.Error()})
+		return
+	}
+
+	if request.ChannelId != "" {
+		p.client.Post.SendEphemeralPost(userID, &model.Post{
+			ChannelId: request.ChannelId,
+			Message: fmt.Sprintf("Queued benchmark `%s %s` as run `%s`, at position %d in the queue. You will get a direct message with its result once it completes.",
+				run.Method, run.URL, run.RunID, position),
+		})
+	}
+	respondWithJSON(w, http.StatusOK, model.SubmitDialogResponse{})
+}
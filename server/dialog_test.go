@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureSubmission(t *testing.T) {
+	endpoint, query, errs := configureSubmission(map[string]any{"conn_type": "raw", "records": "1000", "page_size": 50.0, "mode": "join", "tags": "before-upgrade, pg15"})
+	assert.Nil(t, errs)
+	assert.Equal(t, "/api/v1/test_raw", endpoint)
+	assert.Equal(t, "mode=join&page_size=50&records=1000&tags=before-upgrade%2Cpg15", query.Encode())
+
+	endpoint, query, errs = configureSubmission(map[string]any{"conn_type": "rpc", "records": nil})
+	assert.Nil(t, errs)
+	assert.Equal(t, "/api/v1/test", endpoint)
+	assert.Empty(t, query)
+
+	_, _, errs = configureSubmission(map[string]any{"conn_type": "rest", "records": "-5", "page_size": "many", "mode": "bogus", "tags": "no spaces"})
+	assert.Equal(t, map[string]string{
+		"conn_type": `Unknown connection type "rest".`,
+		"records":   "Must be a positive whole number.",
+		"page_size": "Must be a positive whole number.",
+		"mode":      `Unknown mode "bogus".`,
+		"tags":      `invalid tag "no spaces": must be at most 63 characters of letters, digits, '.', '_', ':' or '-'`,
+	}, errs)
+}
+
+func TestDBTestConfigureCommand(t *testing.T) {
+	bundlePath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bundlePath, "plugin.json"), []byte(`{"id": "com.mattermost.test-rpc-database"}`), 0600))
+
+	api := adminAPI()
+	api.On("GetBundlePath").Return(bundlePath, nil)
+
+	var opened model.OpenDialogRequest
+	api.On("OpenInteractiveDialog", mock.Anything).Run(func(args mock.Arguments) {
+		opened = args.Get(0).(model.OpenDialogRequest)
+	}).Return(nil)
+
+	p := Plugin{}
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(api, nil)
+
+	response, appErr := p.ExecuteCommand(nil, &model.CommandArgs{UserId: "admin", Command: "/dbtest configure", TriggerId: "trigger"})
+	require.Nil(t, appErr)
+	assert.Empty(t, response.Text)
+
+	assert.Equal(t, "trigger", opened.TriggerId)
+	assert.Equal(t, "/plugins/com.mattermost.test-rpc-database/api/v1/dialog/configure", opened.URL)
+	require.Len(t, opened.Dialog.Elements, 6)
+	assert.Equal(t, "conn_type", opened.Dialog.Elements[0].Name)
+	assert.Len(t, opened.Dialog.Elements[3].Options, len(workloadModes))
+}
+
+func TestSubmitConfigureDialog(t *testing.T) {
+	api := adminAPI()
+	var ephemeral *model.Post
+	api.On("SendEphemeralPost", "admin", mock.Anything).Run(func(args mock.Arguments) {
+		ephemeral = args.Get(1).(*model.Post).Clone()
+	}).Return(&model.Post{})
+
+	store := &fakeRunLockStore{}
+	p := Plugin{kvstore: store}
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(api, nil)
+
+	// Keep the queue from draining, so the run stays queued.
+	p.runQueueDraining = true
+
+	submit := func(request model.SubmitDialogRequest) (int, model.SubmitDialogResponse) {
+		body, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/dialog/configure", bytes.NewReader(body))
+		r.Header.Set("Mattermost-User-ID", "admin")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, r)
+
+		var response model.SubmitDialogResponse
+		if w.Body.Len() > 0 {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w.Code, response
+	}
+
+	t.Run("invalid values", func(t *testing.T) {
+		status, response := submit(model.SubmitDialogRequest{Submission: map[string]any{"conn_type": "rpc", "records": "0"}})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, response.Errors, "records")
+		assert.Zero(t, p.runQueueLength())
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		status, _ := submit(model.SubmitDialogRequest{Cancelled: true})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Zero(t, p.runQueueLength())
+	})
+
+	t.Run("queued", func(t *testing.T) {
+		status, response := submit(model.SubmitDialogRequest{ChannelId: "channel", Submission: map[string]any{
+			"conn_type": "raw", "records": "1000", "page_size": "50", "mode": "scan", "notes": "after reindex",
+		}})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Empty(t, response.Error)
+
+		require.Len(t, p.runQueue, 1)
+		run := p.runQueue[0]
+		assert.Equal(t, "admin", run.UserID)
+		assert.Equal(t, "/api/v1/test_raw", run.URL.Path)
+		assert.Equal(t, "mode=scan&notes=after+reindex&page_size=50&records=1000", run.URL.RawQuery)
+		assert.Equal(t, jobQueued, store.jobs[run.RunID].Status)
+
+		require.NotNil(t, ephemeral)
+		assert.Equal(t, "channel", ephemeral.ChannelId)
+		assert.Contains(t, ephemeral.Message, "run `"+run.RunID+"`")
+	})
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// documentChannels is the number of distinct channel values spread across the documents.
+	documentChannels = 100
+
+	// documentPriorities is the number of distinct nested meta.priority values.
+	documentPriorities = 5
+
+	// documentFilterQueries is the number of queries run for each JSON path filter.
+	documentFilterQueries = 20
+)
+
+// testDocument is the JSON document stored in each plugin_test_rpc_json row, shaped like the
+// structured props plugins commonly keep.
+type testDocument struct {
+	Index   int          `json:"index"`
+	Channel string       `json:"channel"`
+	Message string       `json:"message"`
+	Tags    []string     `json:"tags"`
+	Meta    testMetadata `json:"meta"`
+}
+
+// testMetadata is the nested object within a testDocument.
+type testMetadata struct {
+	Priority int  `json:"priority"`
+	Pinned   bool `json:"pinned"`
+}
+
+// JSONFilterResult reports the timing of the queries of one JSON path filter.
+type JSONFilterResult struct {
+	Path         string  `json:"path"`
+	Queries      int     `json:"queries"`
+	RowsReturned int     `json:"rows_returned"`
+	TimeSeconds  float64 `json:"time_seconds"`
+}
+
+// testDocumentJSON generates the document for row i.
+func testDocumentJSON(i int) ([]byte, error) {
+	return json.Marshal(testDocument{
+		Index:   i,
+		Channel: fmt.Sprintf("channel-%d", i%documentChannels),
+		Message: testData(i, 0),
+		Tags:    []string{"benchmark", fmt.Sprintf("tag-%d", i%10)},
+		Meta: testMetadata{
+			Priority: i % documentPriorities,
+			Pinned:   i%2 == 0,
+		},
+	})
+}
+
+// runJSONTest seeds plugin_test_rpc_json with opts.Records JSON documents, pages through them and
+// then filters them by path expressions, measuring the cost of moving JSON values over each
+// connection type.
+func (p *Plugin) runJSONTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeJSON,
+		Phase:    opts.Phase,
+		PageSize: opts.PageSize,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedDocumentTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryDocuments(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// seedDocumentTable creates plugin_test_rpc_json if needed and tops it up to opts.Records
+// documents in a single transaction, returning the number of rows inserted and the time spent
+// inserting.
+func (p *Plugin) seedDocumentTable(db *sql.DB, driverName string, opts testOptions) (int, time.Duration, error) {
+	var createTableSQL string
+	if driverName == "postgres" {
+		createTableSQL = `
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_json (
+				id SERIAL PRIMARY KEY,
+				props JSONB NOT NULL
+			)
+		`
+	} else {
+		createTableSQL = `
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_json (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				props JSON NOT NULL
+			)
+		`
+	}
+
+	if _, err := db.Exec(opts.tagSQL(createTableSQL)); err != nil {
+		return 0, 0, fmt.Errorf("failed to create json table: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(opts.tagSQL("SELECT COUNT(*) FROM plugin_test_rpc_json")).Scan(&count); err != nil {
+		return 0, 0, fmt.Errorf("failed to check document count: %v", err)
+	}
+
+	if count >= opts.Records {
+		return 0, 0, nil
+	}
+
+	p.API.LogInfo(fmt.Sprintf("Inserting documents: %d of %d", count, opts.Records))
+	startInsert := time.Now()
+
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	insertStmt, err := tx.Prepare(opts.tagSQL(rebind(driverName, "INSERT INTO plugin_test_rpc_json (props) VALUES (?)")))
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			p.API.LogError("Failed to rollback transaction", "error", rbErr)
+		}
+		return 0, 0, fmt.Errorf("failed to prepare statement: %v", err)
+	}
+	defer insertStmt.Close()
+
+	for i := count; i < opts.Records; i++ {
+		document, err := testDocumentJSON(i)
+		if err == nil {
+			_, err = insertStmt.Exec(string(document))
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				p.API.LogError("Failed to rollback transaction", "error", rbErr)
+			}
+			return 0, 0, fmt.Errorf("failed to insert document %d: %v", i, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return opts.Records - count, time.Since(startInsert), nil
+}
+
+// documentFilter is a path-expression filter over plugin_test_rpc_json.
+type documentFilter struct {
+	// Path names the filtered path in results.
+	Path string
+
+	// Condition is the driver-specific WHERE clause, taking the filter value as its only
+	// placeholder.
+	Condition string
+
+	// Value returns the filter value for query k.
+	Value func(k int) any
+}
+
+// query returns the filtered lookup, taking the filter value and the limit.
+func (f documentFilter) query() string {
+	return "SELECT id, props FROM plugin_test_rpc_json WHERE " + f.Condition + " ORDER BY id LIMIT ?"
+}
+
+// documentFilters returns the path filters run against plugin_test_rpc_json: a top-level string
+// field and a nested numeric field.
+func documentFilters(flavor string) []documentFilter {
+	channel := documentFilter{
+		Path:  "$.channel",
+		Value: func(k int) any { return fmt.Sprintf("channel-%d", k%documentChannels) },
+	}
+	priority := documentFilter{
+		Path:  "$.meta.priority",
+		Value: func(k int) any { return k % documentPriorities },
+	}
+
+	switch flavor {
+	case flavorPostgres:
+		channel.Condition = "props->>'channel' = ?"
+		priority.Condition = "(props->'meta'->>'priority')::int = ?"
+	case flavorMariaDB:
+		// MariaDB has no ->> operator.
+		channel.Condition = "JSON_UNQUOTE(JSON_EXTRACT(props, '$.channel')) = ?"
+		priority.Condition = "JSON_EXTRACT(props, '$.meta.priority') = ?"
+	default:
+		channel.Condition = "props->>'$.channel' = ?"
+		priority.Condition = "JSON_EXTRACT(props, '$.meta.priority') = ?"
+	}
+
+	return []documentFilter{channel, priority}
+}
+
+// documentPageSQL reads one page of documents.
+const documentPageSQL = "SELECT id, props FROM plugin_test_rpc_json ORDER BY id LIMIT ? OFFSET ?"
+
+// queryDocuments pages through the first opts.Records documents and then runs
+// documentFilterQueries queries of each path filter, each returning at most opts.PageSize rows.
+// The filter timings are recorded on result alongside the total time and the rows and bytes read.
+func queryDocuments(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+
+	querySQL := opts.tagSQL(rebind(driverName, documentPageSQL))
+	for offset := 0; offset < opts.Records; offset += opts.PageSize {
+		limit := min(opts.PageSize, opts.Records-offset)
+
+		if _, err := readDocuments(db, querySQL, result, limit, offset); err != nil {
+			return fmt.Errorf("failed to query documents at offset %d: %v", offset, err)
+		}
+	}
+
+	for _, filter := range documentFilters(opts.Flavor) {
+		filterResult := JSONFilterResult{Path: filter.Path}
+		start := time.Now()
+
+		filterSQL := opts.tagSQL(rebind(driverName, filter.query()))
+		for k := 0; k < documentFilterQueries; k++ {
+			returned, err := readDocuments(db, filterSQL, result, filter.Value(k), opts.PageSize)
+			if err != nil {
+				return fmt.Errorf("failed to filter documents by %s: %v", filter.Path, err)
+			}
+			filterResult.RowsReturned += returned
+			filterResult.Queries++
+		}
+
+		filterResult.TimeSeconds = time.Since(start).Seconds()
+		result.JSONFilters = append(result.JSONFilters, filterResult)
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
+
+// readDocuments runs querySQL with args and reads every returned document, counting the rows
+// and bytes read on result and returning the number of rows.
+func readDocuments(db *sql.DB, querySQL string, result *TestResult, args ...any) (int, error) {
+	rows, err := db.Query(querySQL, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	returned := 0
+	for rows.Next() {
+		var id int
+		var props []byte
+		if err := rows.Scan(&id, &props); err != nil {
+			return returned, fmt.Errorf("failed to scan document: %v", err)
+		}
+		returned++
+		result.RecordsQueried++
+		result.BytesQueried += int64(len(props))
+	}
+
+	return returned, rows.Err()
+}
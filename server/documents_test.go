@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestDocumentJSON(t *testing.T) {
+	encoded, err := testDocumentJSON(123)
+	require.NoError(t, err)
+
+	var document testDocument
+	require.NoError(t, json.Unmarshal(encoded, &document))
+	assert.Equal(t, 123, document.Index)
+	assert.Equal(t, "channel-23", document.Channel)
+	assert.Equal(t, 3, document.Meta.Priority)
+	assert.False(t, document.Meta.Pinned)
+
+	again, err := testDocumentJSON(123)
+	require.NoError(t, err)
+	assert.Equal(t, encoded, again)
+}
+
+func TestDocumentFilters(t *testing.T) {
+	for _, flavor := range []string{flavorPostgres, flavorMySQL, flavorMariaDB} {
+		filters := documentFilters(flavor)
+		require.Len(t, filters, 2)
+		for _, filter := range filters {
+			assert.Contains(t, filter.Condition, "?", flavor)
+		}
+		assert.Equal(t, "channel-5", filters[0].Value(105))
+		assert.Equal(t, 0, filters[1].Value(5))
+	}
+}
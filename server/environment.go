@@ -0,0 +1,148 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// driverModules maps driver names to the Go modules implementing them.
+var driverModules = map[string]string{
+	"postgres": "github.com/lib/pq",
+	"mysql":    "github.com/go-sql-driver/mysql",
+	"sqlite":   "modernc.org/sqlite",
+}
+
+// cgroupRoot is where the cgroup filesystem of the plugin's container is mounted.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// Environment describes where a run executed, so that historical results remain interpretable
+// after the server, plugin, driver or host change.
+type Environment struct {
+	ServerVersion string `json:"server_version,omitempty"`
+	PluginVersion string `json:"plugin_version,omitempty"`
+	DriverName    string `json:"driver_name,omitempty"`
+
+	// DriverVersion is the version of the driver linked into the plugin, which serves raw
+	// connections. RPC connections use the server's own driver, which ships with ServerVersion.
+	DriverVersion string `json:"driver_version,omitempty"`
+
+	GoVersion  string `json:"go_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	CPUs       int    `json:"cpus"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+
+	// GoMemoryLimitBytes is the soft memory limit of the plugin process's Go runtime, as set by
+	// GOMEMLIMIT, left out when there is none.
+	GoMemoryLimitBytes int64 `json:"go_memory_limit_bytes,omitempty"`
+
+	// ContainerMemoryLimitBytes is the memory limit of the cgroup the plugin runs in, such as a
+	// container's, left out when there is none or it cannot be read.
+	ContainerMemoryLimitBytes int64 `json:"container_memory_limit_bytes,omitempty"`
+}
+
+// environment describes the environment of a run against driverName.
+func (p *Plugin) environment(driverName string) *Environment {
+	return &Environment{
+		ServerVersion: p.API.GetServerVersion(),
+		PluginVersion: p.pluginVersion(),
+		DriverName:    driverName,
+		DriverVersion: driverVersion(driverName),
+		GoVersion:     runtime.Version(),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		CPUs:          runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		// A negative limit reads the current one without changing it.
+		GoMemoryLimitBytes:        goMemoryLimit(debug.SetMemoryLimit(-1)),
+		ContainerMemoryLimitBytes: containerMemoryLimit(cgroupRoot),
+	}
+}
+
+// goMemoryLimit returns the Go runtime memory limit, or 0 if it is the default of no limit.
+func goMemoryLimit(limit int64) int64 {
+	if limit == math.MaxInt64 {
+		return 0
+	}
+	return limit
+}
+
+// containerMemoryLimit returns the memory limit of the cgroup mounted at root, read from
+// memory.max under cgroup v2 or memory/memory.limit_in_bytes under cgroup v1, or 0 if the cgroup
+// has no limit or neither can be read, such as outside Linux.
+func containerMemoryLimit(root string) int64 {
+	for _, name := range []string{"memory.max", filepath.Join("memory", "memory.limit_in_bytes")} {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			continue
+		}
+
+		value := strings.TrimSpace(string(data))
+		if value == "max" {
+			return 0
+		}
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0
+		}
+		// cgroup v1 reports no limit as the largest int64 that is a multiple of the page size.
+		if limit > math.MaxInt64-int64(os.Getpagesize()) {
+			return 0
+		}
+		return limit
+	}
+	return 0
+}
+
+// pluginVersion returns the version in the manifest of the plugin's bundle, or "" if it cannot
+// be read.
+func (p *Plugin) pluginVersion() string {
+	manifest := p.pluginManifest()
+	if manifest == nil {
+		return ""
+	}
+	return manifest.Version
+}
+
+// pluginManifest returns the manifest of the plugin's bundle, or nil if it cannot be read.
+func (p *Plugin) pluginManifest() *model.Manifest {
+	bundlePath, err := p.API.GetBundlePath()
+	if err != nil {
+		p.API.LogWarn("Failed to get bundle path", "error", err)
+		return nil
+	}
+
+	manifest, _, err := model.FindManifest(bundlePath)
+	if err != nil {
+		p.API.LogWarn("Failed to read plugin manifest", "error", err)
+		return nil
+	}
+	return manifest
+}
+
+// driverVersion returns the version of the module implementing driverName linked into the
+// plugin, or "" if unknown.
+func driverVersion(driverName string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == driverModules[driverName] {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironment(t *testing.T) {
+	bundlePath := t.TempDir()
+	manifest := `{"id": "com.mattermost.test-rpc-database", "version": "1.2.3"}`
+	require.NoError(t, os.WriteFile(filepath.Join(bundlePath, "plugin.json"), []byte(manifest), 0600))
+
+	api := &plugintest.API{}
+	api.On("GetServerVersion").Return("10.5.0")
+	api.On("GetBundlePath").Return(bundlePath, nil)
+	p := Plugin{}
+	p.SetAPI(api)
+
+	env := p.environment("postgres")
+
+	assert.Equal(t, "10.5.0", env.ServerVersion)
+	assert.Equal(t, "1.2.3", env.PluginVersion)
+	assert.Equal(t, "postgres", env.DriverName)
+	assert.NotEmpty(t, env.DriverVersion)
+	assert.Equal(t, runtime.GOOS, env.OS)
+	assert.Equal(t, runtime.NumCPU(), env.CPUs)
+	assert.Equal(t, runtime.GOMAXPROCS(0), env.GOMAXPROCS)
+}
+
+func TestContainerMemoryLimit(t *testing.T) {
+	cgroup := func(t *testing.T, name, value string) string {
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(value+"\n"), 0600))
+		return root
+	}
+
+	for _, tc := range []struct {
+		name, file, value string
+		expected          int64
+	}{
+		{"cgroup v2 limit", "memory.max", "2147483648", 2147483648},
+		{"cgroup v2 without limit", "memory.max", "max", 0},
+		{"cgroup v1 limit", "memory/memory.limit_in_bytes", "1073741824", 1073741824},
+		{"cgroup v1 without limit", "memory/memory.limit_in_bytes", "9223372036854771712", 0},
+		{"unreadable limit", "memory.max", "lots", 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, containerMemoryLimit(cgroup(t, tc.file, tc.value)))
+		})
+	}
+
+	t.Run("no cgroup", func(t *testing.T) {
+		assert.Zero(t, containerMemoryLimit(t.TempDir()))
+	})
+}
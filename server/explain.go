@@ -0,0 +1,204 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ExplainResult reports the plan of one representative query of a workload.
+type ExplainResult struct {
+	Query    string `json:"query"`
+	Analyzed bool   `json:"analyzed"`
+	Plan     string `json:"plan,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// explainQuery is a representative query of a workload along with the arguments it is
+// explained with.
+type explainQuery struct {
+	// Name identifies the query in results.
+	Name string
+
+	// Settings are statements run first in the same transaction, as the workload does.
+	Settings []string
+
+	// SQL is the query, with placeholders in either style.
+	SQL string
+
+	Args []any
+}
+
+// representativeQueries returns the queries that dominate the workload selected by opts.Mode.
+func representativeQueries(driverName string, opts testOptions) []explainQuery {
+	switch opts.Mode {
+	case modeBlob:
+		return []explainQuery{{Name: "page", SQL: blobPageSQL, Args: []any{opts.PayloadBytes, opts.PageSize, 0}}}
+	case modeJoin:
+		return []explainQuery{{Name: "page", SQL: joinPageSQL, Args: []any{opts.PageSize, 0}}}
+	case modeAggregate:
+		queries := make([]explainQuery, 0, len(aggregateGroupCounts))
+		for _, groups := range aggregateGroupCounts {
+			queries = append(queries, explainQuery{Name: fmt.Sprintf("aggregate_%d", groups), SQL: aggregateSQL, Args: []any{groups, opts.Records}})
+		}
+		return queries
+	case modeSearch:
+		queries := make([]explainQuery, 0, len(searchKinds))
+		for _, kind := range searchKinds {
+			query, term := searchSQL(driverName, searchTerms(kind, opts.Records)[0], kind != searchCommon)
+			queries = append(queries, explainQuery{Name: kind, SQL: query, Args: []any{term, opts.PageSize}})
+		}
+		return queries
+	case modeJSON:
+		queries := []explainQuery{{Name: "page", SQL: documentPageSQL, Args: []any{opts.PageSize, 0}}}
+		for _, filter := range documentFilters(opts.Flavor) {
+			queries = append(queries, explainQuery{Name: filter.Path, SQL: filter.query(), Args: []any{filter.Value(0), opts.PageSize}})
+		}
+		return queries
+	case modeFullScan:
+		return []explainQuery{{Name: "fullscan", SQL: fullScanSQL, Args: []any{opts.Records}}}
+	case modeWide:
+		return []explainQuery{{Name: "page", SQL: widePageSQL(opts.Columns), Args: []any{opts.PageSize, 0}}}
+	case modeNulls:
+		return []explainQuery{{Name: "page", SQL: nullsPageSQL, Args: []any{opts.nullPermille(), opts.PageSize, 0}}}
+	case modeText:
+		return []explainQuery{{Name: "page", SQL: textPageSQL, Args: []any{opts.Charset, opts.PageSize, 0}}}
+	case modeTimestamps:
+		return []explainQuery{{Name: "page", SQL: timestampPageSQL, Args: []any{opts.PageSize, 0}}}
+	case modeNumeric:
+		return []explainQuery{{Name: "page", SQL: numericPageSQL, Args: []any{opts.PageSize, 0}}}
+	case modeSquirrel:
+		// The query is built with ? placeholders, which explaining rebinds for the driver. Building
+		// only fails for a query selecting no columns.
+		query, args, _ := squirrelPage(sq.Question, 0, opts.PageSize)
+		return []explainQuery{{Name: "page", SQL: query, Args: args}}
+	case modePointLookup:
+		return []explainQuery{{Name: "lookup", SQL: pointLookupSQL, Args: []any{opts.Records / 2}}}
+	case modeSavepoint, modeDeadlock, modeRowLock, modeTimeout, modeNoop:
+		// These workloads only write, sleep or do nothing, so they have nothing worth explaining.
+		return nil
+	case modePlanCompare:
+		value := testData(0, opts.RowBytes)
+		variants := planVariants(driverName)
+		queries := make([]explainQuery, 0, len(variants))
+		for _, variant := range variants {
+			queries = append(queries, explainQuery{Name: variant.Name, Settings: variant.Settings, SQL: variant.Query, Args: []any{value, opts.PageSize}})
+		}
+		return queries
+	default:
+		return []explainQuery{{Name: "page", SQL: scanPageSQL, Args: []any{opts.PageSize, 0}}}
+	}
+}
+
+// explainWorkload captures the plan of each representative query of the workload selected by
+// opts. Failures are reported per query rather than failing the run.
+func (p *Plugin) explainWorkload(db *sql.DB, driverName string, opts testOptions) []ExplainResult {
+	queries := representativeQueries(driverName, opts)
+	results := make([]ExplainResult, 0, len(queries))
+
+	for _, query := range queries {
+		result := ExplainResult{Query: query.Name}
+
+		plan, analyzed, err := p.explain(db, driverName, opts, query)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Plan = plan
+			result.Analyzed = analyzed
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// explain returns the plan of query, executing it with EXPLAIN ANALYZE, or MariaDB's ANALYZE,
+// where the database supports it and falling back to a plain EXPLAIN otherwise (MySQL before
+// 8.0.18). SQLite cannot execute a query while explaining it, so only its plan is returned.
+func (p *Plugin) explain(db *sql.DB, driverName string, opts testOptions, query explainQuery) (string, bool, error) {
+	if driverName == "sqlite" {
+		plan, err := p.explainWith(db, driverName, opts, query, "EXPLAIN QUERY PLAN ")
+		return plan, false, err
+	}
+
+	prefix := "EXPLAIN ANALYZE "
+	switch {
+	case driverName == "postgres":
+		prefix = "EXPLAIN (ANALYZE, BUFFERS) "
+	case opts.Flavor == flavorMariaDB:
+		prefix = "ANALYZE "
+	}
+
+	plan, err := p.explainWith(db, driverName, opts, query, prefix)
+	if err == nil {
+		return plan, true, nil
+	}
+	if driverName == "postgres" {
+		return "", false, err
+	}
+
+	plan, err = p.explainWith(db, driverName, opts, query, "EXPLAIN ")
+	if err != nil {
+		return "", false, err
+	}
+
+	return plan, false, nil
+}
+
+// explainWith runs query prefixed by the given EXPLAIN statement within a transaction that is
+// rolled back afterwards, returning the output with one line per row and tab-separated columns.
+func (p *Plugin) explainWith(db *sql.DB, driverName string, opts testOptions, query explainQuery, prefix string) (string, error) {
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil {
+			p.API.LogError("Failed to rollback transaction", "error", err)
+		}
+	}()
+
+	for _, setting := range query.Settings {
+		if _, err := tx.Exec(opts.tagSQL(setting)); err != nil {
+			return "", fmt.Errorf("failed to apply %q: %v", setting, err)
+		}
+	}
+
+	rows, err := tx.Query(opts.tagSQL(prefix+rebind(driverName, query.SQL)), query.Args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to explain %s: %v", query.Name, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("failed to read plan columns: %v", err)
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	var lines []string
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "", fmt.Errorf("failed to scan plan: %v", err)
+		}
+
+		fields := make([]string, len(values))
+		for i, value := range values {
+			fields[i] = value.String
+		}
+		lines = append(lines, strings.Join(fields, "\t"))
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to read plan: %v", err)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepresentativeQueries(t *testing.T) {
+	modes := []string{modeScan, modeBlob, modeJoin, modeAggregate, modeSearch, modeJSON, modePointLookup, modePlanCompare, modeFullScan}
+	opts := testOptions{PageSize: defaultPageSize, Records: defaultRecords, PayloadBytes: defaultPayloadBytes}
+
+	for _, mode := range modes {
+		opts.Mode = mode
+
+		for _, flavor := range []string{flavorMySQL, flavorMariaDB} {
+			opts.Flavor = flavor
+			for _, query := range representativeQueries("mysql", opts) {
+				assert.Equal(t, len(query.Args), strings.Count(query.SQL, "?"), "%s %s %s", flavor, mode, query.Name)
+			}
+		}
+		opts.Flavor = flavorPostgres
+		for _, query := range representativeQueries("postgres", opts) {
+			assert.Equal(t, len(query.Args), strings.Count(rebind("postgres", query.SQL), "$"), "%s %s", mode, query.Name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+)
+
+// Bounds on the number of runs exported by ExportResults, each of which takes a sheet.
+const (
+	defaultExportRuns = 50
+	maxExportRuns     = 250
+)
+
+// exportSummaryHeader heads the columns of the summary sheet of an export, one row per run.
+var exportSummaryHeader = []any{
+	"Run ID", "Stored (UTC)", "Connection", "Mode", "Label", "Params", "Notes", "Records queried", "Page size",
+	"Insert time (s)", "Total query time (s)", "Query rate (rows/s)", "Lookup rate (lookups/s)",
+	"Lookup p99 (ms)", "Duration (s)", "Regressed", "Error",
+}
+
+// ExportResults serves the most recently stored runs as an xlsx workbook, with a summary sheet
+// of one row per run followed by a sheet per run listing every field of its result, for reviews
+// that happen in spreadsheets. The limit query param sets how many runs are exported.
+func (p *Plugin) ExportResults(w http.ResponseWriter, r *http.Request) {
+	limit := defaultExportRuns
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			limit = min(n, maxExportRuns)
+		}
+	}
+
+	runs, err := p.kvstore.ListRuns()
+	if err != nil {
+		p.API.LogError("Failed to list runs to export", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, TestResult{Error: err.Error()})
+		return
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].CreatedAt > runs[j].CreatedAt })
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	var workbook bytes.Buffer
+	if err := writeXLSX(&workbook, exportSheets(runs)); err != nil {
+		p.API.LogError("Failed to build export", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, TestResult{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "benchmark-runs.xlsx"))
+	if _, err := w.Write(workbook.Bytes()); err != nil {
+		p.API.LogError("Failed to write export", "error", err)
+	}
+}
+
+// exportSheets returns the summary sheet of runs followed by a sheet for each run, in order.
+// A run whose stored result cannot be decoded is summarized with the error instead.
+func exportSheets(runs []kvstore.Run) []xlsxSheet {
+	summary := [][]any{exportSummaryHeader}
+	var sheets []xlsxSheet
+
+	for _, run := range runs {
+		stored := time.UnixMilli(run.CreatedAt).UTC().Format(time.DateTime)
+
+		result, err := decodeTestResult(run.Result)
+		if err != nil {
+			summary = append(summary, []any{run.ID, stored, run.ConnType, nil, nil, run.Params, exportText(run.Notes),
+				nil, nil, nil, nil, nil, nil, nil, nil, nil, fmt.Sprintf("invalid stored result: %v", err)})
+			continue
+		}
+
+		var lookupP99 any
+		if result.LookupLatency != nil {
+			lookupP99 = result.LookupLatency.P99
+		}
+		var regressed any
+		if result.Regression != nil {
+			regressed = result.Regression.Regressed
+		}
+		summary = append(summary, []any{
+			run.ID, stored, run.ConnType, exportText(result.Mode), exportText(result.Label), exportText(run.Params),
+			exportText(run.Notes),
+			exportNumber(float64(result.RecordsQueried)), exportNumber(float64(result.PageSize)),
+			exportNumber(result.InsertTimeSeconds), exportNumber(result.TotalQueryTimeSeconds),
+			exportNumber(result.QueryRowsPerSecond), exportNumber(result.LookupsPerSecond),
+			lookupP99, exportNumber(result.DurationSeconds), regressed, exportText(result.Error),
+		})
+
+		rows := [][]any{{"Run ID", run.ID}, {"Stored (UTC)", stored}, {"Params", run.Params}}
+		if run.Notes != "" {
+			rows = append(rows, []any{"Notes", run.Notes})
+		}
+		rows = append(rows, []any{}, []any{"Field", "Value"})
+		sheets = append(sheets, xlsxSheet{Name: run.ID, Rows: append(rows, exportFields(run.Result)...)})
+	}
+
+	return append([]xlsxSheet{{Name: "Summary", Rows: summary}}, sheets...)
+}
+
+// exportFields lists a stored result as a row per field, named by its path within the JSON, such
+// as lookup_latency.p99_ms or latency_trend.3.avg_ms.
+func exportFields(result json.RawMessage) [][]any {
+	fields, err := flattenResult(result)
+	if err != nil {
+		return nil
+	}
+
+	rows := make([][]any, 0, len(fields))
+	for _, field := range fields {
+		rows = append(rows, []any{field.Path, field.Value})
+	}
+	return rows
+}
+
+// resultField is a field of a result, named by its dotted path within the result's JSON.
+type resultField struct {
+	Path  string
+	Value any
+
+	// InList is true for a field of an element of a list, such as latency_trend.3.avg_ms.
+	InList bool
+}
+
+// flattenResult returns every field of the result JSON holding a string, number, boolean or
+// null, in order of their paths.
+func flattenResult(result json.RawMessage) ([]resultField, error) {
+	var decoded any
+	if err := json.Unmarshal(result, &decoded); err != nil {
+		return nil, err
+	}
+
+	var fields []resultField
+	var flatten func(path string, value any, inList bool)
+	flatten = func(path string, value any, inList bool) {
+		switch value := value.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(value))
+			for key := range value {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				flatten(joinFieldPath(path, key), value[key], inList)
+			}
+		case []any:
+			for i, element := range value {
+				flatten(joinFieldPath(path, strconv.Itoa(i)), element, true)
+			}
+		default:
+			fields = append(fields, resultField{Path: path, Value: value, InList: inList})
+		}
+	}
+	flatten("", decoded, false)
+	return fields, nil
+}
+
+// joinFieldPath appends key to the dotted path of a field.
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// exportNumber returns value as a cell, or an empty cell if the run did not report it.
+func exportNumber(value float64) any {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+// exportText returns value as a cell, or an empty cell if the run did not report it.
+func exportText(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
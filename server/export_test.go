@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportResults(t *testing.T) {
+	p := Plugin{kvstore: fakeRetentionStore{KVStore: &fakeRunLockStore{}, runs: map[string]kvstore.Run{
+		"older": {ID: "older", ConnType: "raw", Params: "conn_type=raw", Notes: "during backup", CreatedAt: 1000,
+			Result: []byte(`{"conn_type": "raw", "records_queried": 500, "lookup_latency": {"p99_ms": 2.5}, "latency_trend": [{"offset": 0, "avg_ms": 1}]}`)},
+		"newer":   {ID: "newer", ConnType: "rpc", CreatedAt: 3000, Result: []byte(`{"conn_type": "rpc", "label": "nightly"}`)},
+		"corrupt": {ID: "corrupt", ConnType: "rpc", CreatedAt: 2000, Result: []byte(`{`)},
+	}}}
+	p.SetAPI(adminAPI())
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, target))
+		return w
+	}
+
+	t.Run("all runs", func(t *testing.T) {
+		w := serve("/api/v1/results/export.xlsx")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="benchmark-runs.xlsx"`, w.Header().Get("Content-Disposition"))
+
+		parts := readXLSX(t, w.Body.Bytes())
+
+		// The summary comes first, then the runs that decoded, newest first.
+		workbook := parts["xl/workbook.xml"]
+		assert.Contains(t, workbook, `<sheet name="Summary" sheetId="1"`)
+		assert.Contains(t, workbook, `<sheet name="newer" sheetId="2"`)
+		assert.Contains(t, workbook, `<sheet name="older" sheetId="3"`)
+		assert.NotContains(t, workbook, `name="corrupt"`)
+
+		summary := parts["xl/worksheets/sheet1.xml"]
+		assert.Contains(t, summary, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">newer</t></is></c>`)
+		assert.Contains(t, summary, `<c r="E2" t="inlineStr"><is><t xml:space="preserve">nightly</t></is></c>`)
+		assert.Contains(t, summary, `<c r="A3" t="inlineStr"><is><t xml:space="preserve">corrupt</t></is></c>`)
+		assert.Contains(t, summary, "invalid stored result")
+		assert.Contains(t, summary, `<c r="G4" t="inlineStr"><is><t xml:space="preserve">during backup</t></is></c>`)
+		assert.Contains(t, summary, `<c r="H4"><v>500</v></c>`)
+		assert.Contains(t, summary, `<c r="N4"><v>2.5</v></c>`)
+
+		older := parts["xl/worksheets/sheet3.xml"]
+		assert.Contains(t, older, "conn_type=raw")
+		assert.Contains(t, older, "during backup")
+		assert.Contains(t, older, "lookup_latency.p99_ms")
+		assert.Contains(t, older, "latency_trend.0.avg_ms")
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		w := serve("/api/v1/results/export.xlsx?limit=1")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		parts := readXLSX(t, w.Body.Bytes())
+		assert.Contains(t, parts["xl/workbook.xml"], `name="newer"`)
+		assert.NotContains(t, parts["xl/workbook.xml"], `name="older"`)
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const (
+	// flavorPostgres is PostgreSQL, served by the postgres driver.
+	flavorPostgres = "postgres"
+
+	// flavorMySQL is Oracle MySQL, served by the mysql driver.
+	flavorMySQL = "mysql"
+
+	// flavorMariaDB is MariaDB, which speaks the MySQL protocol and is served by the mysql
+	// driver, but lacks some MySQL syntax such as the ->> JSON operator and EXPLAIN ANALYZE.
+	flavorMariaDB = "mariadb"
+
+	// flavorSQLite is SQLite, served by the sqlite driver within the plugin's own process.
+	flavorSQLite = "sqlite"
+)
+
+// detectFlavor identifies the database behind db from SELECT VERSION(), returning its flavor
+// and version.
+func detectFlavor(db *sql.DB, driverName string) (string, string, error) {
+	versionSQL := "SELECT VERSION()"
+	if driverName == "sqlite" {
+		versionSQL = "SELECT sqlite_version()"
+	}
+
+	var version string
+	if err := db.QueryRow(versionSQL).Scan(&version); err != nil {
+		return "", "", fmt.Errorf("failed to query database version: %v", err)
+	}
+
+	flavor, version := parseFlavor(driverName, version)
+	return flavor, version, nil
+}
+
+// parseFlavor derives the flavor and the bare version number from the output of SELECT VERSION(),
+// such as "PostgreSQL 16.2 on x86_64-pc-linux-gnu", "8.0.36-0ubuntu0.22.04.1" or
+// "10.11.6-MariaDB-1:10.11.6+maria~ubu2204", or of SELECT sqlite_version() on SQLite.
+func parseFlavor(driverName, version string) (string, string) {
+	if driverName == "sqlite" {
+		return flavorSQLite, version
+	}
+	if driverName == "postgres" {
+		fields := strings.Fields(version)
+		if len(fields) > 1 && fields[0] == "PostgreSQL" {
+			return flavorPostgres, fields[1]
+		}
+		return flavorPostgres, version
+	}
+
+	flavor := flavorMySQL
+	if strings.Contains(version, "MariaDB") {
+		flavor = flavorMariaDB
+		// Replication-compatible builds prefix the real version with a fake MySQL one.
+		version = strings.TrimPrefix(version, "5.5.5-")
+	}
+	version, _, _ = strings.Cut(version, "-")
+
+	return flavor, version
+}
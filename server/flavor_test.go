@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFlavor(t *testing.T) {
+	for _, tc := range []struct {
+		driverName string
+		version    string
+		flavor     string
+		number     string
+	}{
+		{"postgres", "PostgreSQL 16.2 on x86_64-pc-linux-gnu, compiled by gcc", flavorPostgres, "16.2"},
+		{"mysql", "8.0.36-0ubuntu0.22.04.1", flavorMySQL, "8.0.36"},
+		{"mysql", "8.4.0", flavorMySQL, "8.4.0"},
+		{"mysql", "10.11.6-MariaDB-1:10.11.6+maria~ubu2204", flavorMariaDB, "10.11.6"},
+		{"mysql", "5.5.5-10.6.16-MariaDB", flavorMariaDB, "10.6.16"},
+		{"sqlite", "3.45.3", flavorSQLite, "3.45.3"},
+	} {
+		flavor, number := parseFlavor(tc.driverName, tc.version)
+		assert.Equal(t, tc.flavor, flavor, tc.version)
+		assert.Equal(t, tc.number, number, tc.version)
+	}
+}
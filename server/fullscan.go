@@ -0,0 +1,76 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// runFullScanTest seeds plugin_test_rpc as scan mode does, then reads the first opts.Records
+// rows with a single unbatched SELECT, exposing whether a connection type streams large result
+// sets or buffers them before the first row arrives.
+func (p *Plugin) runFullScanTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeFullScan,
+		Phase:    opts.Phase,
+		RowBytes: opts.RowBytes,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedTestTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+		result.setInsertMethod(opts)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryFullScan(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// fullScanSQL reads the first rows of plugin_test_rpc in one result set.
+const fullScanSQL = "SELECT id, data FROM plugin_test_rpc ORDER BY id LIMIT ?"
+
+// queryFullScan drains the single full scan result set, recording the time until the first row
+// was available, the total time and the rows and bytes read on result.
+func queryFullScan(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+
+	rows, err := db.Query(opts.tagSQL(rebind(driverName, fullScanSQL)), opts.Records)
+	if err != nil {
+		return fmt.Errorf("failed to run full scan: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if result.RecordsQueried == 0 {
+			result.TimeToFirstRowSeconds = time.Since(startTotalQuery).Seconds()
+		}
+
+		var id int
+		var data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return fmt.Errorf("failed to scan row: %v", err)
+		}
+		result.RecordsQueried++
+		result.BytesQueried += int64(len(data))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to drain full scan after %d rows: %v", result.RecordsQueried, err)
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// gormTestRow maps a row of plugin_test_rpc for GORM.
+type gormTestRow struct {
+	ID   int    `gorm:"column:id;primaryKey"`
+	Data string `gorm:"column:data"`
+}
+
+func (gormTestRow) TableName() string { return "plugin_test_rpc" }
+
+// runGormTest seeds plugin_test_rpc and pages through it as scan mode does, but issuing every
+// insert and query through GORM, so that the cost of the ORM on top of each connection type can
+// be read against scan mode's numbers.
+func (p *Plugin) runGormTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeGorm,
+		Phase:    opts.Phase,
+		RowBytes: opts.RowBytes,
+	}
+
+	if opts.Bulk != "" {
+		return result, fmt.Errorf("bulk loading is not supported in %s mode", modeGorm)
+	}
+
+	gdb, err := openGorm(db, driverName, opts)
+	if err != nil {
+		return result, err
+	}
+
+	if opts.Phase != phaseQuery {
+		p.trackTables(opts, "plugin_test_rpc")
+		if err := createTestTable(db, driverName, opts); err != nil {
+			return result, err
+		}
+
+		inserted, insertTime, err := seedGormRows(gdb, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+		result.setInsertMethod(opts)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryGormPages(gdb, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// openGorm opens GORM over db, with the dialect of driverName, tagging every statement it issues
+// as opts.tagSQL does.
+func openGorm(db *sql.DB, driverName string, opts testOptions) (*gorm.DB, error) {
+	pool := gormConnPool{ConnPool: db, opts: opts}
+
+	var dialector gorm.Dialector
+	switch driverName {
+	case "postgres":
+		dialector = postgres.New(postgres.Config{Conn: pool})
+	case "mysql":
+		// The flavor is already known, so GORM need not query the version itself.
+		dialector = mysql.New(mysql.Config{Conn: pool, SkipInitializeWithVersion: true})
+	default:
+		return nil, fmt.Errorf("%s mode is not supported on %s", modeGorm, driverName)
+	}
+
+	gdb, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard, SkipDefaultTransaction: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gorm: %v", err)
+	}
+	return gdb, nil
+}
+
+// seedGormRows inserts rows into plugin_test_rpc through GORM until it holds opts.Records, in a
+// single transaction, one row per statement or opts.InsertBatch rows per statement, returning the
+// number of rows inserted and the time spent inserting.
+func seedGormRows(gdb *gorm.DB, opts testOptions) (int, time.Duration, error) {
+	var count int64
+	if err := gdb.Model(&gormTestRow{}).Count(&count).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to check record count: %v", err)
+	}
+	if int(count) >= opts.Records {
+		return 0, 0, nil
+	}
+
+	batch := max(opts.InsertBatch, 1)
+	startInsert := time.Now()
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		for start := int(count); start < opts.Records; start += batch {
+			if err := opts.cancelled(); err != nil {
+				return err
+			}
+
+			rows := make([]gormTestRow, 0, min(batch, opts.Records-start))
+			for i := start; i < start+cap(rows); i++ {
+				rows = append(rows, gormTestRow{Data: testData(i, opts.RowBytes)})
+			}
+			if err := tx.Create(&rows).Error; err != nil {
+				return fmt.Errorf("failed to insert rows %d-%d: %v", start, start+len(rows)-1, err)
+			}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: opts.isolationLevel()})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return opts.Records - int(count), time.Since(startInsert), nil
+}
+
+// queryGormPages pages through the first opts.Records rows of plugin_test_rpc through GORM with
+// LIMIT and OFFSET, as scan mode does by default, recording the total query time, the rows and
+// bytes read and the latency of each page on result.
+func queryGormPages(gdb *gorm.DB, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+	result.PageSize = opts.PageSize
+
+	var durations []time.Duration
+	var offsets []int
+	for result.RecordsQueried < opts.Records {
+		if err := opts.cancelled(); err != nil {
+			return err
+		}
+
+		limit := min(opts.PageSize, opts.Records-result.RecordsQueried)
+
+		start := time.Now()
+		var rows []gormTestRow
+		err := gdb.Select("id", "data").Order("id").Limit(limit).Offset(result.RecordsQueried).Find(&rows).Error
+		if err != nil {
+			return fmt.Errorf("failed to query rows after %d: %v", result.RecordsQueried, err)
+		}
+		durations = append(durations, time.Since(start))
+		offsets = append(offsets, result.RecordsQueried)
+
+		for _, row := range rows {
+			result.BytesQueried += int64(len(row.Data))
+		}
+		result.RecordsQueried += len(rows)
+
+		// A short page means the table holds fewer rows than requested.
+		if len(rows) < limit {
+			break
+		}
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+	result.LatencyTrend = latencyTrend(durations, offsets)
+
+	return result.setLatencyHistogram(durations)
+}
+
+// gormConnPool issues GORM's statements on a *sql.DB or *sql.Tx, tagged as opts.tagSQL does.
+type gormConnPool struct {
+	gorm.ConnPool
+	opts testOptions
+}
+
+func (p gormConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.ConnPool.PrepareContext(ctx, p.opts.tagSQL(query))
+}
+
+func (p gormConnPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return p.ConnPool.ExecContext(ctx, p.opts.tagSQL(query), args...)
+}
+
+func (p gormConnPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return p.ConnPool.QueryContext(ctx, p.opts.tagSQL(query), args...)
+}
+
+func (p gormConnPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return p.ConnPool.QueryRowContext(ctx, p.opts.tagSQL(query), args...)
+}
+
+// BeginTx starts a transaction whose statements are tagged too.
+func (p gormConnPool) BeginTx(ctx context.Context, txOpts *sql.TxOptions) (gorm.ConnPool, error) {
+	beginner, ok := p.ConnPool.(gorm.TxBeginner)
+	if !ok {
+		return nil, gorm.ErrInvalidTransaction
+	}
+
+	tx, err := beginner.BeginTx(ctx, txOpts)
+	if err != nil {
+		return nil, err
+	}
+	// GORM requires transactions to be pointers.
+	return &gormTx{gormConnPool: gormConnPool{ConnPool: tx, opts: p.opts}, tx: tx}, nil
+}
+
+// gormTx is a transaction begun by gormConnPool.
+type gormTx struct {
+	gormConnPool
+	tx *sql.Tx
+}
+
+func (t gormTx) Commit() error   { return t.tx.Commit() }
+func (t gormTx) Rollback() error { return t.tx.Rollback() }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openGormOnSQLite opens GORM with the MySQL dialect over a new SQLite database holding an empty
+// plugin_test_rpc. SQLite understands the statements GORM generates for the workload in that
+// dialect, backquoted identifiers included.
+func openGormOnSQLite(t *testing.T, opts testOptions) *sql.DB {
+	db := sql.OpenDB(sqliteConnector{dataSource: filepath.Join(t.TempDir(), "bench.db")})
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, createTestTable(db, "sqlite", opts))
+	return db
+}
+
+func TestOpenGorm(t *testing.T) {
+	_, err := openGorm(&sql.DB{}, "sqlite", testOptions{})
+	assert.ErrorContains(t, err, "gorm mode is not supported on sqlite")
+}
+
+func TestGormWorkload(t *testing.T) {
+	for _, batch := range []int{0, 7} {
+		opts := testOptions{Records: 30, PageSize: 20, InsertBatch: batch, RowBytes: 16}
+		db := openGormOnSQLite(t, opts)
+		gdb, err := openGorm(db, "mysql", opts)
+		require.NoError(t, err)
+
+		inserted, _, err := seedGormRows(gdb, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 30, inserted)
+
+		// The table is only topped up.
+		opts.Records = 35
+		inserted, _, err = seedGormRows(gdb, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 5, inserted)
+
+		var data string
+		require.NoError(t, db.QueryRow("SELECT data FROM plugin_test_rpc WHERE id = 35").Scan(&data))
+		assert.Equal(t, testData(34, 16), data)
+
+		var result TestResult
+		opts.Records = 50
+		require.NoError(t, queryGormPages(gdb, opts, &result))
+		assert.Equal(t, 35, result.RecordsQueried)
+		assert.Equal(t, int64(35*16), result.BytesQueried)
+		assert.Equal(t, 20, result.PageSize)
+	}
+}
+
+func TestGormWorkloadCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := testOptions{Records: 30, PageSize: 20, ctx: ctx}
+
+	db := openGormOnSQLite(t, opts)
+	gdb, err := openGorm(db, "mysql", opts)
+	require.NoError(t, err)
+
+	_, _, err = seedGormRows(gdb, opts)
+	assert.ErrorIs(t, err, errRunCancelled)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM plugin_test_rpc").Scan(&count))
+	assert.Zero(t, count)
+}
+
+func TestGormStatementsAreTagged(t *testing.T) {
+	var statements []string
+	db := sql.OpenDB(timedConnector{
+		Connector: sqliteConnector{dataSource: filepath.Join(t.TempDir(), "bench.db")},
+		log:       func(query timedQuery) { statements = append(statements, query.Query) },
+	})
+	defer db.Close()
+
+	opts := testOptions{Records: 3, PageSize: 2, Label: "nightly"}
+	require.NoError(t, createTestTable(db, "sqlite", opts))
+	gdb, err := openGorm(db, "mysql", opts)
+	require.NoError(t, err)
+
+	_, _, err = seedGormRows(gdb, opts)
+	require.NoError(t, err)
+	var result TestResult
+	require.NoError(t, queryGormPages(gdb, opts, &result))
+
+	require.NotEmpty(t, statements)
+	for _, statement := range statements {
+		assert.True(t, strings.HasPrefix(statement, "/* nightly */ "), statement)
+	}
+}
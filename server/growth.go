@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxGrowthSteps bounds the number of dataset sizes a single growth run may visit.
+const maxGrowthSteps = 10
+
+// defaultGrowthSteps are the dataset sizes visited when steps is not given.
+var defaultGrowthSteps = []int{50000, 500000, 5000000}
+
+// GrowthStep reports the query workload on every connection type at one dataset size.
+type GrowthStep struct {
+	Records            int          `json:"records"`
+	InsertTimeSeconds  float64      `json:"insert_time_seconds"`
+	DatasetFingerprint string       `json:"dataset_fingerprint,omitempty"`
+	Results            []TestResult `json:"results"`
+}
+
+// GrowthResult collects the growth steps of a run, describing query latency as a function of
+// table size.
+type GrowthResult struct {
+	Label string       `json:"label,omitempty"`
+	Mode  string       `json:"mode"`
+	Steps []GrowthStep `json:"steps"`
+	Error string       `json:"error,omitempty"`
+}
+
+// parseGrowthSteps reads the comma-separated, strictly increasing dataset sizes from value.
+func parseGrowthSteps(value string) ([]int, error) {
+	if value == "" {
+		return defaultGrowthSteps, nil
+	}
+
+	fields := strings.Split(value, ",")
+	if len(fields) > maxGrowthSteps {
+		return nil, fmt.Errorf("at most %d steps are allowed", maxGrowthSteps)
+	}
+
+	steps := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid step %q", field)
+		}
+		steps = append(steps, n)
+	}
+
+	if !sort.IntsAreSorted(steps) {
+		return nil, fmt.Errorf("steps must be increasing")
+	}
+
+	return steps, nil
+}
+
+// TestDatabaseGrowth grows the dataset through each requested size, seeding over the raw
+// connection and then running the query workload over both connection types at every step.
+func (p *Plugin) TestDatabaseGrowth(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, GrowthResult{Error: err.Error()})
+		return
+	}
+	if opts.Dataset != "" || opts.Phase != phaseAll {
+		respondWithJSON(w, http.StatusBadRequest, GrowthResult{Error: "growth runs always seed and query; dataset and phase are not supported"})
+		return
+	}
+
+	if p.readOnly() {
+		respondWithJSON(w, http.StatusForbidden, GrowthResult{Error: errReadOnlyMode.Error()})
+		return
+	}
+
+	steps, err := parseGrowthSteps(r.URL.Query().Get("steps"))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, GrowthResult{Error: err.Error()})
+		return
+	}
+
+	result := GrowthResult{
+		Label: opts.Label,
+		Mode:  opts.Mode,
+		Steps: []GrowthStep{},
+	}
+
+	rpcDB, err := p.rpcDB()
+	if err != nil {
+		p.API.LogError("Failed to get database", "error", err)
+		result.Error = fmt.Sprintf("Failed to get database: %v", err)
+		respondWithJSON(w, http.StatusInternalServerError, result)
+		return
+	}
+	rpcDriverName := p.client.Store.DriverName()
+
+	rawDB, rawDriverName, err := p.openRawConnection(opts)
+	if err != nil {
+		p.API.LogError("Failed to connect to database directly", "error", err)
+		result.Error = fmt.Sprintf("Failed to connect to database: %v", err)
+		respondWithJSON(w, http.StatusInternalServerError, result)
+		return
+	}
+	defer rawDB.Close()
+
+	pool := parsePoolSettings(r)
+	pool.apply(rawDB)
+
+	for _, records := range steps {
+		stepOpts := opts
+		stepOpts.Records = records
+
+		// Seed over the raw connection, which is the fastest way to grow the table.
+		seedOpts := stepOpts
+		seedOpts.Phase = phaseSeed
+		seeded, err := p.runWorkload(rawDB, rawDriverName, seedOpts)
+		if err != nil {
+			p.API.LogError("Growth step failed", "records", records, "error", err)
+			result.Error = fmt.Sprintf("failed to seed %d records: %v", records, err)
+			respondWithJSON(w, http.StatusInternalServerError, result)
+			return
+		}
+
+		step := GrowthStep{
+			Records:            records,
+			InsertTimeSeconds:  seeded.InsertTimeSeconds,
+			DatasetFingerprint: seeded.DatasetFingerprint,
+		}
+
+		queryOpts := stepOpts
+		queryOpts.Phase = phaseQuery
+
+		rpcResult, err := p.runWorkload(rpcDB, rpcDriverName, queryOpts)
+		if err != nil {
+			rpcResult.Error = err.Error()
+		}
+		rpcResult.ConnType = "rpc"
+
+		rawResult, err := p.runWorkload(rawDB, rawDriverName, queryOpts)
+		if err != nil {
+			rawResult.Error = err.Error()
+		}
+		rawResult.ConnType = "raw"
+		pool.report(rawDB, &rawResult)
+
+		step.Results = []TestResult{rpcResult, rawResult}
+		result.Steps = append(result.Steps, step)
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
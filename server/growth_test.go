@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGrowthSteps(t *testing.T) {
+	steps, err := parseGrowthSteps("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultGrowthSteps, steps)
+
+	steps, err = parseGrowthSteps("1000, 10000,100000")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1000, 10000, 100000}, steps)
+
+	_, err = parseGrowthSteps("10000,1000")
+	assert.Error(t, err)
+
+	_, err = parseGrowthSteps("1000,abc")
+	assert.Error(t, err)
+
+	_, err = parseGrowthSteps("1,2,3,4,5,6,7,8,9,10,11")
+	assert.Error(t, err)
+}
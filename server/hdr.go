@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// The histogram records latencies in microseconds from 1µs to an hour at three significant
+// figures, the usual HdrHistogram configuration for request latencies.
+const (
+	hdrLowestTrackableValue  = 1
+	hdrHighestTrackableValue = int64(time.Hour / time.Microsecond)
+	hdrSignificantFigures    = 3
+)
+
+// The V2 encoding cookies, as defined by the HdrHistogram reference implementation.
+const (
+	hdrEncodingCookieV2           = 0x1c849303 | 0x10
+	hdrCompressedEncodingCookieV2 = 0x1c849304 | 0x10
+)
+
+// hdrHistogram is a High Dynamic Range histogram of latencies, recording each value with a
+// bounded relative error in a fixed number of buckets however many values are recorded. Its
+// layout follows the HdrHistogram reference implementation, so its encoding can be decoded and
+// merged by the standard HdrHistogram libraries and tools.
+type hdrHistogram struct {
+	subBucketHalfCountMagnitude int
+	subBucketHalfCount          int
+	subBucketMask               int64
+	subBucketCount              int
+
+	totalCount int64
+	maxValue   int64
+	counts     []int64
+}
+
+// newHDRHistogram returns an empty latency histogram.
+func newHDRHistogram() *hdrHistogram {
+	largestValueWithSingleUnitResolution := 2 * int64(math.Pow10(hdrSignificantFigures))
+	subBucketCountMagnitude := int(math.Ceil(math.Log2(float64(largestValueWithSingleUnitResolution))))
+
+	h := &hdrHistogram{
+		subBucketHalfCountMagnitude: subBucketCountMagnitude - 1,
+		subBucketCount:              1 << subBucketCountMagnitude,
+	}
+	h.subBucketHalfCount = h.subBucketCount / 2
+	h.subBucketMask = int64(h.subBucketCount - 1)
+
+	// Every bucket after the first doubles the range covered with the same number of sub-buckets.
+	bucketCount := 1
+	for smallestUntrackable := int64(h.subBucketCount); smallestUntrackable <= hdrHighestTrackableValue; smallestUntrackable <<= 1 {
+		bucketCount++
+	}
+	h.counts = make([]int64, (bucketCount+1)*h.subBucketHalfCount)
+
+	return h
+}
+
+// bucketIndex returns the power-of-two bucket holding value.
+func (h *hdrHistogram) bucketIndex(value int64) int {
+	return 64 - bits.LeadingZeros64(uint64(value|h.subBucketMask)) - (h.subBucketHalfCountMagnitude + 1)
+}
+
+// countsIndex returns the index of the counter for value.
+func (h *hdrHistogram) countsIndex(value int64) int {
+	bucket := h.bucketIndex(value)
+	subBucket := int(value >> bucket)
+
+	return (bucket+1)<<h.subBucketHalfCountMagnitude + subBucket - h.subBucketHalfCount
+}
+
+// valueFromIndex returns the lowest value counted by the counter at index.
+func (h *hdrHistogram) valueFromIndex(index int) int64 {
+	bucket := index>>h.subBucketHalfCountMagnitude - 1
+	subBucket := index&(h.subBucketHalfCount-1) + h.subBucketHalfCount
+	if bucket < 0 {
+		subBucket -= h.subBucketHalfCount
+		bucket = 0
+	}
+
+	return int64(subBucket) << bucket
+}
+
+// highestEquivalentValue returns the highest value counted by the same counter as value.
+func (h *hdrHistogram) highestEquivalentValue(value int64) int64 {
+	bucket := h.bucketIndex(value)
+	subBucket := value >> bucket
+	size := int64(1) << bucket
+	if subBucket >= int64(h.subBucketCount) {
+		size <<= 1
+	}
+
+	return subBucket<<bucket + size - 1
+}
+
+// record counts one latency, clamped to the trackable range.
+func (h *hdrHistogram) record(d time.Duration) {
+	value := min(max(int64(d/time.Microsecond), hdrLowestTrackableValue), hdrHighestTrackableValue)
+
+	h.counts[h.countsIndex(value)]++
+	h.totalCount++
+	h.maxValue = max(h.maxValue, value)
+}
+
+// valueAtPercentile returns the highest value within the counter holding percentile p (0-100]
+// of the recorded values, as the reference implementation does, or zero when empty.
+func (h *hdrHistogram) valueAtPercentile(p float64) int64 {
+	target := max(int64(p/100*float64(h.totalCount)+0.5), 1)
+
+	var seen int64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= target {
+			return h.highestEquivalentValue(h.valueFromIndex(i))
+		}
+	}
+
+	return 0
+}
+
+// encode returns the histogram in the compressed V2 encoding, base64 encoded as in HdrHistogram
+// logs, for decoding with any HdrHistogram implementation.
+func (h *hdrHistogram) encode() (string, error) {
+	// Counts are ZigZag LEB128 encoded up to the last non-zero counter, with each run of zero
+	// counters collapsed into its negated length.
+	var payload []byte
+	limit := 0
+	if h.totalCount > 0 {
+		limit = h.countsIndex(h.maxValue) + 1
+	}
+	for i := 0; i < limit; {
+		count := h.counts[i]
+		i++
+		if count != 0 {
+			payload = appendZigZag(payload, count)
+			continue
+		}
+
+		zeros := int64(1)
+		for i < limit && h.counts[i] == 0 {
+			zeros++
+			i++
+		}
+		if zeros > 1 {
+			payload = appendZigZag(payload, -zeros)
+		} else {
+			payload = appendZigZag(payload, 0)
+		}
+	}
+
+	var uncompressed bytes.Buffer
+	header := []any{
+		int32(hdrEncodingCookieV2),
+		int32(len(payload)),
+		int32(0), // normalizing index offset
+		int32(hdrSignificantFigures),
+		int64(hdrLowestTrackableValue),
+		hdrHighestTrackableValue,
+		float64(1), // integer to double value conversion ratio
+	}
+	for _, field := range header {
+		if err := binary.Write(&uncompressed, binary.BigEndian, field); err != nil {
+			return "", err
+		}
+	}
+	uncompressed.Write(payload)
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	if _, err := writer.Write(uncompressed.Bytes()); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	var encoded bytes.Buffer
+	if err := binary.Write(&encoded, binary.BigEndian, []int32{hdrCompressedEncodingCookieV2, int32(compressed.Len())}); err != nil {
+		return "", err
+	}
+	encoded.Write(compressed.Bytes())
+
+	return base64.StdEncoding.EncodeToString(encoded.Bytes()), nil
+}
+
+// appendZigZag appends value in the ZigZag LEB128 encoding of HdrHistogram, whose ninth byte,
+// if reached, carries a full eight bits.
+func appendZigZag(buf []byte, value int64) []byte {
+	v := uint64(value<<1) ^ uint64(value>>63)
+	for range 8 {
+		if v < 0x80 {
+			return append(buf, byte(v))
+		}
+		buf = append(buf, byte(v&0x7f|0x80))
+		v >>= 7
+	}
+
+	return append(buf, byte(v))
+}
+
+// LatencyHistogram reports an HDR histogram of operation latencies, with its key percentiles in
+// fractional milliseconds.
+type LatencyHistogram struct {
+	Count int64   `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	P999  float64 `json:"p99_9_ms"`
+	P9999 float64 `json:"p99_99_ms"`
+	Max   float64 `json:"max_ms"`
+
+	// Encoded is the histogram of latencies in microseconds in the compressed V2 encoding,
+	// base64 encoded, as found in HdrHistogram logs.
+	Encoded string `json:"encoded"`
+}
+
+// latencyHistogram records durations into an HDR histogram and reports it.
+func latencyHistogram(durations []time.Duration) (*LatencyHistogram, error) {
+	h := newHDRHistogram()
+	for _, d := range durations {
+		h.record(d)
+	}
+
+	encoded, err := h.encode()
+	if err != nil {
+		return nil, err
+	}
+
+	micros := func(value int64) float64 { return millis(time.Duration(value) * time.Microsecond) }
+	return &LatencyHistogram{
+		Count:   h.totalCount,
+		P50:     micros(h.valueAtPercentile(50)),
+		P90:     micros(h.valueAtPercentile(90)),
+		P99:     micros(h.valueAtPercentile(99)),
+		P999:    micros(h.valueAtPercentile(99.9)),
+		P9999:   micros(h.valueAtPercentile(99.99)),
+		Max:     micros(h.highestEquivalentValue(h.maxValue)),
+		Encoded: encoded,
+	}, nil
+}
+
+// setLatencyHistogram records the per-operation latencies of the run on the result.
+func (r *TestResult) setLatencyHistogram(durations []time.Duration) error {
+	histogram, err := latencyHistogram(durations)
+	if err != nil {
+		return fmt.Errorf("failed to encode latency histogram: %v", err)
+	}
+	r.LatencyHistogram = histogram
+
+	return nil
+}
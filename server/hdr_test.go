@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeHDRCounts decodes a compressed V2 histogram as the reference implementation would,
+// returning its counters.
+func decodeHDRCounts(t *testing.T, encoded string) []int64 {
+	t.Helper()
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+
+	var envelope struct{ Cookie, Length int32 }
+	require.NoError(t, binary.Read(bytes.NewReader(raw), binary.BigEndian, &envelope))
+	require.Equal(t, int32(hdrCompressedEncodingCookieV2), envelope.Cookie)
+	require.Len(t, raw[8:], int(envelope.Length))
+
+	reader, err := zlib.NewReader(bytes.NewReader(raw[8:]))
+	require.NoError(t, err)
+	uncompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+
+	var header struct {
+		Cookie, PayloadLength, NormalizingIndexOffset, SignificantFigures int32
+		Lowest, Highest                                                   int64
+		ConversionRatio                                                   float64
+	}
+	require.NoError(t, binary.Read(bytes.NewReader(uncompressed), binary.BigEndian, &header))
+	require.Equal(t, int32(hdrEncodingCookieV2), header.Cookie)
+	require.Equal(t, int32(hdrSignificantFigures), header.SignificantFigures)
+	require.Equal(t, hdrHighestTrackableValue, header.Highest)
+
+	payload := uncompressed[40:]
+	require.Len(t, payload, int(header.PayloadLength))
+
+	var counts []int64
+	for len(payload) > 0 {
+		var v uint64
+		n := 0
+		for shift := 0; ; shift += 7 {
+			b := payload[n]
+			n++
+			if n == 9 {
+				v |= uint64(b) << shift
+				break
+			}
+			v |= uint64(b&0x7f) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		payload = payload[n:]
+
+		value := int64(v>>1) ^ -int64(v&1)
+		if value < 0 {
+			counts = append(counts, make([]int64, -value)...)
+		} else {
+			counts = append(counts, value)
+		}
+	}
+
+	return counts
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	var durations []time.Duration
+	for us := 1; us <= 10000; us++ {
+		durations = append(durations, time.Duration(us)*time.Microsecond)
+	}
+
+	histogram, err := latencyHistogram(durations)
+	require.NoError(t, err)
+
+	// Values are reported at the top of their counter's range, as the reference implementation
+	// does, within three significant figures of the exact percentile.
+	assert.Equal(t, int64(10000), histogram.Count)
+	assert.Equal(t, 5.003, histogram.P50)
+	assert.Equal(t, 9.903, histogram.P99)
+	assert.Equal(t, 10.007, histogram.Max)
+
+	counts := decodeHDRCounts(t, histogram.Encoded)
+	var total int64
+	for _, count := range counts {
+		total += count
+	}
+	assert.Equal(t, int64(10000), total)
+
+	h := newHDRHistogram()
+	for _, d := range durations {
+		h.record(d)
+	}
+	assert.Equal(t, h.counts[:len(counts)], counts)
+}
+
+func TestLatencyHistogramEmpty(t *testing.T) {
+	histogram, err := latencyHistogram(nil)
+	require.NoError(t, err)
+
+	assert.Zero(t, histogram.Count)
+	assert.Zero(t, histogram.P99)
+	assert.Empty(t, decodeHDRCounts(t, histogram.Encoded))
+}
+
+func TestAppendZigZag(t *testing.T) {
+	assert.Equal(t, []byte{0x00}, appendZigZag(nil, 0))
+	assert.Equal(t, []byte{0x02}, appendZigZag(nil, 1))
+	assert.Equal(t, []byte{0x03}, appendZigZag(nil, -2))
+	assert.Equal(t, []byte{0x80, 0x01}, appendZigZag(nil, 64))
+	assert.Len(t, appendZigZag(nil, -1<<63), 9)
+}
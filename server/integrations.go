@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pluginRequest issues an inter-plugin request to another plugin's API as the plugin's bot,
+// encoding body as JSON when given and decoding the response into out when given.
+func (p *Plugin) pluginRequest(pluginID, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	request, err := http.NewRequest(method, "/"+pluginID+path, reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	request.Header.Set("Mattermost-User-Id", p.botUserID)
+	request.Header.Set("Content-Type", "application/json")
+	// Boards and Playbooks reject state-changing requests without this CSRF header.
+	request.Header.Set("X-Requested-With", "XMLHttpRequest")
+
+	response := p.API.PluginHTTP(request)
+	if response == nil {
+		return errors.Errorf("no response from the %s plugin; is it enabled?", pluginID)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return errors.Errorf("%s API returned %d: %s", pluginID, response.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(response.Body).Decode(out); err != nil {
+			return errors.Wrap(err, "failed to decode response")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	isolationReadCommitted  = "read_committed"
+	isolationRepeatableRead = "repeatable_read"
+	isolationSerializable   = "serializable"
+)
+
+const (
+	// maxTxRetries is the number of times a workload aborted by a serialization failure is
+	// retried before the run fails.
+	maxTxRetries = 3
+
+	// txRetryBackoff is the delay before the first retry, doubling with each further one.
+	txRetryBackoff = 50 * time.Millisecond
+)
+
+// serializationFailures are fragments of the messages with which Postgres and MySQL abort a
+// transaction that may succeed if retried. Errors are matched by message because the RPC driver
+// only carries the text of database errors across the plugin boundary.
+var serializationFailures = []string{
+	"could not serialize access", // Postgres 40001
+	"deadlock detected",          // Postgres 40P01
+	"Error 1213",                 // MySQL ER_LOCK_DEADLOCK
+	"Error 1205",                 // MySQL ER_LOCK_WAIT_TIMEOUT
+}
+
+// isSerializationFailure reports whether err aborted a transaction that may be retried.
+func isSerializationFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	message := err.Error()
+	for _, failure := range serializationFailures {
+		if strings.Contains(message, failure) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isolationLevel maps opts.Isolation onto database/sql, leaving the driver default when unset.
+func (o testOptions) isolationLevel() sql.IsolationLevel {
+	switch o.Isolation {
+	case isolationReadCommitted:
+		return sql.LevelReadCommitted
+	case isolationRepeatableRead:
+		return sql.LevelRepeatableRead
+	case isolationSerializable:
+		return sql.LevelSerializable
+	default:
+		return sql.LevelDefault
+	}
+}
+
+// beginTx starts a benchmark transaction at the isolation level selected by opts.
+func (o testOptions) beginTx(db *sql.DB) (*sql.Tx, error) {
+	return db.BeginTx(context.Background(), &sql.TxOptions{Isolation: o.isolationLevel()})
+}
+
+// runModeWithRetries runs the workload selected by opts.Mode, retrying it with backoff when a
+// transaction is aborted by a serialization failure, as applications must at stricter isolation
+// levels. Aborted transactions roll back, so a retry starts from the same state. The aborts and
+// retries are counted on the returned result.
+func (p *Plugin) runModeWithRetries(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	var aborts, retries int
+	for {
+		result, err := p.runMode(db, driverName, opts)
+		if isSerializationFailure(err) {
+			aborts++
+			if retries < maxTxRetries {
+				p.API.LogWarn("Retrying workload after serialization failure", "attempt", retries+1, "error", err)
+				time.Sleep(txRetryBackoff << retries)
+				retries++
+				continue
+			}
+			err = fmt.Errorf("gave up after %d retries: %v", retries, err)
+		}
+
+		result.Isolation = opts.Isolation
+		result.TxAborts = aborts
+		result.TxRetries = retries
+		return result, err
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSerializationFailure(t *testing.T) {
+	assert.False(t, isSerializationFailure(nil))
+	assert.False(t, isSerializationFailure(errors.New("pq: relation \"plugin_test_rpc\" does not exist")))
+
+	assert.True(t, isSerializationFailure(fmt.Errorf("failed to insert row 3: %v", errors.New("pq: could not serialize access due to concurrent update"))))
+	assert.True(t, isSerializationFailure(errors.New("pq: deadlock detected")))
+	assert.True(t, isSerializationFailure(errors.New("Error 1213 (40001): Deadlock found when trying to get lock; try restarting transaction")))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultIssueTemplate renders a GitHub-compatible issue payload.
+	defaultIssueTemplate = `{"title": {{json .Title}}, "body": {{json .Body}}}`
+
+	// defaultIssueConsecutiveRuns is used when IssueConsecutiveRuns is not positive.
+	defaultIssueConsecutiveRuns = 3
+
+	// issueRequestTimeout bounds how long the issue tracker may take to respond.
+	issueRequestTimeout = 30 * time.Second
+)
+
+// issueTemplateData is the data available to the issue template.
+type issueTemplateData struct {
+	Title  string
+	Body   string
+	Reason string
+	Streak int
+	Result TestResult
+}
+
+// issueTemplateFuncs are the functions available to the issue template.
+var issueTemplateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+// renderIssue renders the issue request body for data using text, or the default template when
+// text is blank. The result must be valid JSON.
+func renderIssue(text string, data issueTemplateData) ([]byte, error) {
+	if strings.TrimSpace(text) == "" {
+		text = defaultIssueTemplate
+	}
+
+	tmpl, err := template.New("issue").Funcs(issueTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse issue template")
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return nil, errors.Wrap(err, "failed to render issue template")
+	}
+	if !json.Valid(body.Bytes()) {
+		return nil, errors.New("issue template did not render valid JSON")
+	}
+
+	return body.Bytes(), nil
+}
+
+// openRegressionIssue files an issue in the configured tracker for a series that has regressed
+// for streak consecutive runs. It does nothing unless an endpoint is configured.
+func (p *Plugin) openRegressionIssue(alert regressionAlert, streak int) error {
+	config := p.getConfiguration()
+	endpoint := strings.TrimSpace(config.IssueEndpoint)
+	if endpoint == "" {
+		return nil
+	}
+
+	details, err := json.MarshalIndent(alert.Result, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode run details")
+	}
+
+	data := issueTemplateData{
+		Title:  fmt.Sprintf("Sustained database benchmark regression: %s", regressionSeries(alert.Result)),
+		Body:   fmt.Sprintf("The last %d runs breached their regression thresholds.\n\n%s\n\n```json\n%s\n```", streak, alert.Reason, details),
+		Reason: alert.Reason,
+		Streak: streak,
+		Result: alert.Result,
+	}
+	body, err := renderIssue(config.IssueTemplate, data)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create issue request")
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	if config.IssueAuthorization != "" {
+		request.Header.Set("Authorization", config.IssueAuthorization)
+	}
+
+	client := &http.Client{Timeout: issueRequestTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to post issue")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return errors.Errorf("issue tracker returned %d: %s", response.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
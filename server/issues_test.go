@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderIssue(t *testing.T) {
+	data := issueTemplateData{
+		Title:  `Regression "rpc"`,
+		Body:   "line one\nline two",
+		Streak: 3,
+		Result: TestResult{ConnType: "rpc"},
+	}
+
+	t.Run("default template", func(t *testing.T) {
+		body, err := renderIssue("", data)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"title": "Regression \"rpc\"", "body": "line one\nline two"}`, string(body))
+	})
+
+	t.Run("custom template", func(t *testing.T) {
+		body, err := renderIssue(`{"fields": {"summary": {{json .Title}}, "labels": [{{json .Result.ConnType}}], "streak": {{.Streak}}}}`, data)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"fields": {"summary": "Regression \"rpc\"", "labels": ["rpc"], "streak": 3}}`, string(body))
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := renderIssue(`{"title": {{.Title}}}`, data)
+		assert.Error(t, err)
+	})
+}
+
+func TestRegressionIssueLinksRun(t *testing.T) {
+	issues := make(chan string, 1)
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var issue struct {
+			Body string `json:"body"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&issue))
+		issues <- issue.Body
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer tracker.Close()
+
+	api := &plugintest.API{}
+	api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	p := Plugin{kvstore: &fakeAlertStore{streaks: map[string]int{}}}
+	p.SetAPI(api)
+	p.setConfiguration(&configuration{
+		AlertThresholds:      "total_query_time_seconds > 30",
+		IssueEndpoint:        tracker.URL,
+		IssueConsecutiveRuns: 1,
+	})
+
+	result := TestResult{ConnType: "rpc", Mode: modeScan, TotalQueryTimeSeconds: 60}
+	p.finishRun("slow-run", "rpc", url.Values{}, "", true, &result)
+
+	select {
+	case body := <-issues:
+		assert.Contains(t, body, `"run_id": "slow-run"`)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no issue was opened")
+	}
+}
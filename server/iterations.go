@@ -0,0 +1,90 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// maxIterations caps iterations.
+	maxIterations = 100
+
+	// maxDuration caps duration.
+	maxDuration = time.Hour
+)
+
+// repeatQueries reruns the timed queries of the workload selected by opts until opts.Iterations
+// runs have completed, counting the run that produced result as the first, and records the
+// variability of their query time and throughput on result. Seeding, index rebuilds and warm-up
+// happen only in the first run, whose figures result otherwise keeps.
+func (p *Plugin) repeatQueries(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	repeat := opts.repeated()
+
+	times := []float64{result.TotalQueryTimeSeconds}
+	rates := []float64{result.QueryRowsPerSecond}
+	for i := 1; i < opts.Iterations; i++ {
+		if err := opts.cancelled(); err != nil {
+			return err
+		}
+		iteration, err := p.runMode(db, driverName, repeat)
+		if err != nil {
+			return fmt.Errorf("failed to run iteration %d: %v", i+1, err)
+		}
+		times = append(times, iteration.TotalQueryTimeSeconds)
+		rates = append(rates, iteration.QueryRowsPerSecond)
+	}
+
+	queryTime := summarizeVariability(times)
+	queryRate := summarizeVariability(rates)
+	result.Iterations = opts.Iterations
+	result.QueryTime = &queryTime
+	result.QueryRate = &queryRate
+
+	return nil
+}
+
+// repeatQueriesFor reruns the timed queries of the workload selected by opts, counting the run
+// that produced result as the first, until their combined query time reaches opts.Duration.
+// The rows, bytes and lookups read and the time taken are totalled on result, so its throughput
+// is the one achieved over the whole duration.
+func (p *Plugin) repeatQueriesFor(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	repeat := opts.repeated()
+
+	// The wall clock bounds the run too, in case iterations report no query time at all.
+	deadline := time.Now().Add(opts.Duration)
+	iterations := 1
+	lookups := result.Lookups
+	for result.TotalQueryTimeSeconds < opts.Duration.Seconds() && time.Now().Before(deadline) {
+		if err := opts.cancelled(); err != nil {
+			return err
+		}
+		iteration, err := p.runMode(db, driverName, repeat)
+		if err != nil {
+			return fmt.Errorf("failed to run iteration %d: %v", iterations+1, err)
+		}
+		iterations++
+		result.RecordsQueried += iteration.RecordsQueried
+		result.BytesQueried += iteration.BytesQueried
+		lookups += iteration.Lookups
+		result.TotalQueryTimeSeconds += iteration.TotalQueryTimeSeconds
+	}
+
+	result.Iterations = iterations
+	result.DurationSeconds = opts.Duration.Seconds()
+	result.setQueryThroughput()
+	if lookups > 0 && result.TotalQueryTimeSeconds > 0 {
+		result.Lookups = lookups
+		result.LookupsPerSecond = float64(lookups) / result.TotalQueryTimeSeconds
+	}
+
+	return nil
+}
+
+// repeated returns the options for rerunning only the timed queries of a run with opts.
+func (o testOptions) repeated() testOptions {
+	o.Phase = phaseQuery
+	o.RebuildIndex = false
+	o.WarmupBatches = 0
+	return o
+}
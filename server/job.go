@@ -1,6 +1,113 @@
 package main
 
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// defaultSchedulePreset is the autorun preset scheduled benchmarks run when none is set.
+	defaultSchedulePreset = "default"
+
+	// scheduleSlack lets a scheduled benchmark start slightly early, since the hourly job that
+	// checks the schedule wakes on the rounded hour rather than exactly an interval after the
+	// previous benchmark started.
+	scheduleSlack = 5 * time.Minute
+)
+
+// runJob runs hourly on a single node of the cluster, recovering interrupted jobs, pruning stored
+// runs and starting a scheduled benchmark when one is due.
 func (p *Plugin) runJob() {
-	// Include job logic here
-	p.API.LogInfo("Job is currently running")
+	p.recoverJobs()
+	p.pruneRuns()
+
+	config := p.getConfiguration()
+	if config.ScheduleIntervalHours <= 0 {
+		return
+	}
+
+	last, err := p.kvstore.GetLastScheduledRun()
+	if err != nil {
+		p.API.LogError("Failed to get last scheduled run", "error", err)
+		return
+	}
+
+	now := time.Now()
+	if !scheduledRunDue(now, last, time.Duration(config.ScheduleIntervalHours)*time.Hour) {
+		return
+	}
+
+	preset := strings.TrimSpace(config.SchedulePreset)
+	if preset == "" {
+		preset = defaultSchedulePreset
+	}
+	legs, ok := autorunPresets[preset]
+	if !ok {
+		p.API.LogError("Unknown scheduled benchmark preset", "preset", preset)
+		return
+	}
+
+	report := p.runScheduledBenchmark(preset, legs, config.ScheduleParams)
+
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		p.API.LogError("Failed to encode scheduled benchmark report", "error", err)
+		return
+	}
+	if err := p.kvstore.SaveLastScheduledRun(kvstore.ScheduledRun{StartedAt: now.UnixMilli(), Report: encoded}); err != nil {
+		p.API.LogError("Failed to save last scheduled run", "error", err)
+	}
+}
+
+// scheduledRunDue reports whether a scheduled benchmark should start at now, given the last one.
+func scheduledRunDue(now time.Time, last *kvstore.ScheduledRun, interval time.Duration) bool {
+	if last == nil {
+		return true
+	}
+
+	return now.Sub(time.UnixMilli(last.StartedAt)) >= interval-scheduleSlack
+}
+
+// runScheduledBenchmark runs every leg of an autorun preset, applying extraParams on top of each.
+// Each completed run is stored, checked for alerts and published like a run requested over HTTP,
+// so scheduled runs build up a long-term trend.
+func (p *Plugin) runScheduledBenchmark(preset string, legs []autorunLeg, extraParams string) AutorunReport {
+	report := AutorunReport{
+		Preset:    preset,
+		StartedAt: time.Now(),
+	}
+
+	p.API.LogInfo("Starting scheduled benchmark", "preset", preset)
+	report.Steps = runAutorunLegs(legs, p.rawModeEnabled(), func(leg autorunLeg) (TestResult, error) {
+		r, err := autorunRequest(leg.Params, extraParams)
+		if err != nil {
+			return TestResult{}, err
+		}
+
+		runID := model.NewId()
+		ctx, end, err := p.beginRun(context.Background(), kvstore.Job{ID: runID, Endpoint: "schedule:" + leg.Name, Params: r.URL.RawQuery})
+		if err != nil {
+			return TestResult{}, err
+		}
+
+		result, err := p.runTest(leg.ConnType, r.WithContext(ctx))
+		if err != nil {
+			end(err)
+			return result, err
+		}
+		p.finishRun(runID, leg.ConnType, r.URL.Query(), "", true, &result)
+		end(nil)
+		p.publishResult(result)
+
+		return result, nil
+	})
+	report.FinishedAt = time.Now()
+	p.API.LogInfo("Finished scheduled benchmark", "preset", preset, "duration", report.FinishedAt.Sub(report.StartedAt).String())
+
+	return report
 }
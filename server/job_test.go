@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduledRunDue(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	startedAgo := func(d time.Duration) *kvstore.ScheduledRun {
+		return &kvstore.ScheduledRun{StartedAt: now.Add(-d).UnixMilli()}
+	}
+
+	assert.True(t, scheduledRunDue(now, nil, 6*time.Hour), "the first run is due immediately")
+	assert.True(t, scheduledRunDue(now, startedAgo(6*time.Hour), 6*time.Hour))
+	assert.True(t, scheduledRunDue(now, startedAgo(6*time.Hour-time.Minute), 6*time.Hour), "runs that started just off the hour are tolerated")
+	assert.False(t, scheduledRunDue(now, startedAgo(5*time.Hour), 6*time.Hour))
+}
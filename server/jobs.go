@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+)
+
+// errRunCancelled is returned by a run cancelled with DELETE /api/v1/jobs/{id}.
+var errRunCancelled = errors.New("the run was cancelled")
+
+// Job statuses, as recorded in the KV store and filtered on by GET /api/v1/jobs.
+const (
+	jobQueued      = "queued"
+	jobRunning     = "running"
+	jobCompleted   = "completed"
+	jobFailed      = "failed"
+	jobInterrupted = "interrupted"
+)
+
+// jobFinished reports whether a job with status has stopped for good.
+func jobFinished(status string) bool {
+	return status == jobCompleted || status == jobFailed || status == jobInterrupted
+}
+
+// jobRetention is how long the records of finished jobs are kept.
+const jobRetention = 7 * 24 * time.Hour
+
+// runningJob is a benchmark run in progress on this node.
+type runningJob struct {
+	RunID     string
+	Endpoint  string
+	StartedAt time.Time
+
+	// record is the job as recorded when it started running. It is empty for a leg of a run
+	// this node runs on behalf of holderNodeID, which records the job instead.
+	record       kvstore.Job
+	holderNodeID string
+	cancel       context.CancelFunc
+}
+
+// jobTTL returns how long the record of a job with status is kept.
+func jobTTL(status string) time.Duration {
+	if jobFinished(status) {
+		return jobRetention
+	}
+	return 0
+}
+
+// saveJob records job in the KV store, keeping the records of finished jobs for jobRetention.
+// Failures are logged but never fail the run itself.
+func (p *Plugin) saveJob(job kvstore.Job) {
+	if err := p.kvstore.SaveJob(job, jobTTL(job.Status)); err != nil {
+		p.API.LogError("Failed to save job", "run_id", job.ID, "status", job.Status, "error", err)
+	}
+}
+
+// updateJob replaces the record old of a job with job, reporting false if another node or the
+// recovery sweep changed the record first, in which case job is not recorded.
+func (p *Plugin) updateJob(old, job kvstore.Job) bool {
+	updated, err := p.kvstore.UpdateJob(old, job, jobTTL(job.Status))
+	if err != nil {
+		p.API.LogError("Failed to update job", "run_id", job.ID, "status", job.Status, "error", err)
+		return false
+	}
+	if !updated {
+		p.API.LogDebug("Job changed before it could be updated", "run_id", job.ID, "status", job.Status)
+	}
+	return updated
+}
+
+// beginRun takes the run lock as job.ID for a run of job.Endpoint, registers it as a job of this
+// node and records it as running, with its params redacted of any database password. It returns
+// a context keeping the values of parent, which is cancelled only when the job is, and a
+// function ending the run with its outcome. The context carries the run ID, so options parsed
+// from a request bearing it stop at the workload's next checkpoint once the job is cancelled.
+func (p *Plugin) beginRun(parent context.Context, job kvstore.Job) (context.Context, func(error), error) {
+	release, err := p.acquireRunLock(job.ID, job.Endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	ctx = context.WithValue(ctx, runIDContextKey{}, job.ID)
+
+	job.NodeID = p.nodeInfo.ID
+	job.Params = redactQuery(job.Params)
+	job.Status = jobRunning
+	job.StartedAt = time.Now().UnixMilli()
+	p.saveJob(job)
+
+	p.jobsLock.Lock()
+	if p.jobs == nil {
+		p.jobs = make(map[string]*runningJob)
+	}
+	p.jobs[job.ID] = &runningJob{RunID: job.ID, Endpoint: job.Endpoint, StartedAt: time.Now(), record: job, cancel: cancel}
+	p.jobsLock.Unlock()
+
+	end := func(runErr error) {
+		p.jobsLock.Lock()
+		delete(p.jobs, job.ID)
+		p.jobsLock.Unlock()
+
+		cancel()
+
+		// The outcome is recorded before the lock is released, so a running job whose run no
+		// longer holds the lock is known to have been interrupted. A job interrupted while
+		// the plugin was deactivating keeps that status.
+		finished := job
+		finished.Status = jobCompleted
+		if runErr != nil {
+			finished.Status = jobFailed
+			finished.Error = runErr.Error()
+		}
+		finished.FinishedAt = time.Now().UnixMilli()
+		p.updateJob(job, finished)
+
+		release()
+	}
+
+	return ctx, end, nil
+}
+
+// responseError returns the error reported by a benchmark response with status and body, or nil
+// if it succeeded. Every benchmark response carries its error at the top level, if any.
+func responseError(status int, body []byte) error {
+	if status < http.StatusBadRequest {
+		return nil
+	}
+
+	var outcome struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &outcome); err != nil || outcome.Error == "" {
+		return fmt.Errorf("status %d", status)
+	}
+	return errors.New(outcome.Error)
+}
+
+// cancelLocalJob cancels the job runID if it runs on this node, reporting whether it did.
+func (p *Plugin) cancelLocalJob(runID string) bool {
+	p.jobsLock.Lock()
+	defer p.jobsLock.Unlock()
+
+	job, ok := p.jobs[runID]
+	if ok {
+		job.cancel()
+	}
+	return ok
+}
+
+// localJobHolder returns the node holding the run lock for the job runID, if this node runs a leg
+// of it on that node's behalf.
+func (p *Plugin) localJobHolder(runID string) string {
+	p.jobsLock.Lock()
+	defer p.jobsLock.Unlock()
+
+	if job, ok := p.jobs[runID]; ok {
+		return job.holderNodeID
+	}
+	return ""
+}
+
+// localJobStartedAt returns when the job runID running on this node started, reporting false if
+// it is not running here.
+func (p *Plugin) localJobStartedAt(runID string) (time.Time, bool) {
+	p.jobsLock.Lock()
+	defer p.jobsLock.Unlock()
+
+	job, ok := p.jobs[runID]
+	if !ok {
+		return time.Time{}, false
+	}
+	return job.StartedAt, true
+}
+
+// cancelled returns errRunCancelled once the run with opts has been cancelled. Workloads check
+// it between statements, so a cancelled run stops after the statement in flight.
+func (o testOptions) cancelled() error {
+	if o.ctx != nil && o.ctx.Err() != nil {
+		return errRunCancelled
+	}
+	return nil
+}
+
+// JobCancellation reports the outcome of cancelling a job.
+type JobCancellation struct {
+	RunID  string `json:"run_id,omitempty"`
+	NodeID string `json:"node_id,omitempty"`
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Job cancellation statuses.
+const (
+	// cancellationRequested means a running job was asked to stop at its next checkpoint.
+	cancellationRequested = "cancelling"
+
+	// cancellationDequeued means a queued job was removed before it started.
+	cancellationDequeued = "dequeued"
+)
+
+// CancelJob cancels the benchmark run with the ID given in the path, wherever it runs in the
+// cluster, or removes it from this node's queue if it has not started yet. A node running a leg
+// of the job on another's behalf stops the leg and the other node stops the rest. A running job
+// stops at its next checkpoint, rolling back any open transaction, and tables a cancelled
+// seeding run was filling are dropped, so no partial dataset is left behind.
+func (p *Plugin) CancelJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	holderNodeID := p.localJobHolder(id)
+	if p.cancelLocalJob(id) {
+		if holderNodeID != "" {
+			p.publishClusterMessage(clusterEventCancel, clusterMessage{Node: p.nodeInfo, TargetNodeID: holderNodeID, RunID: id})
+		}
+		respondWithJSON(w, http.StatusAccepted, JobCancellation{RunID: id, NodeID: p.nodeInfo.ID, Status: cancellationRequested})
+		return
+	}
+	if run, ok := p.dequeueRun(id); ok {
+		p.saveJob(run.job(jobFailed, errDequeued.Error()))
+		respondWithJSON(w, http.StatusOK, JobCancellation{RunID: id, NodeID: p.nodeInfo.ID, Status: cancellationDequeued})
+		return
+	}
+
+	holder, err := p.kvstore.GetRunLock()
+	if err != nil {
+		p.API.LogError("Failed to get run lock", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, JobCancellation{Error: err.Error()})
+		return
+	}
+	if holder == nil || holder.RunID != id || holder.NodeID == p.nodeInfo.ID {
+		respondWithJSON(w, http.StatusNotFound, JobCancellation{Error: fmt.Sprintf("no job %s is running or queued on this node", id)})
+		return
+	}
+
+	p.publishClusterMessage(clusterEventCancel, clusterMessage{Node: p.nodeInfo, TargetNodeID: holder.NodeID, RunID: id})
+	respondWithJSON(w, http.StatusAccepted, JobCancellation{RunID: id, NodeID: holder.NodeID, Status: cancellationRequested})
+}
+
+// JobList reports benchmark jobs, most recently queued or started first.
+type JobList struct {
+	Jobs  []kvstore.Job `json:"jobs"`
+	Error string        `json:"error,omitempty"`
+}
+
+// ListJobs returns the benchmark jobs recorded by every node of the cluster, with the params each
+// was requested with, so operators can see what the plugin is doing to their database. The
+// status query param restricts them to a comma-separated list of statuses among queued,
+// running, completed, failed and interrupted. Finished jobs are kept for a week.
+func (p *Plugin) ListJobs(w http.ResponseWriter, r *http.Request) {
+	statuses := map[string]bool{}
+	if value := r.URL.Query().Get("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			switch status {
+			case jobQueued, jobRunning, jobCompleted, jobFailed, jobInterrupted:
+				statuses[status] = true
+			default:
+				respondWithJSON(w, http.StatusBadRequest, JobList{Error: fmt.Sprintf("unknown status %q", status)})
+				return
+			}
+		}
+	}
+
+	jobs, err := p.kvstore.ListJobs()
+	if err != nil {
+		p.API.LogError("Failed to list jobs", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, JobList{Error: err.Error()})
+		return
+	}
+
+	if len(statuses) > 0 {
+		jobs = slices.DeleteFunc(jobs, func(job kvstore.Job) bool { return !statuses[job.Status] })
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return max(jobs[i].QueuedAt, jobs[i].StartedAt) > max(jobs[j].QueuedAt, jobs[j].StartedAt)
+	})
+
+	respondWithJSON(w, http.StatusOK, JobList{Jobs: jobs})
+}
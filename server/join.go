@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// runJoinTest seeds plugin_test_rpc along with one related plugin_test_rpc_detail row per record,
+// then pages through the two-table join, since real plugin queries are rarely single-table scans.
+func (p *Plugin) runJoinTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeJoin,
+		Phase:    opts.Phase,
+		PageSize: opts.PageSize,
+		RowBytes: opts.RowBytes,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedTestTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+
+		detailInserted, detailInsertTime, err := seedDetailTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+
+		result.setInsertThroughput(inserted+detailInserted, insertTime+detailInsertTime)
+		result.setInsertMethod(opts)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryJoin(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// seedDetailTable creates plugin_test_rpc_detail if needed and adds a detail row for each of the
+// first opts.Records rows of plugin_test_rpc that lacks one, returning the number of rows inserted
+// and the time spent inserting. The rows are derived server-side with INSERT ... SELECT.
+func seedDetailTable(db *sql.DB, driverName string, opts testOptions) (int, time.Duration, error) {
+	var createTableSQL []string
+	if driverName == "postgres" {
+		createTableSQL = []string{`
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_detail (
+				id SERIAL PRIMARY KEY,
+				test_id INTEGER NOT NULL,
+				note VARCHAR(255) NOT NULL
+			)
+		`,
+			"CREATE INDEX IF NOT EXISTS idx_plugin_test_rpc_detail_test_id ON plugin_test_rpc_detail (test_id)",
+		}
+	} else {
+		createTableSQL = []string{`
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_detail (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				test_id INT NOT NULL,
+				note VARCHAR(255) NOT NULL,
+				INDEX idx_plugin_test_rpc_detail_test_id (test_id)
+			)
+		`}
+	}
+
+	for _, statement := range createTableSQL {
+		if _, err := db.Exec(opts.tagSQL(statement)); err != nil {
+			return 0, 0, fmt.Errorf("failed to create detail table: %v", err)
+		}
+	}
+
+	startInsert := time.Now()
+
+	insertSQL := rebind(driverName, `
+		INSERT INTO plugin_test_rpc_detail (test_id, note)
+		SELECT t.id, CONCAT('Detail for ', t.id)
+		FROM (SELECT id FROM plugin_test_rpc ORDER BY id LIMIT ?) t
+		LEFT JOIN plugin_test_rpc_detail d ON d.test_id = t.id
+		WHERE d.id IS NULL
+	`)
+	res, err := db.Exec(opts.tagSQL(insertSQL), opts.Records)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert detail rows: %v", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count detail rows: %v", err)
+	}
+
+	return int(inserted), time.Since(startInsert), nil
+}
+
+// joinPageSQL reads one page of the join of plugin_test_rpc and plugin_test_rpc_detail.
+const joinPageSQL = `
+	SELECT t.id, t.data, d.note
+	FROM plugin_test_rpc t
+	JOIN plugin_test_rpc_detail d ON d.test_id = t.id
+	ORDER BY t.id
+	LIMIT ? OFFSET ?
+`
+
+// queryJoin pages through the join of plugin_test_rpc and plugin_test_rpc_detail, recording the
+// total query time and the rows and bytes read on result.
+func queryJoin(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+
+	querySQL := opts.tagSQL(rebind(driverName, joinPageSQL))
+	for offset := 0; offset < opts.Records; offset += opts.PageSize {
+		limit := min(opts.PageSize, opts.Records-offset)
+
+		rows, err := db.Query(querySQL, limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to query join at offset %d: %v", offset, err)
+		}
+
+		for rows.Next() {
+			var id int
+			var data, note string
+			if err := rows.Scan(&id, &data, &note); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan joined row: %v", err)
+			}
+			result.RecordsQueried++
+			result.BytesQueried += int64(len(data) + len(note))
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read join at offset %d: %v", offset, err)
+		}
+		rows.Close()
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	// defaultLookups is the number of point lookups issued when lookups is not given.
+	defaultLookups = 10000
+
+	// maxLookups bounds the lookups query param.
+	maxLookups = 1000000
+)
+
+// runPointLookupTest seeds plugin_test_rpc and then fetches opts.Lookups single rows by random
+// id, the access pattern most plugins rely on, reporting the lookup rate and latency distribution.
+func (p *Plugin) runPointLookupTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modePointLookup,
+		Phase:    opts.Phase,
+		RowBytes: opts.RowBytes,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedTestTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+		result.setInsertMethod(opts)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryPointLookups(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// pointLookupSQL fetches a single row of plugin_test_rpc by id.
+const pointLookupSQL = "SELECT id, data FROM plugin_test_rpc WHERE id = ?"
+
+// queryPointLookups looks up opts.Lookups random ids among the first opts.Records rows of
+// plugin_test_rpc, recording the rate, the latency distribution and the rows and bytes read on
+// result. The ids are drawn from a fixed seed so every run issues the same sequence. With
+// opts.TargetQPS set, lookups are paced to that rate rather than issued back to back. With
+// opts.ThinkTime or opts.Jitter, it pauses between lookups, excluding the pauses from the query
+// time.
+func queryPointLookups(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	var firstID int
+	if err := db.QueryRow(opts.tagSQL("SELECT COALESCE(MIN(id), 0) FROM plugin_test_rpc")).Scan(&firstID); err != nil {
+		return fmt.Errorf("failed to find first id: %v", err)
+	}
+
+	querySQL := opts.tagSQL(rebind(driverName, pointLookupSQL))
+	random := rand.New(rand.NewSource(int64(opts.Records)))
+	durations := make([]time.Duration, 0, opts.Lookups)
+
+	var bucket *tokenBucket
+	if opts.TargetQPS > 0 {
+		bucket = newTokenBucket(opts.TargetQPS)
+		result.TargetQPS = opts.TargetQPS
+	}
+
+	thinker := newThinker(opts)
+	startTotalQuery := time.Now()
+
+	for i := 0; i < opts.Lookups; i++ {
+		if err := opts.cancelled(); err != nil {
+			return err
+		}
+		id := firstID + random.Intn(opts.Records)
+		if i > 0 {
+			thinker.think()
+		}
+		if bucket != nil {
+			bucket.wait()
+		}
+
+		var data string
+		start := time.Now()
+		err := db.QueryRow(querySQL, id).Scan(&id, &data)
+		durations = append(durations, time.Since(start))
+
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to look up id %d: %v", id, err)
+		}
+		result.RecordsQueried++
+		result.BytesQueried += int64(len(data))
+	}
+
+	result.TotalQueryTimeSeconds = (time.Since(startTotalQuery) - thinker.total).Seconds()
+	result.setQueryThroughput()
+	thinker.report(result)
+
+	result.Lookups = opts.Lookups
+	if result.TotalQueryTimeSeconds > 0 {
+		result.LookupsPerSecond = float64(opts.Lookups) / result.TotalQueryTimeSeconds
+	}
+	latency := summarizeLatencies(durations).millis()
+	result.LookupLatency = &latency
+
+	return result.setLatencyHistogram(durations)
+}
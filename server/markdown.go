@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resultMarkdown renders result as Markdown tables of its parameters, timings, latency
+// percentiles and any regression against its baseline, for posting to chat in place of raw JSON.
+// Values the run did not report are left out.
+func resultMarkdown(result TestResult) string {
+	var sections []string
+	if result.Error != "" {
+		sections = append(sections, "**Error:** "+result.Error)
+	}
+
+	if params := resultParameters(result); len(params) > 0 {
+		sections = append(sections, markdownTable([]string{"Parameter", "Value"}, params))
+	}
+	if timings := resultTimings(result); len(timings) > 0 {
+		sections = append(sections, markdownTable([]string{"Timing", "Value"}, timings))
+	}
+
+	var latencies [][]string
+	for _, latency := range resultLatencies(result) {
+		m := latency.Millis
+		latencies = append(latencies, []string{latency.Name,
+			fmt.Sprintf("%.2f", m.Min), fmt.Sprintf("%.2f", m.Avg), fmt.Sprintf("%.2f", m.P50),
+			fmt.Sprintf("%.2f", m.P95), fmt.Sprintf("%.2f", m.P99), fmt.Sprintf("%.2f", m.Max)})
+	}
+	if len(latencies) > 0 {
+		sections = append(sections, markdownTable([]string{"Latency (ms)", "Min", "Avg", "P50", "P95", "P99", "Max"}, latencies))
+	}
+
+	if result.Regression != nil && len(result.Regression.Metrics) > 0 {
+		rows := make([][]string, 0, len(result.Regression.Metrics))
+		for _, metric := range result.Regression.Metrics {
+			status := ""
+			if metric.Regressed {
+				status = "regressed"
+			}
+			rows = append(rows, []string{"`" + metric.Name + "`", fmt.Sprintf("%g", metric.Baseline),
+				fmt.Sprintf("%g", metric.Current), fmt.Sprintf("%+.1f%%", metric.PercentSlower), status})
+		}
+		sections = append(sections, fmt.Sprintf("Compared to baseline run `%s` (threshold %g%%):\n\n%s",
+			result.Regression.BaselineRunID, result.Regression.ThresholdPercent,
+			markdownTable([]string{"Metric", "Baseline", "Current", "Slower by", ""}, rows)))
+	}
+
+	return strings.Join(sections, "\n\n")
+}
+
+// resultParameters returns the name and value of each parameter result reports.
+func resultParameters(result TestResult) [][]string {
+	var params [][]string
+	add := func(name string, value any, set bool) {
+		if set {
+			params = append(params, []string{name, fmt.Sprint(value)})
+		}
+	}
+	add("Connection", result.ConnType, result.ConnType != "")
+	add("Mode", result.Mode, result.Mode != "")
+	add("Label", result.Label, result.Label != "")
+	add("Tags", strings.Join(result.Tags, ", "), len(result.Tags) > 0)
+	add("Run ID", result.RunID, result.RunID != "")
+	add("Replay of", result.ReplayOf, result.ReplayOf != "")
+	add("Phase", result.Phase, result.Phase != "")
+	add("Records queried", result.RecordsQueried, result.RecordsQueried > 0)
+	add("Records inserted", result.RecordsInserted, result.RecordsInserted > 0)
+	add("Page size", result.PageSize, result.PageSize > 0)
+	add("Pagination", result.Pagination, result.Pagination != "")
+	add("Row bytes", result.RowBytes, result.RowBytes > 0)
+	add("Payload bytes", result.PayloadBytes, result.PayloadBytes > 0)
+	add("Iterations", result.Iterations, result.Iterations > 0)
+	add("Max open connections", result.MaxOpenConns, result.MaxOpenConns > 0)
+	add("Database", strings.TrimSpace(result.DatabaseFlavor+" "+result.DatabaseVersion), result.DatabaseFlavor != "")
+	add("Dataset", result.DatasetFingerprint, result.DatasetFingerprint != "")
+	return params
+}
+
+// resultTimings returns the name and formatted value of each timing result reports.
+func resultTimings(result TestResult) [][]string {
+	var timings [][]string
+	add := func(name, format string, value float64) {
+		if value > 0 {
+			timings = append(timings, []string{name, fmt.Sprintf(format, value)})
+		}
+	}
+	add("Insert time", "%.3f s", result.InsertTimeSeconds)
+	add("Insert rate", "%.0f rows/s", result.InsertRowsPerSecond)
+	add("Index build time", "%.3f s", result.IndexBuildTimeSeconds)
+	add("Total query time", "%.3f s", result.TotalQueryTimeSeconds)
+	add("Query rate", "%.0f rows/s", result.QueryRowsPerSecond)
+	add("Query throughput", "%.0f bytes/s", result.QueryBytesPerSecond)
+	add("Time to first row", "%.3f s", result.TimeToFirstRowSeconds)
+	add("Lookup rate", "%.0f lookups/s", result.LookupsPerSecond)
+	add("Duration", "%.3f s", result.DurationSeconds)
+	return timings
+}
+
+// namedLatency is a latency summary reported by a result, with what it measured.
+type namedLatency struct {
+	Name   string
+	Millis LatencyMillis
+}
+
+// resultLatencies returns the latency summaries result reports.
+func resultLatencies(result TestResult) []namedLatency {
+	var latencies []namedLatency
+	for _, latency := range []struct {
+		name   string
+		millis *LatencyMillis
+	}{
+		{"Lookup", result.LookupLatency},
+		{"Savepoint", result.SavepointLatency},
+		{"Deadlock", result.DeadlockLatency},
+		{"Lock wait", result.LockWaitLatency},
+	} {
+		if latency.millis != nil {
+			latencies = append(latencies, namedLatency{Name: latency.name, Millis: *latency.millis})
+		}
+	}
+	return latencies
+}
+
+// markdownTable renders a Markdown table with header and rows, escaping pipes within cells.
+func markdownTable(header []string, rows [][]string) string {
+	escape := func(cells []string) string {
+		escaped := make([]string, len(cells))
+		for i, cell := range cells {
+			escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+		}
+		return "| " + strings.Join(escaped, " | ") + " |"
+	}
+
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+
+	lines := []string{escape(header), "|" + strings.Join(separator, "|") + "|"}
+	for _, row := range rows {
+		lines = append(lines, escape(row))
+	}
+	return strings.Join(lines, "\n")
+}
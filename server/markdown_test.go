@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultMarkdown(t *testing.T) {
+	result := TestResult{
+		ConnType:              "rpc",
+		Mode:                  "scan",
+		Label:                 "a|b",
+		RunID:                 "run1",
+		RecordsQueried:        1000,
+		PageSize:              100,
+		TotalQueryTimeSeconds: 1.5,
+		QueryRowsPerSecond:    666.67,
+		LookupLatency:         &LatencyMillis{Min: 1, Avg: 2, P50: 2, P95: 3, P99: 4, Max: 5},
+		Regression: &Regression{
+			BaselineRunID:    "base",
+			ThresholdPercent: 20,
+			Metrics: []RegressionMetric{
+				{Name: "total_query_time_seconds", Baseline: 1, Current: 1.5, PercentSlower: 50, Regressed: true},
+			},
+			Regressed: true,
+		},
+	}
+
+	markdown := resultMarkdown(result)
+	assert.Contains(t, markdown, "| Parameter | Value |\n|---|---|\n| Connection | rpc |")
+	assert.Contains(t, markdown, `| Label | a\|b |`)
+	assert.Contains(t, markdown, "| Run ID | run1 |")
+	assert.Contains(t, markdown, "| Total query time | 1.500 s |")
+	assert.Contains(t, markdown, "| Query rate | 667 rows/s |")
+	assert.Contains(t, markdown, "| Lookup | 1.00 | 2.00 | 2.00 | 3.00 | 4.00 | 5.00 |")
+	assert.Contains(t, markdown, "Compared to baseline run `base` (threshold 20%)")
+	assert.Contains(t, markdown, "| `total_query_time_seconds` | 1 | 1.5 | +50.0% | regressed |")
+	assert.NotContains(t, markdown, "Insert time")
+
+	assert.Contains(t, resultMarkdown(TestResult{ConnType: "raw", Error: "boom"}), "**Error:** boom")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"runtime"
+	"time"
+)
+
+// PhaseMemory reports the Go allocations of the plugin process over a phase of a run. Raw
+// connections decode rows in the plugin process, while RPC connections also receive them over
+// RPC there, so comparing the two shows the allocation cost of the RPC driver.
+type PhaseMemory struct {
+	Phase string `json:"phase"`
+	Rows  int    `json:"rows,omitempty"`
+
+	TotalAllocBytes uint64 `json:"total_alloc_bytes"`
+	Mallocs         uint64 `json:"mallocs"`
+
+	// HeapGrowthBytes is the change in live heap over the phase, which is negative when the
+	// garbage collector freed more than the phase kept.
+	HeapGrowthBytes int64 `json:"heap_growth_bytes"`
+
+	AllocBytesPerRow float64 `json:"alloc_bytes_per_row,omitempty"`
+	MallocsPerRow    float64 `json:"mallocs_per_row,omitempty"`
+
+	// GCs counts the garbage collections completed during the phase, and the pauses total and
+	// max their stop-the-world pauses, which add to the latency of any query they interrupt.
+	GCs            uint32  `json:"gc_count"`
+	GCPauseTotalMs float64 `json:"gc_pause_total_ms"`
+	GCPauseMaxMs   float64 `json:"gc_pause_max_ms"`
+}
+
+// memoryPhase samples the memory statistics of the Go runtime at the start of a phase. Reading
+// them briefly stops the world, so phases are sampled outside of timed sections.
+type memoryPhase struct {
+	name   string
+	before runtime.MemStats
+}
+
+// startMemoryPhase samples the memory statistics at the start of the phase name.
+func startMemoryPhase(name string) *memoryPhase {
+	m := &memoryPhase{name: name}
+	runtime.ReadMemStats(&m.before)
+	return m
+}
+
+// end reports the allocations since the phase started, spread over the rows it handled.
+func (m *memoryPhase) end(rows int) PhaseMemory {
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	phase := PhaseMemory{
+		Phase:           m.name,
+		Rows:            rows,
+		TotalAllocBytes: after.TotalAlloc - m.before.TotalAlloc,
+		Mallocs:         after.Mallocs - m.before.Mallocs,
+		HeapGrowthBytes: int64(after.HeapAlloc) - int64(m.before.HeapAlloc),
+	}
+	phase.GCs, phase.GCPauseTotalMs, phase.GCPauseMaxMs = gcPauses(m.before, after)
+	if rows > 0 {
+		phase.AllocBytesPerRow = float64(phase.TotalAllocBytes) / float64(rows)
+		phase.MallocsPerRow = float64(phase.Mallocs) / float64(rows)
+	}
+	return phase
+}
+
+// gcPauses returns the number of garbage collections completed between two samples of the
+// memory statistics, and the total and longest of their pauses in milliseconds. The runtime
+// keeps only the most recent 256 pauses, so the longest is of those when there were more.
+func gcPauses(before, after runtime.MemStats) (uint32, float64, float64) {
+	count := after.NumGC - before.NumGC
+	total := float64(after.PauseTotalNs-before.PauseTotalNs) / float64(time.Millisecond)
+
+	var longest uint64
+	for i := uint32(0); i < min(count, uint32(len(after.PauseNs))); i++ {
+		// The pause of the nth collection is at (n+255)%256.
+		longest = max(longest, after.PauseNs[(after.NumGC-i+255)%uint32(len(after.PauseNs))])
+	}
+	return count, total, float64(longest) / float64(time.Millisecond)
+}
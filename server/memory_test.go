@@ -0,0 +1,69 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var memorySink [][]byte
+
+func TestMemoryPhase(t *testing.T) {
+	memory := startMemoryPhase(phaseQuery)
+	for i := 0; i < 100; i++ {
+		memorySink = append(memorySink, make([]byte, 1024))
+	}
+	phase := memory.end(100)
+	memorySink = nil
+
+	assert.Equal(t, phaseQuery, phase.Phase)
+	assert.Equal(t, 100, phase.Rows)
+	assert.GreaterOrEqual(t, phase.TotalAllocBytes, uint64(100*1024))
+	assert.GreaterOrEqual(t, phase.Mallocs, uint64(100))
+	assert.InDelta(t, float64(phase.TotalAllocBytes)/100, phase.AllocBytesPerRow, 1e-9)
+	assert.InDelta(t, float64(phase.Mallocs)/100, phase.MallocsPerRow, 1e-9)
+
+	t.Run("no rows", func(t *testing.T) {
+		phase := startMemoryPhase(phaseSeed).end(0)
+		assert.Zero(t, phase.AllocBytesPerRow)
+		assert.Zero(t, phase.MallocsPerRow)
+	})
+}
+
+func TestGCPauses(t *testing.T) {
+	t.Run("collections during the phase", func(t *testing.T) {
+		var before, after runtime.MemStats
+		before.NumGC, before.PauseTotalNs = 10, 5_000_000
+		after.NumGC, after.PauseTotalNs = 12, 8_000_000
+		after.PauseNs[(11+255)%256] = 1_000_000
+		after.PauseNs[(12+255)%256] = 2_000_000
+		// A pause from before the phase is not counted.
+		after.PauseNs[(10+255)%256] = 9_000_000
+
+		count, total, longest := gcPauses(before, after)
+		assert.Equal(t, uint32(2), count)
+		assert.InDelta(t, 3.0, total, 1e-9)
+		assert.InDelta(t, 2.0, longest, 1e-9)
+	})
+
+	t.Run("no collections", func(t *testing.T) {
+		var stats runtime.MemStats
+		stats.NumGC = 3
+		stats.PauseNs[2] = 1_000_000
+
+		count, total, longest := gcPauses(stats, stats)
+		assert.Zero(t, count)
+		assert.Zero(t, total)
+		assert.Zero(t, longest)
+	})
+
+	t.Run("forced collection", func(t *testing.T) {
+		memory := startMemoryPhase(phaseQuery)
+		runtime.GC()
+		phase := memory.end(0)
+
+		assert.GreaterOrEqual(t, phase.GCs, uint32(1))
+		assert.GreaterOrEqual(t, phase.GCPauseTotalMs, phase.GCPauseMaxMs)
+	})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxNoiseOps bounds the noise_ops query param.
+	maxNoiseOps = 10000
+
+	// noiseSlots is the number of rows in plugin_test_rpc_noise touched by the noise workload.
+	noiseSlots = 100
+
+	// noiseWorkers is the number of goroutines issuing noise operations, so that a slow
+	// operation does not hold back the target rate.
+	noiseWorkers = 4
+
+	// noiseWritePercent is the share of noise operations that are updates rather than reads.
+	noiseWritePercent = 20
+)
+
+// noiseGenerator keeps the database moderately busy with a light mixed workload against its own
+// table while a benchmark runs, so results reflect a database that is not otherwise idle.
+type noiseGenerator struct {
+	db   *sql.DB
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	opsPerSecond int
+	ops          atomic.Int64
+	errors       atomic.Int64
+}
+
+// startNoise opens a separate raw connection and starts issuing opts.NoiseOps operations per
+// second against plugin_test_rpc_noise until stopped.
+func (p *Plugin) startNoise(opts testOptions) (*noiseGenerator, error) {
+	db, driverName, err := p.openRawConnection(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database for noise: %w", err)
+	}
+
+	if err := seedNoiseTable(db, driverName, opts); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	noise := &noiseGenerator{
+		db:           db,
+		done:         make(chan struct{}),
+		opsPerSecond: opts.NoiseOps,
+	}
+
+	readSQL := opts.tagSQL(rebind(driverName, "SELECT counter FROM plugin_test_rpc_noise WHERE slot = ?"))
+	writeSQL := opts.tagSQL(rebind(driverName, "UPDATE plugin_test_rpc_noise SET counter = counter + 1 WHERE slot = ?"))
+
+	// Ticks are dropped rather than queued when every worker is busy, so the database never
+	// sees a burst of catch-up operations.
+	ticks := make(chan struct{})
+	noise.wg.Add(1)
+	go func() {
+		defer noise.wg.Done()
+		defer close(ticks)
+
+		ticker := time.NewTicker(time.Second / time.Duration(opts.NoiseOps))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-noise.done:
+				return
+			case <-ticker.C:
+				select {
+				case ticks <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	for worker := 0; worker < noiseWorkers; worker++ {
+		noise.wg.Add(1)
+		go func(worker int) {
+			defer noise.wg.Done()
+
+			random := rand.New(rand.NewSource(int64(worker)))
+			for range ticks {
+				slot := random.Intn(noiseSlots)
+
+				var err error
+				if random.Intn(100) < noiseWritePercent {
+					_, err = db.Exec(writeSQL, slot)
+				} else {
+					var counter int
+					err = db.QueryRow(readSQL, slot).Scan(&counter)
+				}
+
+				noise.ops.Add(1)
+				if err != nil {
+					noise.errors.Add(1)
+				}
+			}
+		}(worker)
+	}
+
+	return noise, nil
+}
+
+// seedNoiseTable creates plugin_test_rpc_noise if needed and fills it with noiseSlots rows.
+func seedNoiseTable(db *sql.DB, driverName string, opts testOptions) error {
+	createTableSQL := `
+		CREATE TABLE IF NOT EXISTS plugin_test_rpc_noise (
+			slot INTEGER PRIMARY KEY,
+			counter INTEGER NOT NULL
+		)
+	`
+	if _, err := db.Exec(opts.tagSQL(createTableSQL)); err != nil {
+		return fmt.Errorf("failed to create noise table: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(opts.tagSQL("SELECT COUNT(*) FROM plugin_test_rpc_noise")).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check noise row count: %v", err)
+	}
+	if count == noiseSlots {
+		return nil
+	}
+
+	if _, err := db.Exec(opts.tagSQL("DELETE FROM plugin_test_rpc_noise")); err != nil {
+		return fmt.Errorf("failed to clear noise table: %v", err)
+	}
+
+	args := make([]any, 0, noiseSlots*2)
+	for slot := 0; slot < noiseSlots; slot++ {
+		args = append(args, slot, 0)
+	}
+	insertSQL := multiRowInsert(driverName, "plugin_test_rpc_noise", []string{"slot", "counter"}, noiseSlots)
+	if _, err := db.Exec(opts.tagSQL(insertSQL), args...); err != nil {
+		return fmt.Errorf("failed to seed noise table: %v", err)
+	}
+
+	return nil
+}
+
+// stop halts the noise workload, closes its connection and records what it did on result.
+func (n *noiseGenerator) stop(result *TestResult) {
+	close(n.done)
+	n.wg.Wait()
+	n.db.Close()
+
+	result.NoiseOpsPerSecond = n.opsPerSecond
+	result.NoiseOps = n.ops.Load()
+	result.NoiseErrors = n.errors.Load()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultNoopOps is the number of each no-op operation issued when ops is not given.
+	defaultNoopOps = 1000
+
+	// maxNoopOps bounds the ops query param.
+	maxNoopOps = 100000
+)
+
+// No-op operations timed by the noop workload.
+const (
+	// noopPing pings the database, which the RPC driver forwards to the server's connection.
+	noopPing = "ping"
+
+	// noopExec executes SELECT 1 without reading its result.
+	noopExec = "exec"
+)
+
+// NoopResult times one of the cheapest operations a connection supports.
+type NoopResult struct {
+	Operation    string         `json:"operation"`
+	Count        int            `json:"count"`
+	OpsPerSecond float64        `json:"ops_per_second,omitempty"`
+	Latency      *LatencyMillis `json:"latency,omitempty"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// runNoopTest times opts.NoopOps of each of the cheapest operations the connection supports,
+// which do next to no work in the database, so their latency is that of the driver and, over
+// RPC, of the go-plugin round trip to the server. It needs no data and never writes.
+func (p *Plugin) runNoopTest(db *sql.DB, _ string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label: opts.Label,
+		Mode:  modeNoop,
+		Phase: opts.Phase,
+	}
+
+	if opts.Phase == phaseSeed {
+		return result, nil
+	}
+
+	query := opts.tagSQL("SELECT 1")
+	operations := []struct {
+		name string
+		run  func() error
+	}{
+		{noopPing, db.Ping},
+		{noopExec, func() error {
+			_, err := db.Exec(query)
+			return err
+		}},
+	}
+
+	start := time.Now()
+	for _, operation := range operations {
+		noop, err := timeNoop(operation.name, opts, operation.run)
+		if err != nil {
+			return result, err
+		}
+		result.Noops = append(result.Noops, noop)
+	}
+	result.TotalQueryTimeSeconds = time.Since(start).Seconds()
+
+	return result, nil
+}
+
+// timeNoop issues opts.NoopOps of the operation run back to back, timing each. An operation
+// failing is reported on the result rather than failing the run, so the other can still be
+// timed; only cancellation is returned.
+func timeNoop(name string, opts testOptions, run func() error) (NoopResult, error) {
+	noop := NoopResult{Operation: name}
+	durations := make([]time.Duration, 0, opts.NoopOps)
+
+	start := time.Now()
+	for i := 0; i < opts.NoopOps; i++ {
+		if err := opts.cancelled(); err != nil {
+			return noop, err
+		}
+
+		opStart := time.Now()
+		if err := run(); err != nil {
+			noop.Error = fmt.Sprintf("failed on operation %d: %v", i+1, err)
+			return noop, nil
+		}
+		durations = append(durations, time.Since(opStart))
+	}
+	elapsed := time.Since(start)
+
+	noop.Count = len(durations)
+	if elapsed > 0 {
+		noop.OpsPerSecond = float64(noop.Count) / elapsed.Seconds()
+	}
+	latency := summarizeLatencies(durations).millis()
+	noop.Latency = &latency
+
+	return noop, nil
+}
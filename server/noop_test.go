@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeNoop(t *testing.T) {
+	t.Run("times every operation", func(t *testing.T) {
+		calls := 0
+		noop, err := timeNoop(noopPing, testOptions{NoopOps: 50}, func() error {
+			calls++
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 50, calls)
+		assert.Equal(t, noopPing, noop.Operation)
+		assert.Equal(t, 50, noop.Count)
+		assert.Positive(t, noop.OpsPerSecond)
+		require.NotNil(t, noop.Latency)
+		assert.LessOrEqual(t, noop.Latency.Min, noop.Latency.Max)
+		assert.Empty(t, noop.Error)
+	})
+
+	t.Run("failed operation", func(t *testing.T) {
+		calls := 0
+		noop, err := timeNoop(noopExec, testOptions{NoopOps: 50}, func() error {
+			calls++
+			if calls == 3 {
+				return errors.New("connection reset")
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Zero(t, noop.Count)
+		assert.Nil(t, noop.Latency)
+		assert.Equal(t, "failed on operation 3: connection reset", noop.Error)
+	})
+}
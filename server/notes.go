@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RunNotes reports the notes annotating a stored run.
+type RunNotes struct {
+	RunID string `json:"run_id,omitempty"`
+	Notes string `json:"notes"`
+	Error string `json:"error,omitempty"`
+}
+
+// runNotesUpdate is the body of a request annotating a stored run.
+type runNotesUpdate struct {
+	Notes *string `json:"notes"`
+}
+
+// AnnotateResult replaces the notes of the stored run with the ID given in the path, such as to
+// record after the fact that it ran during a nightly backup window. An empty note clears them.
+func (p *Plugin) AnnotateResult(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var update runNotesUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, RunNotes{RunID: id, Error: "invalid request body: " + err.Error()})
+		return
+	}
+	if update.Notes == nil {
+		respondWithJSON(w, http.StatusBadRequest, RunNotes{RunID: id, Error: "notes must be given"})
+		return
+	}
+	if err := validateNotes(*update.Notes); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, RunNotes{RunID: id, Error: err.Error()})
+		return
+	}
+
+	run, err := p.kvstore.GetRun(id)
+	if err != nil {
+		p.API.LogError("Failed to get run", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, RunNotes{RunID: id, Error: err.Error()})
+		return
+	}
+	if run == nil {
+		respondWithJSON(w, http.StatusNotFound, RunNotes{RunID: id, Error: fmt.Sprintf("unknown run %s", id)})
+		return
+	}
+
+	run.Notes = *update.Notes
+	if err := p.kvstore.SaveRun(*run); err != nil {
+		p.API.LogError("Failed to save run", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, RunNotes{RunID: id, Error: err.Error()})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, RunNotes{RunID: id, Notes: run.Notes})
+}
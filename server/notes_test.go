@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotateResult(t *testing.T) {
+	runs := map[string]kvstore.Run{
+		"run": {ID: "run", ConnType: "rpc", Params: "records=100", Result: []byte(`{"conn_type": "rpc"}`)},
+	}
+	p := Plugin{kvstore: fakeRetentionStore{KVStore: &fakeRunLockStore{}, runs: runs}}
+	p.SetAPI(adminAPI())
+
+	patch := func(target, body string) (int, RunNotes) {
+		r := httptest.NewRequest(http.MethodPatch, target, strings.NewReader(body))
+		r.Header.Set("Mattermost-User-ID", "admin")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, r)
+
+		var response RunNotes
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	t.Run("annotates the stored run", func(t *testing.T) {
+		status, response := patch("/api/v1/results/run", `{"notes": "run during nightly backup window"}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, RunNotes{RunID: "run", Notes: "run during nightly backup window"}, response)
+
+		run := runs["run"]
+		assert.Equal(t, "run during nightly backup window", run.Notes)
+		assert.Equal(t, "records=100", run.Params)
+		assert.JSONEq(t, `{"conn_type": "rpc"}`, string(run.Result))
+	})
+
+	t.Run("empty notes clear them", func(t *testing.T) {
+		status, _ := patch("/api/v1/results/run", `{"notes": ""}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Empty(t, runs["run"].Notes)
+	})
+
+	t.Run("unknown run", func(t *testing.T) {
+		status, response := patch("/api/v1/results/missing", `{"notes": "x"}`)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Contains(t, response.Error, "unknown run")
+	})
+
+	t.Run("invalid bodies", func(t *testing.T) {
+		for _, body := range []string{`{`, `{}`, `{"notes": "` + strings.Repeat("x", maxNotesLength+1) + `"}`} {
+			status, response := patch("/api/v1/results/run", body)
+			assert.Equal(t, http.StatusBadRequest, status, body)
+			assert.NotEmpty(t, response.Error)
+		}
+	})
+}
+
+func TestSaveRunNotes(t *testing.T) {
+	runs := map[string]kvstore.Run{}
+	p := Plugin{kvstore: fakeRetentionStore{KVStore: &fakeRunLockStore{}, runs: runs}}
+	p.SetAPI(adminAPI())
+
+	params := url.Values{"records": {"100"}, "notes": {"after reindex"}}
+	result := TestResult{ConnType: "rpc"}
+	p.saveRun("run", "rpc", params, "", &result)
+
+	// The notes are stored apart from the params a replay reuses, without changing the caller's.
+	run := runs["run"]
+	assert.Equal(t, "after reindex", run.Notes)
+	assert.Equal(t, "records=100", run.Params)
+	assert.Equal(t, "after reindex", params.Get("notes"))
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// defaultNullFraction is the fraction of NULL values when null_fraction is not given.
+const defaultNullFraction = 0.5
+
+// nullColumns are the nullable columns of plugin_test_rpc_nulls, one per sql.Null* type.
+var nullColumns = []string{"s", "n", "f", "b"}
+
+// nullInsertBatch is the number of rows per multi-row INSERT when seeding plugin_test_rpc_nulls.
+const nullInsertBatch = 100
+
+// runNullsTest seeds plugin_test_rpc_nulls with opts.Records rows in which about opts.NullFraction
+// of the values are NULL and pages through them, verifying that every value scans back into its
+// sql.Null* type as written. Rows of different fractions share the table and are told apart by
+// null_permille.
+func (p *Plugin) runNullsTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:        opts.Label,
+		Mode:         modeNulls,
+		Phase:        opts.Phase,
+		PageSize:     opts.PageSize,
+		RowBytes:     opts.RowBytes,
+		NullFraction: opts.NullFraction,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedNullsTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryNullsTable(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// nullPermille is opts.NullFraction in thousandths, which keys the rows seeded with it.
+func (o testOptions) nullPermille() int {
+	return int(math.Round(o.NullFraction * 1000))
+}
+
+// isNullValue reports whether column of row seq is NULL when permille thousandths of the values
+// are. The pattern is a fixed hash of the position, so it is spread evenly over rows and columns
+// and the reader can verify it without another query.
+func isNullValue(seq, column, permille int) bool {
+	h := uint32(seq*len(nullColumns)+column) * 2654435761
+	return int(h%1000) < permille
+}
+
+// nullRowValues returns the values written to the nullable columns of row seq.
+func nullRowValues(seq, rowBytes, permille int) []any {
+	values := []any{
+		sql.NullString{String: testData(seq, rowBytes), Valid: true},
+		sql.NullInt64{Int64: int64(seq), Valid: true},
+		sql.NullFloat64{Float64: float64(seq) / 4, Valid: true},
+		sql.NullBool{Bool: seq%2 == 0, Valid: true},
+	}
+	for column := range values {
+		if isNullValue(seq, column, permille) {
+			values[column] = nil
+		}
+	}
+
+	return values
+}
+
+// seedNullsTable creates plugin_test_rpc_nulls if needed and tops it up to opts.Records rows of
+// opts.NullFraction, returning the number of rows inserted and the time spent inserting.
+func (p *Plugin) seedNullsTable(db *sql.DB, driverName string, opts testOptions) (int, time.Duration, error) {
+	var createTableSQL []string
+	if driverName == "postgres" {
+		createTableSQL = []string{`
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_nulls (
+				id SERIAL PRIMARY KEY,
+				null_permille INTEGER NOT NULL,
+				seq INTEGER NOT NULL,
+				s VARCHAR(255) NULL,
+				n BIGINT NULL,
+				f DOUBLE PRECISION NULL,
+				b BOOLEAN NULL
+			)
+		`,
+			"CREATE INDEX IF NOT EXISTS idx_plugin_test_rpc_nulls_seq ON plugin_test_rpc_nulls (null_permille, seq)",
+		}
+	} else {
+		createTableSQL = []string{`
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_nulls (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				null_permille INT NOT NULL,
+				seq INT NOT NULL,
+				s VARCHAR(255) NULL,
+				n BIGINT NULL,
+				f DOUBLE NULL,
+				b BOOLEAN NULL,
+				INDEX idx_plugin_test_rpc_nulls_seq (null_permille, seq)
+			)
+		`}
+	}
+
+	for _, statement := range createTableSQL {
+		if _, err := db.Exec(opts.tagSQL(statement)); err != nil {
+			return 0, 0, fmt.Errorf("failed to create nulls table: %v", err)
+		}
+	}
+
+	var count int
+	countSQL := rebind(driverName, "SELECT COUNT(*) FROM plugin_test_rpc_nulls WHERE null_permille = ?")
+	if err := db.QueryRow(opts.tagSQL(countSQL), opts.nullPermille()).Scan(&count); err != nil {
+		return 0, 0, fmt.Errorf("failed to check record count: %v", err)
+	}
+	if count >= opts.Records {
+		return 0, 0, nil
+	}
+
+	p.API.LogInfo(fmt.Sprintf("Inserting nullable records: %d of %d", count, opts.Records), "null_fraction", opts.NullFraction)
+	startInsert := time.Now()
+
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	columns := append([]string{"null_permille", "seq"}, nullColumns...)
+	for start := count; start < opts.Records; start += nullInsertBatch {
+		rows := min(nullInsertBatch, opts.Records-start)
+
+		args := make([]any, 0, rows*len(columns))
+		for seq := start; seq < start+rows; seq++ {
+			args = append(args, opts.nullPermille(), seq)
+			args = append(args, nullRowValues(seq, opts.RowBytes, opts.nullPermille())...)
+		}
+
+		if _, err := tx.Exec(opts.tagSQL(multiRowInsert(driverName, "plugin_test_rpc_nulls", columns, rows)), args...); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				p.API.LogError("Failed to rollback transaction", "error", rbErr)
+			}
+			return 0, 0, fmt.Errorf("failed to insert rows %d-%d: %v", start, start+rows-1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return opts.Records - count, time.Since(startInsert), nil
+}
+
+// nullsPageSQL reads one page of the rows of a NULL fraction.
+const nullsPageSQL = "SELECT seq, s, n, f, b FROM plugin_test_rpc_nulls WHERE null_permille = ? ORDER BY seq LIMIT ? OFFSET ?"
+
+// queryNullsTable pages through the rows of opts.NullFraction, recording the rows, bytes and
+// NULL values read on result, and fails on the first value that does not match what was written.
+func queryNullsTable(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+
+	querySQL := opts.tagSQL(rebind(driverName, nullsPageSQL))
+	for offset := 0; offset < opts.Records; offset += opts.PageSize {
+		limit := min(opts.PageSize, opts.Records-offset)
+
+		rows, err := db.Query(querySQL, opts.nullPermille(), limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to query rows at offset %d: %v", offset, err)
+		}
+
+		for rows.Next() {
+			var seq int
+			var s sql.NullString
+			var n sql.NullInt64
+			var f sql.NullFloat64
+			var b sql.NullBool
+			if err := rows.Scan(&seq, &s, &n, &f, &b); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan row: %v", err)
+			}
+
+			if err := checkNullRow(seq, opts, []any{s, n, f, b}); err != nil {
+				rows.Close()
+				return err
+			}
+			for _, valid := range []bool{s.Valid, n.Valid, f.Valid, b.Valid} {
+				if !valid {
+					result.NullValues++
+				}
+			}
+			result.RecordsQueried++
+			result.BytesQueried += int64(len(s.String))
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read rows at offset %d: %v", offset, err)
+		}
+		rows.Close()
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
+
+// checkNullRow compares the scanned values of row seq with those written to it, a NULL column
+// scanning as the zero value of its sql.Null* type.
+func checkNullRow(seq int, opts testOptions, scanned []any) error {
+	for column, expected := range nullRowValues(seq, opts.RowBytes, opts.nullPermille()) {
+		if expected == nil {
+			switch scanned[column].(type) {
+			case sql.NullString:
+				expected = sql.NullString{}
+			case sql.NullInt64:
+				expected = sql.NullInt64{}
+			case sql.NullFloat64:
+				expected = sql.NullFloat64{}
+			case sql.NullBool:
+				expected = sql.NullBool{}
+			}
+		}
+		if scanned[column] != expected {
+			return fmt.Errorf("row %d column %s scanned as %+v, expected %+v", seq, nullColumns[column], scanned[column], expected)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNullValue(t *testing.T) {
+	count := func(permille int) int {
+		nulls := 0
+		for seq := 0; seq < 1000; seq++ {
+			for column := range nullColumns {
+				if isNullValue(seq, column, permille) {
+					nulls++
+				}
+			}
+		}
+		return nulls
+	}
+
+	assert.Zero(t, count(0))
+	assert.Equal(t, 4000, count(1000))
+	assert.InDelta(t, 2000, count(500), 200)
+	assert.InDelta(t, 400, count(100), 80)
+}
+
+func TestCheckNullRow(t *testing.T) {
+	opts := testOptions{NullFraction: 0.5}
+
+	// Find a row whose n column is NULL, to check NULLs and values alike.
+	seq := 0
+	for !isNullValue(seq, 1, opts.nullPermille()) {
+		seq++
+	}
+
+	zeros := []any{sql.NullString{}, sql.NullInt64{}, sql.NullFloat64{}, sql.NullBool{}}
+	row := nullRowValues(seq, 0, opts.nullPermille())
+	for column, value := range row {
+		if value == nil {
+			row[column] = zeros[column]
+		}
+	}
+	assert.NoError(t, checkNullRow(seq, opts, row))
+
+	row[1] = sql.NullInt64{Valid: true}
+	assert.Error(t, checkNullRow(seq, opts, row))
+}
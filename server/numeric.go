@@ -0,0 +1,166 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// numericInsertBatch is the number of rows per multi-row INSERT when seeding
+// plugin_test_rpc_numeric.
+const numericInsertBatch = 100
+
+// numericScale is the number of fractional digits of the decimal column, whose precision of 38
+// leaves 20 integer digits: more significant digits than a float64 can hold.
+const numericScale = 18
+
+// runNumericTest seeds plugin_test_rpc_numeric with opts.Records rows of exact decimals and
+// extreme BIGINTs and pages through them, failing unless every value reads back exactly as
+// written, since plugins handling money depend on exact decimal round-trips.
+func (p *Plugin) runNumericTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeNumeric,
+		Phase:    opts.Phase,
+		PageSize: opts.PageSize,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedNumericTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryNumericTable(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// numericValues returns the decimal, as text with numericScale fractional digits, and the
+// BIGINT written to row seq. Both use every digit their column allows, alternating in sign, so
+// any rounding through a floating point type shows up.
+func numericValues(seq int) (string, int64) {
+	integer := 12345678901234567890 + uint64(seq)
+	fraction := (uint64(seq) * 982451653) % 1e18
+
+	sign := ""
+	big := int64(math.MaxInt64 - seq)
+	if seq%2 == 1 {
+		sign = "-"
+		big = math.MinInt64 + int64(seq)
+	}
+
+	return fmt.Sprintf("%s%d.%0*d", sign, integer, numericScale, fraction), big
+}
+
+// seedNumericTable creates plugin_test_rpc_numeric if needed and tops it up to opts.Records
+// rows, returning the number of rows inserted and the time spent inserting.
+func (p *Plugin) seedNumericTable(db *sql.DB, driverName string, opts testOptions) (int, time.Duration, error) {
+	id := "id INT AUTO_INCREMENT PRIMARY KEY"
+	if driverName == "postgres" {
+		id = "id SERIAL PRIMARY KEY"
+	}
+	// DECIMAL is an alias of NUMERIC on Postgres, so one statement serves both drivers.
+	createTableSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS plugin_test_rpc_numeric (%s, seq INT NOT NULL UNIQUE, amount DECIMAL(38, %d) NOT NULL, big BIGINT NOT NULL)",
+		id, numericScale)
+	if _, err := db.Exec(opts.tagSQL(createTableSQL)); err != nil {
+		return 0, 0, fmt.Errorf("failed to create numeric table: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(opts.tagSQL("SELECT COUNT(*) FROM plugin_test_rpc_numeric")).Scan(&count); err != nil {
+		return 0, 0, fmt.Errorf("failed to check record count: %v", err)
+	}
+	if count >= opts.Records {
+		return 0, 0, nil
+	}
+
+	p.API.LogInfo(fmt.Sprintf("Inserting numeric records: %d of %d", count, opts.Records))
+	startInsert := time.Now()
+
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	columns := []string{"seq", "amount", "big"}
+	for start := count; start < opts.Records; start += numericInsertBatch {
+		rows := min(numericInsertBatch, opts.Records-start)
+
+		args := make([]any, 0, rows*len(columns))
+		for seq := start; seq < start+rows; seq++ {
+			// Decimals are bound as text, so no floating point conversion happens on the way in.
+			amount, big := numericValues(seq)
+			args = append(args, seq, amount, big)
+		}
+
+		if _, err := tx.Exec(opts.tagSQL(multiRowInsert(driverName, "plugin_test_rpc_numeric", columns, rows)), args...); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				p.API.LogError("Failed to rollback transaction", "error", rbErr)
+			}
+			return 0, 0, fmt.Errorf("failed to insert rows %d-%d: %v", start, start+rows-1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return opts.Records - count, time.Since(startInsert), nil
+}
+
+// numericPageSQL reads one page of plugin_test_rpc_numeric.
+const numericPageSQL = "SELECT seq, amount, big FROM plugin_test_rpc_numeric ORDER BY seq LIMIT ? OFFSET ?"
+
+// queryNumericTable pages through the first opts.Records rows of plugin_test_rpc_numeric,
+// recording the rows and bytes read on result, and fails on the first value that does not read
+// back exactly as written.
+func queryNumericTable(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+
+	querySQL := opts.tagSQL(rebind(driverName, numericPageSQL))
+	for offset := 0; offset < opts.Records; offset += opts.PageSize {
+		limit := min(opts.PageSize, opts.Records-offset)
+
+		rows, err := db.Query(querySQL, limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to query rows at offset %d: %v", offset, err)
+		}
+
+		for rows.Next() {
+			var seq int
+			var amount string
+			var big int64
+			if err := rows.Scan(&seq, &amount, &big); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan row: %v", err)
+			}
+			if expectedAmount, expectedBig := numericValues(seq); amount != expectedAmount || big != expectedBig {
+				rows.Close()
+				return fmt.Errorf("row %d read back as (%s, %d), expected (%s, %d)", seq, amount, big, expectedAmount, expectedBig)
+			}
+			result.RecordsQueried++
+			result.BytesQueried += int64(len(amount)) + 8
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read rows at offset %d: %v", offset, err)
+		}
+		rows.Close()
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumericValues(t *testing.T) {
+	amount, big := numericValues(0)
+	assert.Equal(t, "12345678901234567890.000000000000000000", amount)
+	assert.Equal(t, int64(math.MaxInt64), big)
+
+	amount, big = numericValues(1)
+	assert.Equal(t, "-12345678901234567891.000000000982451653", amount)
+	assert.Equal(t, int64(math.MinInt64+1), big)
+
+	for seq := 0; seq < 1000; seq++ {
+		amount, _ := numericValues(seq)
+		integer, fraction, found := strings.Cut(strings.TrimPrefix(amount, "-"), ".")
+		assert.True(t, found)
+		assert.LessOrEqual(t, len(integer), 38-numericScale)
+		assert.Len(t, fraction, numericScale)
+
+		// The values must not survive a float64, or the workload would not catch rounding.
+		f, err := strconv.ParseFloat(amount, 64)
+		assert.NoError(t, err)
+		assert.NotEqual(t, amount, strconv.FormatFloat(f, 'f', numericScale, 64))
+	}
+}
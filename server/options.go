@@ -0,0 +1,689 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// defaultPageSize is the number of records fetched per query when page_size is not given.
+const defaultPageSize = 100
+
+// defaultRecords is the size of the test dataset when records is not given.
+const defaultRecords = 50000
+
+// Workload modes selectable with the mode query param.
+const (
+	// modeScan pages through the plugin_test_rpc table.
+	modeScan = "scan"
+
+	// modeBlob writes and pages through large binary payloads.
+	modeBlob = "blob"
+
+	// modeJoin pages through a join of plugin_test_rpc and a related detail table.
+	modeJoin = "join"
+
+	// modeAggregate runs GROUP BY / COUNT / SUM queries over plugin_test_rpc.
+	modeAggregate = "aggregate"
+
+	// modeSearch runs full-text searches over plugin_test_rpc.
+	modeSearch = "search"
+
+	// modeJSON writes, pages through and filters JSON documents.
+	modeJSON = "json"
+
+	// modePointLookup issues single-row lookups of random ids in plugin_test_rpc.
+	modePointLookup = "point_lookup"
+
+	// modePlanCompare runs the same predicate under a forced index scan and a forced sequential
+	// scan.
+	modePlanCompare = "plan_compare"
+
+	// modeSavepoint runs nested savepoints within a transaction that is rolled back.
+	modeSavepoint = "savepoint"
+
+	// modeDeadlock provokes deadlocks between two workers and retries them with backoff.
+	modeDeadlock = "deadlock"
+
+	// modeRowLock has concurrent workers contend on SELECT ... FOR UPDATE of a few hot rows.
+	modeRowLock = "row_lock"
+
+	// modeTimeout checks whether deadlines cut a deliberately slow query short.
+	modeTimeout = "timeout"
+
+	// modeFullScan reads plugin_test_rpc with a single unbatched SELECT.
+	modeFullScan = "fullscan"
+
+	// modeWide pages through every column of a table with a configurable number of columns.
+	modeWide = "wide"
+
+	// modeNulls pages through nullable columns holding a configurable fraction of NULLs.
+	modeNulls = "nulls"
+
+	// modeText pages through text values in a selectable character set, verifying each.
+	modeText = "text"
+
+	// modeTimestamps round-trips timestamps written in a range of time zones.
+	modeTimestamps = "timestamps"
+
+	// modeNumeric round-trips exact decimals and extreme BIGINTs.
+	modeNumeric = "numeric"
+
+	// modeNoop times the cheapest operations a connection supports, isolating the overhead of
+	// the driver and RPC from SQL execution.
+	modeNoop = "noop"
+
+	// modeSquirrel pages through plugin_test_rpc with queries built by squirrel.
+	modeSquirrel = "squirrel"
+
+	// modeGorm seeds and pages through plugin_test_rpc through the GORM ORM.
+	modeGorm = "gorm"
+)
+
+// workloadModes lists every workload mode, in the order they are offered.
+var workloadModes = []string{
+	modeScan, modeBlob, modeJoin, modeAggregate, modeSearch, modeJSON, modePointLookup, modePlanCompare, modeSavepoint,
+	modeDeadlock, modeRowLock, modeTimeout, modeFullScan, modeWide, modeNulls, modeText, modeTimestamps, modeNumeric,
+	modeNoop, modeSquirrel, modeGorm,
+}
+
+const (
+	// paginationOffset pages with LIMIT and OFFSET.
+	paginationOffset = "offset"
+
+	// paginationKeyset pages by seeking past the last id read.
+	paginationKeyset = "keyset"
+
+	// paginationCursor pages with a Postgres server-side cursor.
+	paginationCursor = "cursor"
+
+	// paginationRandom reads pages from random offsets.
+	paginationRandom = "random"
+)
+
+// maxLabelLength matches the longest application_name Postgres will keep without truncation.
+const maxLabelLength = 63
+
+// labelPattern restricts run labels to characters that are safe inside SQL comments and
+// session settings without any escaping.
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// maxTags caps the number of tags a run may be given.
+const maxTags = 10
+
+// maxNotesLength caps the length of the free-form notes annotating a run.
+const maxNotesLength = 1000
+
+// profileCPU selects a pprof CPU profile of the run with the profile query param.
+const profileCPU = "cpu"
+
+// fingerprintPattern matches the dataset fingerprints handed out by the registry.
+var fingerprintPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// Benchmark phases selectable with the phase query param.
+const (
+	// phaseSeed only creates and populates the test table.
+	phaseSeed = "seed"
+
+	// phaseQuery only runs the timed queries against previously seeded data.
+	phaseQuery = "query"
+
+	// phaseAll seeds any missing data and then runs the timed queries.
+	phaseAll = "all"
+)
+
+// testOptions captures the query params shared by the benchmark endpoints.
+type testOptions struct {
+	// PageSize is the number of records fetched per query.
+	PageSize int
+
+	// Label optionally identifies the run in results and in database-side monitoring.
+	Label string
+
+	// Tags optionally group the run with related runs in the run history.
+	Tags []string
+
+	// Mode selects the workload to run.
+	Mode string
+
+	// Phase selects whether to seed, query, or both.
+	Phase string
+
+	// Profile optionally selects a profile of the plugin process to capture during the run.
+	Profile string
+
+	// QueryLog records every statement of the run in a query log stored with it.
+	QueryLog bool
+
+	// Dataset optionally references a registered dataset fingerprint that a query-phase run must
+	// read. Its profile overrides the mode and sizing options.
+	Dataset string
+
+	// Records is the number of rows seeded and then queried by the workload.
+	Records int
+
+	// PayloadBytes is the size of each binary value written in blob mode.
+	PayloadBytes int
+
+	// RowBytes pads or truncates seeded data values to this many bytes when non-zero.
+	RowBytes int
+
+	// InsertBatch is the number of rows per multi-row INSERT when seeding; values below two
+	// insert one row per statement.
+	InsertBatch int
+
+	// RetryAttempts is the number of times scan mode retries a page that fails with a transient
+	// error before the run fails.
+	RetryAttempts int
+
+	// RetryBackoff is the delay before the first retry of a page, doubling with each further one.
+	// Zero means defaultRetryBackoff.
+	RetryBackoff time.Duration
+
+	// Pagination selects the Paginator scan mode pages through plugin_test_rpc with, defaulting
+	// to OFFSET.
+	Pagination string
+
+	// StatementCache prepares scan mode's page query once per run and reuses it for every page,
+	// rather than sending the query text with each one.
+	StatementCache bool
+
+	// RebuildIndex drops and recreates the secondary index on plugin_test_rpc.data between the
+	// seed and query phases, timing both.
+	RebuildIndex bool
+
+	// Reset truncates the workload's tables before seeding.
+	Reset bool
+
+	// KeepData preserves the tables of a phase=all run, which are otherwise dropped afterwards.
+	KeepData bool
+
+	// Analyze optionally refreshes the statistics of the workload's tables, or also optimizes
+	// them, between the seed and query phases.
+	Analyze string
+
+	// Bulk optionally selects a driver-specific bulk load path for seeding, which takes
+	// precedence over InsertBatch.
+	Bulk string
+
+	// Lookups is the number of single-row queries issued in point lookup mode.
+	Lookups int
+
+	// NoopOps is the number of each operation issued in noop mode.
+	NoopOps int
+
+	// LockWorkers is the number of concurrent workers taking row locks in row lock mode.
+	LockWorkers int
+
+	// HotRows is the number of rows the row lock workers contend on.
+	HotRows int
+
+	// Columns is the number of data columns in the wide mode table.
+	Columns int
+
+	// NullFraction is the fraction of values written as NULL in nulls mode, from 0 to 1.
+	NullFraction float64
+
+	// Charset is the character set of the values written in text mode.
+	Charset string
+
+	// ThinkTime is the pause between scan pages or point lookups.
+	ThinkTime time.Duration
+
+	// Jitter varies each pause by up to this much either way.
+	Jitter time.Duration
+
+	// TargetQPS paces point lookups to this many per second when non-zero.
+	TargetQPS int
+
+	// NoiseOps is the rate of background noise operations per second kept up while the
+	// workload runs, or zero for none.
+	NoiseOps int
+
+	// Explain captures the plans of the workload's representative queries after it runs.
+	Explain bool
+
+	// DSNOptions override parameters of the configured data source when opening raw
+	// connections, such as sslmode or tls. Only system admins may set them.
+	DSNOptions map[string]string
+
+	// DSN optionally replaces the Mattermost data source for raw connections, so another
+	// database such as a replica or a proxy endpoint can be benchmarked. Only system admins may
+	// set it.
+	DSN string
+
+	// DSNDriver is the driver of DSN, defaulting to that of the Mattermost database.
+	DSNDriver string
+
+	// Isolation optionally selects the isolation level of benchmark transactions.
+	Isolation string
+
+	// Iterations is the number of times the timed queries are run, reporting the variability
+	// across them when more than one.
+	Iterations int
+
+	// Duration, when non-zero, keeps rerunning the timed queries until this much query time
+	// has elapsed, reporting the throughput achieved over all of them.
+	Duration time.Duration
+
+	// WarmupBatches is the number of untimed batches of the workload's representative queries
+	// run before the timed queries.
+	WarmupBatches int
+
+	// NodeID optionally pins /api/v1/test and /api/v1/test_raw to the cluster node with this ID,
+	// rather than the node serving the request.
+	NodeID string
+
+	// Flavor is the database flavor detected before the workload runs, rather than a query
+	// param, letting workloads adapt syntax that differs between MySQL and MariaDB.
+	Flavor string
+
+	// ctx is the context of the job running with these options, rather than a query param. It
+	// is cancelled when the job is, and workloads check it with cancelled.
+	ctx context.Context
+
+	// userID is the user who requested the run, rather than a query param, or empty for runs
+	// started by the plugin itself.
+	userID string
+
+	// queryLog records the statements of a run with QueryLog set, rather than a query param. It
+	// is created along with the run's connection.
+	queryLog *queryLog
+}
+
+// parseTestOptions reads the benchmark query params from r. Malformed numeric params fall back
+// to their defaults, while an invalid label is rejected so it never reaches the database.
+func parseTestOptions(r *http.Request) (testOptions, error) {
+	opts := testOptions{
+		PageSize:       defaultPageSize,
+		Mode:           modeScan,
+		Phase:          phaseAll,
+		StatementCache: true,
+	}
+
+	// Only requests running as a job can be cancelled.
+	if _, ok := r.Context().Value(runIDContextKey{}).(string); ok {
+		opts.ctx = r.Context()
+	}
+	opts.userID = r.Header.Get("Mattermost-User-ID")
+
+	query := r.URL.Query()
+	if value := query.Get("page_size"); value != "" {
+		if size, err := strconv.Atoi(value); err == nil && size > 0 {
+			opts.PageSize = size
+		}
+	}
+
+	if label := query.Get("label"); label != "" {
+		if len(label) > maxLabelLength || !labelPattern.MatchString(label) {
+			return opts, fmt.Errorf("invalid label %q: must be at most %d characters of letters, digits, '.', '_', ':' or '-'", label, maxLabelLength)
+		}
+		opts.Label = label
+	}
+
+	if value := query.Get("tags"); value != "" {
+		tags, err := parseTags(value)
+		if err != nil {
+			return opts, err
+		}
+		opts.Tags = tags
+	}
+
+	// Notes are stored with the run rather than read by the workloads, but are checked here so
+	// an overlong note fails the request before the benchmark starts.
+	if err := validateNotes(query.Get("notes")); err != nil {
+		return opts, err
+	}
+
+	if mode := query.Get("mode"); mode != "" {
+		if !slices.Contains(workloadModes, mode) {
+			return opts, fmt.Errorf("unknown mode %q", mode)
+		}
+		opts.Mode = mode
+	}
+
+	if phase := query.Get("phase"); phase != "" {
+		switch phase {
+		case phaseSeed, phaseQuery, phaseAll:
+			opts.Phase = phase
+		default:
+			return opts, fmt.Errorf("unknown phase %q", phase)
+		}
+	}
+
+	if profile := query.Get("profile"); profile != "" {
+		if profile != profileCPU {
+			return opts, fmt.Errorf("unknown profile %q: only %s is supported", profile, profileCPU)
+		}
+		opts.Profile = profile
+	}
+
+	if value := query.Get("query_log"); value != "" {
+		queryLog, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid query_log %q", value)
+		}
+		opts.QueryLog = queryLog
+	}
+
+	if dataset := query.Get("dataset"); dataset != "" {
+		if !fingerprintPattern.MatchString(dataset) {
+			return opts, fmt.Errorf("invalid dataset fingerprint %q", dataset)
+		}
+		if opts.Phase != phaseQuery {
+			return opts, fmt.Errorf("dataset can only be referenced with phase=%s", phaseQuery)
+		}
+		opts.Dataset = dataset
+	}
+
+	opts.Records = defaultRecords
+	switch opts.Mode {
+	case modeSavepoint:
+		opts.Records = defaultSavepointRecords
+	case modeDeadlock:
+		opts.Records = defaultDeadlockRecords
+	case modeRowLock:
+		opts.Records = defaultRowLockRecords
+	case modeTimestamps:
+		opts.Records = defaultTimestampRecords
+	}
+	if opts.Mode == modeBlob {
+		opts.Records = defaultBlobRecords
+		opts.PayloadBytes = defaultPayloadBytes
+		if value := query.Get("payload_bytes"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil {
+				opts.PayloadBytes = max(minPayloadBytes, min(n, maxPayloadBytes))
+			}
+		}
+	}
+	if value := query.Get("row_bytes"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			opts.RowBytes = min(n, maxRowBytes)
+		}
+	}
+	if value := query.Get("insert_batch"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 1 {
+			opts.InsertBatch = min(n, maxPlaceholders)
+		}
+	}
+	if bulk := query.Get("bulk"); bulk != "" {
+		switch bulk {
+		case bulkCopy, bulkLoadData:
+			opts.Bulk = bulk
+		default:
+			return opts, fmt.Errorf("unknown bulk load path %q", bulk)
+		}
+	}
+	if pagination := query.Get("pagination"); pagination != "" {
+		switch pagination {
+		case paginationOffset:
+		case paginationKeyset, paginationCursor, paginationRandom:
+			if opts.Mode != modeScan {
+				return opts, fmt.Errorf("%s pagination is only supported in %s mode", pagination, modeScan)
+			}
+			opts.Pagination = pagination
+		default:
+			return opts, fmt.Errorf("unknown pagination %q", pagination)
+		}
+	}
+	if value := query.Get("retry_attempts"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			if opts.Mode != modeScan {
+				return opts, fmt.Errorf("retry_attempts is only supported in %s mode", modeScan)
+			}
+			if opts.Pagination == paginationCursor {
+				return opts, fmt.Errorf("retry_attempts is not supported with %s pagination", paginationCursor)
+			}
+			opts.RetryAttempts = min(n, maxRetryAttempts)
+		}
+	}
+	if value := query.Get("retry_backoff"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			opts.RetryBackoff = min(d, maxRetryBackoff)
+		}
+	}
+	if value := query.Get("stmt_cache"); value != "" {
+		cache, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid stmt_cache %q", value)
+		}
+		opts.StatementCache = cache
+	}
+	if value := query.Get("rebuild_index"); value != "" {
+		rebuild, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid rebuild_index %q", value)
+		}
+		if rebuild && opts.Mode != modeScan {
+			return opts, fmt.Errorf("rebuild_index is only supported in %s mode", modeScan)
+		}
+		opts.RebuildIndex = rebuild
+	}
+	if value := query.Get("reset"); value != "" {
+		reset, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid reset %q", value)
+		}
+		if reset && opts.Phase == phaseQuery {
+			return opts, fmt.Errorf("reset is not supported with phase=%s, which would find no data", phaseQuery)
+		}
+		opts.Reset = reset
+	}
+	if value := query.Get("keep_data"); value != "" {
+		keep, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid keep_data %q", value)
+		}
+		opts.KeepData = keep
+	}
+	if analyze := query.Get("analyze"); analyze != "" {
+		switch analyze {
+		case analyzeStatistics, analyzeOptimize:
+			opts.Analyze = analyze
+		default:
+			return opts, fmt.Errorf("unknown analyze %q", analyze)
+		}
+	}
+	if value := query.Get("records"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			opts.Records = n
+		}
+	}
+	if value := query.Get("explain"); value != "" {
+		explain, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid explain %q", value)
+		}
+		opts.Explain = explain
+	}
+	if value := query.Get("noise_ops"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			opts.NoiseOps = min(n, maxNoiseOps)
+		}
+	}
+	dsnOptions, err := parseDSNOptions(query)
+	if err != nil {
+		return opts, err
+	}
+	opts.DSNOptions = dsnOptions
+	opts.DSN = query.Get("dsn")
+	if driver := query.Get("dsn_driver"); driver != "" {
+		if driver == "sqlite3" {
+			driver = "sqlite"
+		}
+		if driver != "postgres" && driver != "mysql" && driver != "sqlite" {
+			return opts, fmt.Errorf("unknown dsn_driver %q", driver)
+		}
+		if opts.DSN == "" {
+			return opts, fmt.Errorf("dsn_driver requires dsn")
+		}
+		opts.DSNDriver = driver
+	}
+	if isolation := query.Get("isolation"); isolation != "" {
+		switch isolation {
+		case isolationReadCommitted, isolationRepeatableRead, isolationSerializable:
+			opts.Isolation = isolation
+		default:
+			return opts, fmt.Errorf("unknown isolation %q", isolation)
+		}
+	}
+	if value := query.Get("iterations"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 1 {
+			opts.Iterations = min(n, maxIterations)
+		}
+	}
+	if value := query.Get("duration"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			if opts.Iterations > 0 {
+				return opts, fmt.Errorf("iterations and duration cannot be combined")
+			}
+			opts.Duration = min(d, maxDuration)
+		}
+	}
+	if value := query.Get("warmup_batches"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			opts.WarmupBatches = min(n, maxWarmupBatches)
+		}
+	}
+	if opts.Mode == modePointLookup {
+		opts.Lookups = defaultLookups
+		if value := query.Get("lookups"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				opts.Lookups = min(n, maxLookups)
+			}
+		}
+	}
+	if opts.Mode == modeNoop {
+		opts.NoopOps = defaultNoopOps
+		if value := query.Get("ops"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				opts.NoopOps = min(n, maxNoopOps)
+			}
+		}
+	}
+	if opts.Mode == modeRowLock {
+		opts.LockWorkers = defaultLockWorkers
+		if value := query.Get("lock_workers"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				opts.LockWorkers = min(n, maxLockWorkers)
+			}
+		}
+		opts.HotRows = defaultHotRows
+		if value := query.Get("hot_rows"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				opts.HotRows = min(n, maxHotRows)
+			}
+		}
+	}
+	if opts.Mode == modeWide {
+		opts.Columns = defaultWideColumns
+		if value := query.Get("columns"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				opts.Columns = min(n, maxWideColumns)
+			}
+		}
+	}
+	if opts.Mode == modeNulls {
+		opts.NullFraction = defaultNullFraction
+		if value := query.Get("null_fraction"); value != "" {
+			if f, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(f) {
+				opts.NullFraction = max(0, min(f, 1))
+			}
+		}
+	}
+	if opts.Mode == modeText {
+		opts.Charset = charsetASCII
+	}
+	if charset := query.Get("charset"); charset != "" {
+		switch charset {
+		case charsetASCII, charsetUTF8MB4:
+			if opts.Mode != modeText {
+				return opts, fmt.Errorf("charset is only supported in %s mode", modeText)
+			}
+			opts.Charset = charset
+		default:
+			return opts, fmt.Errorf("unknown charset %q", charset)
+		}
+	}
+	if nodeID := query.Get("node_id"); nodeID != "" {
+		if !model.IsValidId(nodeID) {
+			return opts, fmt.Errorf("invalid node_id %q", nodeID)
+		}
+		opts.NodeID = nodeID
+	}
+	for _, pause := range []struct {
+		param    string
+		duration *time.Duration
+	}{
+		{"think_time_ms", &opts.ThinkTime},
+		{"jitter_ms", &opts.Jitter},
+	} {
+		value := query.Get(pause.param)
+		if value == "" {
+			continue
+		}
+		if opts.Mode != modeScan && opts.Mode != modePointLookup {
+			return opts, fmt.Errorf("%s is only supported in %s and %s modes", pause.param, modeScan, modePointLookup)
+		}
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			*pause.duration = min(time.Duration(n)*time.Millisecond, maxThinkTime)
+		}
+	}
+	if value := query.Get("target_qps"); value != "" {
+		if opts.Mode != modePointLookup {
+			return opts, fmt.Errorf("target_qps is only supported in %s mode", modePointLookup)
+		}
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			opts.TargetQPS = min(n, maxTargetQPS)
+		}
+	}
+
+	return opts, nil
+}
+
+// tagSQL prefixes query with a comment naming the run label, if any, so that statements
+// issued over connections we cannot otherwise tag remain attributable in database logs.
+func (o testOptions) tagSQL(query string) string {
+	if o.Label == "" {
+		return query
+	}
+
+	return "/* " + o.Label + " */ " + query
+}
+
+// validateNotes checks the free-form notes annotating a run.
+func validateNotes(notes string) error {
+	if utf8.RuneCountInString(notes) > maxNotesLength {
+		return fmt.Errorf("notes too long: at most %d characters may be given", maxNotesLength)
+	}
+	return nil
+}
+
+// parseTags parses a comma-separated list of tags, each restricted as labels are, dropping
+// duplicates.
+func parseTags(value string) ([]string, error) {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		if len(tag) > maxLabelLength || !labelPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q: must be at most %d characters of letters, digits, '.', '_', ':' or '-'", tag, maxLabelLength)
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxTags {
+		return nil, fmt.Errorf("too many tags: at most %d may be given", maxTags)
+	}
+	return tags, nil
+}
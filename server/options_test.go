@@ -0,0 +1,459 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTestOptions(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, testOptions{PageSize: defaultPageSize, Mode: modeScan, Phase: phaseAll, Records: defaultRecords, StatementCache: true}, opts)
+	})
+
+	t.Run("label", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?page_size=500&label=run-1", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, testOptions{PageSize: 500, Label: "run-1", Mode: modeScan, Phase: phaseAll, Records: defaultRecords, StatementCache: true}, opts)
+	})
+
+	t.Run("blob mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=blob&payload_bytes=10", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, testOptions{PageSize: defaultPageSize, Mode: modeBlob, Phase: phaseAll, Records: defaultBlobRecords, PayloadBytes: minPayloadBytes, StatementCache: true}, opts)
+	})
+
+	t.Run("deadlock mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=deadlock", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, modeDeadlock, opts.Mode)
+		assert.Equal(t, defaultDeadlockRecords, opts.Records)
+		assert.True(t, opts.writes())
+		assert.False(t, opts.seedsDataset())
+	})
+
+	t.Run("row lock mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=row_lock&lock_workers=1000&hot_rows=2", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, defaultRowLockRecords, opts.Records)
+		assert.Equal(t, maxLockWorkers, opts.LockWorkers)
+		assert.Equal(t, 2, opts.HotRows)
+	})
+
+	t.Run("timeout mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=timeout", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.False(t, opts.writes())
+		assert.False(t, opts.seedsDataset())
+	})
+
+	t.Run("wide mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=wide", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, defaultWideColumns, opts.Columns)
+		assert.False(t, opts.seedsDataset())
+	})
+
+	t.Run("wide mode columns clamped", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=wide&columns=1000", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, maxWideColumns, opts.Columns)
+	})
+
+	t.Run("nulls mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=nulls&null_fraction=1.5", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, opts.NullFraction)
+		assert.Equal(t, 1000, opts.nullPermille())
+		assert.False(t, opts.seedsDataset())
+	})
+
+	t.Run("text mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=text", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, charsetASCII, opts.Charset)
+		assert.False(t, opts.seedsDataset())
+	})
+
+	t.Run("timestamps mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=timestamps", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, defaultTimestampRecords, opts.Records)
+		assert.False(t, opts.seedsDataset())
+	})
+
+	t.Run("numeric mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=numeric", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, modeNumeric, opts.Mode)
+		assert.False(t, opts.seedsDataset())
+	})
+
+	t.Run("invalid node id", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?node_id=app-1", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("statement cache disabled", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?stmt_cache=false", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.False(t, opts.StatementCache)
+	})
+
+	t.Run("invalid statement cache", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?stmt_cache=sometimes", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("cursor pagination", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?pagination=cursor", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, paginationCursor, opts.Pagination)
+	})
+
+	t.Run("reset with phase query", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?phase=query&reset=true", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown analyze", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?analyze=vacuum", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("keyset pagination", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?pagination=keyset", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, paginationKeyset, opts.Pagination)
+	})
+
+	t.Run("cursor pagination outside scan mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=join&pagination=cursor", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?retry_attempts=50&retry_backoff=250ms", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, maxRetryAttempts, opts.RetryAttempts)
+		assert.Equal(t, 250*time.Millisecond, opts.RetryBackoff)
+	})
+
+	t.Run("retries with cursor pagination", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?pagination=cursor&retry_attempts=3", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("think time", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=point_lookup&think_time_ms=25&jitter_ms=120000", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 25*time.Millisecond, opts.ThinkTime)
+		assert.Equal(t, maxThinkTime, opts.Jitter)
+	})
+
+	t.Run("think time outside scan and point lookup modes", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=join&think_time_ms=25&jitter_ms=10", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.EqualError(t, err, "think_time_ms is only supported in scan and point_lookup modes")
+	})
+
+	t.Run("charset outside text mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?charset=utf8mb4", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("target qps", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=point_lookup&target_qps=500", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 500, opts.TargetQPS)
+	})
+
+	t.Run("target qps outside point lookup mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?target_qps=500", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("isolation", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?isolation=serializable", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, isolationSerializable, opts.Isolation)
+		assert.Equal(t, sql.LevelSerializable, opts.isolationLevel())
+	})
+
+	t.Run("unknown isolation", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?isolation=snapshot", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("iterations", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?iterations=1000", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, maxIterations, opts.Iterations)
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?duration=2h", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, maxDuration, opts.Duration)
+	})
+
+	t.Run("iterations with duration", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?iterations=3&duration=30s", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("warmup batches", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?warmup_batches=5000", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, maxWarmupBatches, opts.WarmupBatches)
+	})
+
+	t.Run("alternate dsn", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test_raw?dsn=postgres%3A%2F%2Freplica%2Fmattermost&dsn_driver=postgres", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "postgres://replica/mattermost", opts.DSN)
+		assert.Equal(t, "postgres", opts.DSNDriver)
+	})
+
+	t.Run("dsn driver without dsn", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test_raw?dsn_driver=mysql", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("sqlite dsn driver", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test_raw?dsn=file%3Atest.db&dsn_driver=sqlite3", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "sqlite", opts.DSNDriver)
+	})
+
+	t.Run("unknown dsn driver", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test_raw?dsn=x&dsn_driver=oracle", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("point lookup mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=point_lookup&lookups=5000000", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, testOptions{PageSize: defaultPageSize, Mode: modePointLookup, Phase: phaseAll, Records: defaultRecords, Lookups: maxLookups, StatementCache: true}, opts)
+	})
+
+	t.Run("noop mode", func(t *testing.T) {
+		opts, err := parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=noop", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, defaultNoopOps, opts.NoopOps)
+		assert.False(t, opts.seedsDataset())
+
+		opts, err = parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=noop&ops=5000000", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, maxNoopOps, opts.NoopOps)
+
+		// ops only applies to noop mode.
+		opts, err = parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test?ops=10", nil))
+		assert.NoError(t, err)
+		assert.Zero(t, opts.NoopOps)
+	})
+
+	t.Run("noise ops", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?noise_ops=50000", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, maxNoiseOps, opts.NoiseOps)
+	})
+
+	t.Run("query phase", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?phase=query", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, phaseQuery, opts.Phase)
+	})
+
+	t.Run("unknown phase is rejected", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?phase=bogus", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown mode is rejected", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?mode=bogus", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("label with comment terminator is rejected", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?label=a*/b", nil)
+
+		_, err := parseTestOptions(r)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/test?tags=before-upgrade,postgres-15,,before-upgrade", nil)
+
+		opts, err := parseTestOptions(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"before-upgrade", "postgres-15"}, opts.Tags)
+	})
+
+	t.Run("invalid tags are rejected", func(t *testing.T) {
+		for _, tags := range []string{"a*/b", "1,2,3,4,5,6,7,8,9,10,11"} {
+			_, err := parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test?tags="+tags, nil))
+			assert.Error(t, err, tags)
+		}
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		opts, err := parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test?profile=cpu", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, profileCPU, opts.Profile)
+
+		_, err = parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test?profile=heap", nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("query log", func(t *testing.T) {
+		opts, err := parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test?query_log=true", nil))
+		assert.NoError(t, err)
+		assert.True(t, opts.QueryLog)
+
+		_, err = parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test?query_log=all", nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("overlong notes are rejected", func(t *testing.T) {
+		_, err := parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test?notes="+strings.Repeat("x", maxNotesLength), nil))
+		assert.NoError(t, err)
+
+		_, err = parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test?notes="+strings.Repeat("x", maxNotesLength+1), nil))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+)
+
+// maxTargetQPS caps target_qps.
+const maxTargetQPS = 100000
+
+// tokenBucket paces operations to a steady rate. It holds at most one token, so an operation
+// that overruns its slot lets only the next one start immediately rather than a burst catching
+// up, and the achieved rate falls below the target once operations can no longer keep up.
+type tokenBucket struct {
+	interval time.Duration
+	tokens   float64
+	last     time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// newTokenBucket returns a bucket issuing rate tokens per second, the first immediately.
+func newTokenBucket(rate int) *tokenBucket {
+	return &tokenBucket{
+		interval: time.Second / time.Duration(rate),
+		tokens:   1,
+		last:     time.Now(),
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+}
+
+// wait blocks until a token is available and takes it.
+func (b *tokenBucket) wait() {
+	now := b.now()
+	b.tokens = min(1, b.tokens+float64(now.Sub(b.last))/float64(b.interval))
+	b.last = now
+
+	if b.tokens < 1 {
+		delay := time.Duration((1 - b.tokens) * float64(b.interval))
+		b.sleep(delay)
+		b.last = b.last.Add(delay)
+		b.tokens = 1
+	}
+
+	b.tokens--
+}
+
+// maxThinkTime caps think_time_ms and jitter_ms.
+const maxThinkTime = 60 * time.Second
+
+// thinker pauses between operations as an interactive client would, rather than issuing them
+// in a tight loop, so idle connections are reused after a gap as they are in production. Each
+// pause is the think time plus a uniformly random jitter either way, never below zero, drawn from
+// a fixed seed so every run pauses alike.
+type thinker struct {
+	thinkTime time.Duration
+	jitter    time.Duration
+	random    *rand.Rand
+
+	// total is the time spent paused, which callers exclude from their query time.
+	total time.Duration
+
+	sleep func(time.Duration)
+}
+
+// newThinker returns a thinker pausing for opts.ThinkTime and opts.Jitter.
+func newThinker(opts testOptions) *thinker {
+	return &thinker{
+		thinkTime: opts.ThinkTime,
+		jitter:    opts.Jitter,
+		random:    rand.New(rand.NewSource(int64(opts.ThinkTime + opts.Jitter))),
+		sleep:     time.Sleep,
+	}
+}
+
+// think pauses before the next operation.
+func (t *thinker) think() {
+	pause := t.thinkTime
+	if t.jitter > 0 {
+		pause += time.Duration(t.random.Int63n(int64(2*t.jitter)+1)) - t.jitter
+	}
+	if pause <= 0 {
+		return
+	}
+
+	t.sleep(pause)
+	t.total += pause
+}
+
+// report records the time spent paused on result.
+func (t *thinker) report(result *TestResult) {
+	result.ThinkTimeSeconds = t.total.Seconds()
+}
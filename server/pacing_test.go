@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	clock := time.Unix(0, 0)
+	var slept []time.Duration
+
+	bucket := newTokenBucket(100)
+	bucket.last = clock
+	bucket.now = func() time.Time { return clock }
+	bucket.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		clock = clock.Add(d)
+	}
+
+	// The first operation starts immediately and the next waits out its 10ms slot.
+	bucket.wait()
+	bucket.wait()
+	assert.Equal(t, []time.Duration{10 * time.Millisecond}, slept)
+
+	// An operation taking 4ms leaves 6ms to wait.
+	clock = clock.Add(4 * time.Millisecond)
+	bucket.wait()
+	assert.Equal(t, 6*time.Millisecond, slept[1])
+
+	// An operation overrunning its slot lets only the next one start without waiting.
+	clock = clock.Add(50 * time.Millisecond)
+	bucket.wait()
+	bucket.wait()
+	assert.Len(t, slept, 3)
+	assert.Equal(t, 10*time.Millisecond, slept[2])
+}
+
+func TestThinker(t *testing.T) {
+	t.Run("pauses for the think time", func(t *testing.T) {
+		var slept []time.Duration
+		thinker := newThinker(testOptions{ThinkTime: 20 * time.Millisecond})
+		thinker.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+		thinker.think()
+		thinker.think()
+
+		assert.Equal(t, []time.Duration{20 * time.Millisecond, 20 * time.Millisecond}, slept)
+		assert.Equal(t, 40*time.Millisecond, thinker.total)
+	})
+
+	t.Run("jitter varies each pause either way", func(t *testing.T) {
+		var slept []time.Duration
+		thinker := newThinker(testOptions{ThinkTime: 20 * time.Millisecond, Jitter: 5 * time.Millisecond})
+		thinker.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+		for range 100 {
+			thinker.think()
+		}
+
+		for _, d := range slept {
+			assert.GreaterOrEqual(t, d, 15*time.Millisecond)
+			assert.LessOrEqual(t, d, 25*time.Millisecond)
+		}
+		assert.NotEqual(t, slept[0], slept[1])
+	})
+
+	t.Run("no think time never pauses", func(t *testing.T) {
+		thinker := newThinker(testOptions{})
+		thinker.sleep = func(time.Duration) { t.Fatal("unexpected pause") }
+
+		thinker.think()
+
+		var result TestResult
+		thinker.report(&result)
+		assert.Zero(t, result.ThinkTimeSeconds)
+	})
+}
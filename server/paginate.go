@@ -0,0 +1,201 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+)
+
+// Paginator is a strategy for paging through the first opts.Records rows of plugin_test_rpc.
+// queryTestTable drives the loop, reading, counting and retrying each page, so a new strategy
+// only decides which rows its next page holds.
+type Paginator interface {
+	// Name is the pagination strategy reported in results.
+	Name() string
+
+	// Page queries the next page of at most limit rows, selecting id and data.
+	Page(limit int) (*sql.Rows, error)
+
+	// Advance moves past a page of read rows, the last of which had id lastID.
+	Advance(lastID, read int)
+
+	// Close releases anything held for the duration of the scan.
+	Close() error
+}
+
+// newPaginator returns the paginator selected by opts.Pagination, defaulting to OFFSET.
+func newPaginator(db *sql.DB, driverName string, opts testOptions, result *TestResult) (Paginator, error) {
+	switch opts.Pagination {
+	case paginationKeyset:
+		query, err := preparePageQuery(db, driverName, opts, keysetPageSQL, result)
+		if err != nil {
+			return nil, err
+		}
+		return &keysetPaginator{pageQuery: query}, nil
+	case paginationCursor:
+		return newCursorPaginator(db, driverName, opts)
+	case paginationRandom:
+		query, err := preparePageQuery(db, driverName, opts, scanPageSQL, result)
+		if err != nil {
+			return nil, err
+		}
+		return &randomPaginator{pageQuery: query, records: opts.Records, random: rand.New(rand.NewSource(int64(opts.Records)))}, nil
+	default:
+		query, err := preparePageQuery(db, driverName, opts, scanPageSQL, result)
+		if err != nil {
+			return nil, err
+		}
+		return &offsetPaginator{pageQuery: query}, nil
+	}
+}
+
+// pageQuery runs a page query, through a statement prepared once per scan with
+// opts.StatementCache.
+type pageQuery struct {
+	query func(args ...any) (*sql.Rows, error)
+	stmt  *sql.Stmt
+}
+
+// preparePageQuery tags and rebinds querySQL, preparing it when opts.StatementCache is set.
+func preparePageQuery(db *sql.DB, driverName string, opts testOptions, querySQL string, result *TestResult) (pageQuery, error) {
+	querySQL = opts.tagSQL(rebind(driverName, querySQL))
+	if !opts.StatementCache {
+		return pageQuery{query: func(args ...any) (*sql.Rows, error) { return db.Query(querySQL, args...) }}, nil
+	}
+
+	stmt, err := db.Prepare(querySQL)
+	if err != nil {
+		return pageQuery{}, fmt.Errorf("failed to prepare page query: %v", err)
+	}
+	result.StatementCache = true
+
+	return pageQuery{query: stmt.Query, stmt: stmt}, nil
+}
+
+// Close closes the prepared statement, if any.
+func (q pageQuery) Close() error {
+	if q.stmt == nil {
+		return nil
+	}
+
+	return q.stmt.Close()
+}
+
+// scanPageSQL reads one page of plugin_test_rpc.
+const scanPageSQL = "SELECT id, data FROM plugin_test_rpc ORDER BY id LIMIT ? OFFSET ?"
+
+// offsetPaginator pages with LIMIT and OFFSET, so each page scans past every row before it.
+type offsetPaginator struct {
+	pageQuery
+	offset int
+}
+
+func (p *offsetPaginator) Name() string { return paginationOffset }
+
+func (p *offsetPaginator) Page(limit int) (*sql.Rows, error) {
+	rows, err := p.query(limit, p.offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rows at offset %d: %w", p.offset, err)
+	}
+
+	return rows, nil
+}
+
+func (p *offsetPaginator) Advance(_, read int) { p.offset += read }
+
+// keysetPageSQL reads the page of plugin_test_rpc following an id.
+const keysetPageSQL = "SELECT id, data FROM plugin_test_rpc WHERE id > ? ORDER BY id LIMIT ?"
+
+// keysetPaginator pages by seeking past the last id read, so every page is an index range scan
+// however deep the scan reads.
+type keysetPaginator struct {
+	pageQuery
+	lastID int
+}
+
+func (p *keysetPaginator) Name() string { return paginationKeyset }
+
+func (p *keysetPaginator) Page(limit int) (*sql.Rows, error) {
+	rows, err := p.query(p.lastID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rows after id %d: %w", p.lastID, err)
+	}
+
+	return rows, nil
+}
+
+func (p *keysetPaginator) Advance(lastID, _ int) { p.lastID = lastID }
+
+// randomPaginator reads each page from a random offset within the first records rows, as a
+// client jumping between pages would, rather than scanning in order. Offsets are drawn from a
+// fixed seed so runs of the same size read the same pages.
+type randomPaginator struct {
+	pageQuery
+	records int
+	random  *rand.Rand
+}
+
+func (p *randomPaginator) Name() string { return paginationRandom }
+
+func (p *randomPaginator) Page(limit int) (*sql.Rows, error) {
+	offset := p.random.Intn(max(p.records-limit, 0) + 1)
+	rows, err := p.query(limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rows at offset %d: %w", offset, err)
+	}
+
+	return rows, nil
+}
+
+func (p *randomPaginator) Advance(_, _ int) {}
+
+// cursorName names the server-side cursor used for cursor pagination.
+const cursorName = "plugin_test_rpc_cursor"
+
+// cursorPaginator fetches pages from a Postgres server-side cursor, so that no page costs more
+// than the last as it would with OFFSET. The cursor lives in a transaction for its duration.
+type cursorPaginator struct {
+	tx      *sql.Tx
+	fetched int
+	opts    testOptions
+}
+
+// newCursorPaginator declares a cursor over the first opts.Records rows of plugin_test_rpc.
+func newCursorPaginator(db *sql.DB, driverName string, opts testOptions) (*cursorPaginator, error) {
+	if driverName != "postgres" {
+		return nil, fmt.Errorf("%s pagination is only supported on postgres", paginationCursor)
+	}
+
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	// DECLARE takes no bind parameters, so the limit is formatted in.
+	declareSQL := fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR SELECT id, data FROM plugin_test_rpc ORDER BY id LIMIT %d", cursorName, opts.Records)
+	if _, err := tx.Exec(opts.tagSQL(declareSQL)); err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("failed to declare cursor: %v", err)
+	}
+
+	return &cursorPaginator{tx: tx, opts: opts}, nil
+}
+
+func (p *cursorPaginator) Name() string { return paginationCursor }
+
+func (p *cursorPaginator) Page(limit int) (*sql.Rows, error) {
+	// FETCH takes no bind parameters either.
+	rows, err := p.tx.Query(p.opts.tagSQL(fmt.Sprintf("FETCH FORWARD %d FROM %s", limit, cursorName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rows after %d: %w", p.fetched, err)
+	}
+
+	return rows, nil
+}
+
+func (p *cursorPaginator) Advance(_, read int) { p.fetched += read }
+
+// Close rolls back the transaction. Nothing was written, so this only closes the cursor.
+func (p *cursorPaginator) Close() error {
+	return p.tx.Rollback()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPageQuery returns a pageQuery that records the arguments of every page query.
+func recordingPageQuery(calls *[][]any) pageQuery {
+	return pageQuery{query: func(args ...any) (*sql.Rows, error) {
+		*calls = append(*calls, args)
+		return nil, nil
+	}}
+}
+
+func TestPaginators(t *testing.T) {
+	t.Run("offset", func(t *testing.T) {
+		var calls [][]any
+		pager := &offsetPaginator{pageQuery: recordingPageQuery(&calls)}
+
+		_, err := pager.Page(100)
+		require.NoError(t, err)
+		pager.Advance(150, 100)
+		_, err = pager.Page(50)
+		require.NoError(t, err)
+
+		assert.Equal(t, paginationOffset, pager.Name())
+		assert.Equal(t, [][]any{{100, 0}, {50, 100}}, calls)
+	})
+
+	t.Run("keyset", func(t *testing.T) {
+		var calls [][]any
+		pager := &keysetPaginator{pageQuery: recordingPageQuery(&calls)}
+
+		_, err := pager.Page(100)
+		require.NoError(t, err)
+		pager.Advance(150, 100)
+		_, err = pager.Page(50)
+		require.NoError(t, err)
+
+		assert.Equal(t, paginationKeyset, pager.Name())
+		assert.Equal(t, [][]any{{0, 100}, {150, 50}}, calls)
+	})
+
+	t.Run("random offsets stay within the records", func(t *testing.T) {
+		var calls [][]any
+		opts := testOptions{Pagination: paginationRandom, Records: 1000}
+		pager, err := newPaginator(nil, "postgres", opts, &TestResult{})
+		require.NoError(t, err)
+		pager.(*randomPaginator).pageQuery = recordingPageQuery(&calls)
+
+		for range 100 {
+			_, err := pager.Page(100)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, paginationRandom, pager.Name())
+		for _, args := range calls {
+			assert.Equal(t, 100, args[0])
+			assert.LessOrEqual(t, args[1].(int), 900)
+		}
+	})
+
+	t.Run("cursor on mysql", func(t *testing.T) {
+		_, err := newPaginator(nil, "mysql", testOptions{Pagination: paginationCursor}, &TestResult{})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultPingIterations is the number of round trips timed per connection type.
+	defaultPingIterations = 100
+
+	// maxPingIterations bounds the work a single ping request can trigger.
+	maxPingIterations = 10000
+)
+
+// PingResult reports the round-trip latency of trivial statements on one connection type.
+type PingResult struct {
+	ConnType   string  `json:"conn_type"`
+	Iterations int     `json:"iterations"`
+	MinMillis  float64 `json:"min_ms"`
+	AvgMillis  float64 `json:"avg_ms"`
+	P99Millis  float64 `json:"p99_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// PingResponse collects the ping results for every connection type.
+type PingResponse struct {
+	Label   string       `json:"label,omitempty"`
+	Results []PingResult `json:"results"`
+}
+
+// PingDatabase runs `SELECT 1` repeatedly over the RPC and raw connections, isolating the
+// per-statement round-trip overhead from the cost of executing real queries.
+func (p *Plugin) PingDatabase(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, PingResponse{
+			Results: []PingResult{{Error: err.Error()}},
+		})
+		return
+	}
+
+	iterations := defaultPingIterations
+	if value := r.URL.Query().Get("iterations"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			iterations = min(n, maxPingIterations)
+		}
+	}
+
+	response := PingResponse{
+		Label: opts.Label,
+	}
+
+	rpcResult := PingResult{ConnType: "rpc", Iterations: iterations}
+	if db, err := p.rpcDB(); err != nil {
+		rpcResult.Error = fmt.Sprintf("Failed to get database: %v", err)
+	} else if err := pingDB(db, iterations, opts, &rpcResult); err != nil {
+		rpcResult.Error = err.Error()
+	}
+	response.Results = append(response.Results, rpcResult)
+
+	rawResult := PingResult{ConnType: "raw", Iterations: iterations}
+	if db, _, err := p.openRawConnection(opts); err != nil {
+		rawResult.Error = fmt.Sprintf("Failed to connect to database: %v", err)
+	} else {
+		if err := pingDB(db, iterations, opts, &rawResult); err != nil {
+			rawResult.Error = err.Error()
+		}
+		db.Close()
+	}
+	response.Results = append(response.Results, rawResult)
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// pingDB times iterations round trips of `SELECT 1` on db and records the summary on result.
+func pingDB(db *sql.DB, iterations int, opts testOptions, result *PingResult) error {
+	query := opts.tagSQL("SELECT 1")
+	durations := make([]time.Duration, 0, iterations)
+
+	for i := 0; i < iterations; i++ {
+		var one int
+		start := time.Now()
+		if err := db.QueryRow(query).Scan(&one); err != nil {
+			return fmt.Errorf("failed to ping database on iteration %d: %v", i, err)
+		}
+		durations = append(durations, time.Since(start))
+	}
+
+	summary := summarizeLatencies(durations)
+	result.MinMillis = millis(summary.Min)
+	result.AvgMillis = millis(summary.Avg)
+	result.P99Millis = millis(summary.P99)
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// planQueries is the number of predicate lookups run under each plan.
+const planQueries = 20
+
+// Plans compared by the plan comparison workload.
+const (
+	planIndex   = "index"
+	planSeqScan = "seq_scan"
+)
+
+// PlanResult reports the timing of the predicate lookups under one forced plan.
+type PlanResult struct {
+	Plan         string  `json:"plan"`
+	Queries      int     `json:"queries"`
+	RowsReturned int     `json:"rows_returned"`
+	TimeSeconds  float64 `json:"time_seconds"`
+}
+
+// planVariant forces a query plan for the predicate lookup.
+type planVariant struct {
+	// Name identifies the plan in results.
+	Name string
+
+	// Settings are statements run at the start of the transaction to steer the planner.
+	Settings []string
+
+	// Query is the predicate lookup, taking the data value and the limit.
+	Query string
+}
+
+// planVariants returns the driver-specific ways of forcing an index scan and a sequential scan
+// of plugin_test_rpc for the same predicate: planner settings on Postgres, index hints on MySQL.
+func planVariants(driverName string) []planVariant {
+	if driverName == "postgres" {
+		query := "SELECT id, data FROM plugin_test_rpc WHERE data = $1 LIMIT $2"
+		return []planVariant{
+			{
+				Name:     planIndex,
+				Settings: []string{"SET LOCAL enable_seqscan = off"},
+				Query:    query,
+			},
+			{
+				Name: planSeqScan,
+				Settings: []string{
+					"SET LOCAL enable_indexscan = off",
+					"SET LOCAL enable_indexonlyscan = off",
+					"SET LOCAL enable_bitmapscan = off",
+				},
+				Query: query,
+			},
+		}
+	}
+
+	return []planVariant{
+		{
+			Name:  planIndex,
+			Query: "SELECT id, data FROM plugin_test_rpc FORCE INDEX (" + testDataIndex + ") WHERE data = ? LIMIT ?",
+		},
+		{
+			Name:  planSeqScan,
+			Query: "SELECT id, data FROM plugin_test_rpc IGNORE INDEX (" + testDataIndex + ") WHERE data = ? LIMIT ?",
+		},
+	}
+}
+
+// runPlanCompareTest seeds plugin_test_rpc, ensures the index on its data column, and then runs
+// the same equality predicate under a forced index scan and a forced sequential scan. Comparing
+// the two across connection types shows whether the RPC overhead or the plan dominates.
+func (p *Plugin) runPlanCompareTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modePlanCompare,
+		Phase:    opts.Phase,
+		PageSize: opts.PageSize,
+		RowBytes: opts.RowBytes,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedTestTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+		result.setInsertMethod(opts)
+
+		buildTime, err := ensureTestDataIndex(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.IndexBuildTimeSeconds = buildTime.Seconds()
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := p.comparePlans(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// comparePlans runs planQueries lookups of seeded data values under each plan variant, each
+// variant in its own transaction so planner settings stay scoped to it.
+func (p *Plugin) comparePlans(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+
+	for _, variant := range planVariants(driverName) {
+		plan := PlanResult{Plan: variant.Name}
+		start := time.Now()
+
+		tx, err := opts.beginTx(db)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %v", err)
+		}
+
+		if err := runPlanVariant(tx, variant, opts, result, &plan); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				p.API.LogError("Failed to rollback transaction", "error", rbErr)
+			}
+			return err
+		}
+
+		// Nothing was written, so rolling back only discards the planner settings.
+		if err := tx.Rollback(); err != nil {
+			return fmt.Errorf("failed to end transaction: %v", err)
+		}
+
+		plan.TimeSeconds = time.Since(start).Seconds()
+		result.Plans = append(result.Plans, plan)
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
+
+// runPlanVariant applies the variant's settings within tx and runs its lookups, counting the rows
+// and bytes read on result and plan.
+func runPlanVariant(tx *sql.Tx, variant planVariant, opts testOptions, result *TestResult, plan *PlanResult) error {
+	for _, setting := range variant.Settings {
+		if _, err := tx.Exec(opts.tagSQL(setting)); err != nil {
+			return fmt.Errorf("failed to apply %q: %v", setting, err)
+		}
+	}
+
+	querySQL := opts.tagSQL(variant.Query)
+	for k := 0; k < planQueries; k++ {
+		value := testData(k*opts.Records/planQueries, opts.RowBytes)
+
+		rows, err := tx.Query(querySQL, value, opts.PageSize)
+		if err != nil {
+			return fmt.Errorf("failed to run %s lookup: %v", variant.Name, err)
+		}
+
+		for rows.Next() {
+			var id int
+			var data string
+			if err := rows.Scan(&id, &data); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan %s lookup: %v", variant.Name, err)
+			}
+			plan.RowsReturned++
+			result.RecordsQueried++
+			result.BytesQueried += int64(len(data))
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read %s lookup: %v", variant.Name, err)
+		}
+		rows.Close()
+
+		plan.Queries++
+	}
+
+	return nil
+}
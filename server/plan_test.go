@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanVariants(t *testing.T) {
+	postgres := planVariants("postgres")
+	require.Len(t, postgres, 2)
+	assert.Equal(t, planIndex, postgres[0].Name)
+	assert.Equal(t, []string{"SET LOCAL enable_seqscan = off"}, postgres[0].Settings)
+	assert.Equal(t, planSeqScan, postgres[1].Name)
+	assert.Contains(t, postgres[1].Settings, "SET LOCAL enable_indexscan = off")
+	assert.Equal(t, postgres[0].Query, postgres[1].Query)
+
+	mysql := planVariants("mysql")
+	require.Len(t, mysql, 2)
+	assert.Empty(t, mysql[0].Settings)
+	assert.Contains(t, mysql[0].Query, "FORCE INDEX (idx_plugin_test_rpc_data)")
+	assert.Contains(t, mysql[1].Query, "IGNORE INDEX (idx_plugin_test_rpc_data)")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// playbooksPluginID is the plugin ID of Mattermost Playbooks.
+const playbooksPluginID = "playbooks"
+
+// playbookRunRequest is the payload for starting a Playbooks run.
+type playbookRunRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	OwnerUserID string `json:"owner_user_id"`
+	TeamID      string `json:"team_id"`
+	PlaybookID  string `json:"playbook_id"`
+}
+
+// startRegressionPlaybook starts a run of the configured playbook for alert, attaching the
+// benchmark details to the run summary. It does nothing unless a playbook is configured.
+func (p *Plugin) startRegressionPlaybook(alert regressionAlert) error {
+	config := p.getConfiguration()
+	playbookID := strings.TrimSpace(config.PlaybookID)
+	if playbookID == "" {
+		return nil
+	}
+
+	details, err := json.MarshalIndent(alert.Result, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode run details")
+	}
+
+	name := fmt.Sprintf("Database benchmark regression: %s %s", alert.Result.ConnType, alert.Result.Mode)
+	if alert.Result.Label != "" {
+		name += " (" + alert.Result.Label + ")"
+	}
+
+	run := playbookRunRequest{
+		Name:        name,
+		Description: alert.Reason + "\n\n```json\n" + string(details) + "\n```",
+		OwnerUserID: p.botUserID,
+		TeamID:      strings.TrimSpace(config.PlaybooksTeamID),
+		PlaybookID:  playbookID,
+	}
+	if err := p.pluginRequest(playbooksPluginID, http.MethodPost, "/api/v0/runs", run, nil); err != nil {
+		return errors.Wrap(err, "failed to start playbook run")
+	}
+
+	return nil
+}
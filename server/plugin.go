@@ -1,7 +1,9 @@
 package main
 
 import (
+	"database/sql"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,8 +29,44 @@ type Plugin struct {
 	// commandClient is the client used to register and execute slash commands.
 	commandClient command.Command
 
+	// botUserID is the user the plugin acts as when talking to other plugins and posting.
+	botUserID string
+
 	backgroundJob *cluster.Job
 
+	// nodeInfo identifies the cluster node this instance of the plugin runs on.
+	nodeInfo NodeInfo
+
+	// clusterLock synchronizes access to clusterReplies.
+	clusterLock sync.Mutex
+
+	// clusterReplies delivers the replies of other nodes to the requests awaiting them.
+	clusterReplies map[string]chan clusterMessage
+
+	// runQueueLock synchronizes access to runQueue and runQueueDraining.
+	runQueueLock sync.Mutex
+
+	// runQueue holds the benchmarks queued on this node, in the order they run.
+	runQueue []queuedRun
+
+	// runQueueDraining is set while a goroutine is running the queued benchmarks.
+	runQueueDraining bool
+
+	// jobsLock synchronizes access to jobs.
+	jobsLock sync.Mutex
+
+	// jobs holds the benchmark runs in progress on this node by run ID.
+	jobs map[string]*runningJob
+
+	// recoveryTimer recovers the jobs of crashed nodes once their run locks have expired.
+	recoveryTimer *time.Timer
+
+	// slowQueryDBLock synchronizes access to slowQueryDB.
+	slowQueryDBLock sync.Mutex
+
+	// slowQueryDB is the RPC connection pool benchmarks run over while slow queries are logged.
+	slowQueryDB *sql.DB
+
 	// configurationLock synchronizes access to the configuration.
 	configurationLock sync.RWMutex
 
@@ -44,6 +82,21 @@ func (p *Plugin) OnActivate() error {
 	p.kvstore = kvstore.NewKVStore(p.client)
 
 	p.commandClient = command.NewCommandHandler(p.client)
+	if err := p.registerDBTestCommand(); err != nil {
+		p.API.LogError("Failed to register command", "trigger", dbtestCommandTrigger, "error", err)
+	}
+
+	p.nodeInfo = newNodeInfo()
+
+	botUserID, err := p.client.Bot.EnsureBot(&model.Bot{
+		Username:    "rpc-db-benchmark",
+		DisplayName: "RPC Database Benchmark",
+		Description: "Reports results from the RPC Database Test Plugin.",
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to ensure bot")
+	}
+	p.botUserID = botUserID
 
 	job, err := cluster.Schedule(
 		p.API,
@@ -57,21 +110,45 @@ func (p *Plugin) OnActivate() error {
 
 	p.backgroundJob = job
 
+	p.startAutorun()
+
+	// Jobs a crashed node was running are only recovered once its run lock expires.
+	go p.recoverJobs()
+	p.recoveryTimer = time.AfterFunc(runLockExpiry, p.recoverJobs)
+
 	return nil
 }
 
 // OnDeactivate is invoked when the plugin is deactivated.
 func (p *Plugin) OnDeactivate() error {
+	if p.recoveryTimer != nil {
+		p.recoveryTimer.Stop()
+	}
+	p.interruptLocalJobs()
+
 	if p.backgroundJob != nil {
 		if err := p.backgroundJob.Close(); err != nil {
 			p.API.LogError("Failed to close background job", "err", err)
 		}
 	}
+
+	p.slowQueryDBLock.Lock()
+	defer p.slowQueryDBLock.Unlock()
+	if p.slowQueryDB != nil {
+		if err := p.slowQueryDB.Close(); err != nil {
+			p.API.LogError("Failed to close slow query connection pool", "err", err)
+		}
+		p.slowQueryDB = nil
+	}
 	return nil
 }
 
 // This will execute the commands that were registered in the NewCommandHandler function.
 func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
+	if fields := strings.Fields(args.Command); len(fields) > 0 && strings.TrimPrefix(fields[0], "/") == dbtestCommandTrigger {
+		return p.executeDBTestCommand(args), nil
+	}
+
 	response, err := p.commandClient.Handle(args)
 	if err != nil {
 		return nil, model.NewAppError("ExecuteCommand", "plugin.command.execute_command.app_error", nil, err.Error(), http.StatusInternalServerError)
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// defaultPostsRecords is the number of posts read per connection type when records is not given.
+const defaultPostsRecords = 10000
+
+// PostsComparison reports the existing Posts table read over both connection types.
+type PostsComparison struct {
+	ChannelID string       `json:"channel_id,omitempty"`
+	Label     string       `json:"label,omitempty"`
+	Results   []TestResult `json:"results"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// TestDatabasePosts pages through the existing Posts table, newest first, over both connection
+// types, giving numbers against production-shaped data. It never writes.
+func (p *Plugin) TestDatabasePosts(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, PostsComparison{Error: err.Error()})
+		return
+	}
+
+	channelID := r.URL.Query().Get("channel_id")
+	if channelID != "" && !model.IsValidId(channelID) {
+		respondWithJSON(w, http.StatusBadRequest, PostsComparison{Error: "invalid channel_id"})
+		return
+	}
+
+	records := defaultPostsRecords
+	if r.URL.Query().Get("records") != "" {
+		records = opts.Records
+	}
+
+	comparison := PostsComparison{
+		ChannelID: channelID,
+		Label:     opts.Label,
+	}
+
+	rpcResult := TestResult{ConnType: "rpc", Label: opts.Label, PageSize: opts.PageSize}
+	if db, err := p.rpcDB(); err != nil {
+		rpcResult.Error = fmt.Sprintf("Failed to get database: %v", err)
+	} else if err := readPosts(db, p.client.Store.DriverName(), opts, channelID, records, &rpcResult); err != nil {
+		rpcResult.Error = err.Error()
+	}
+	comparison.Results = append(comparison.Results, rpcResult)
+
+	rawResult := TestResult{ConnType: "raw", Label: opts.Label, PageSize: opts.PageSize}
+	if db, driverName, err := p.openRawConnection(opts); err != nil {
+		rawResult.Error = fmt.Sprintf("Failed to connect to database: %v", err)
+	} else {
+		pool := parsePoolSettings(r)
+		pool.apply(db)
+		if err := readPosts(db, driverName, opts, channelID, records, &rawResult); err != nil {
+			rawResult.Error = err.Error()
+		}
+		pool.report(db, &rawResult)
+		db.Close()
+	}
+	comparison.Results = append(comparison.Results, rawResult)
+
+	respondWithJSON(w, http.StatusOK, comparison)
+}
+
+// postsPageSQL returns the keyset-paginated read of undeleted posts, optionally restricted to one
+// channel. Keyset pagination keeps every page an index range scan however deep the run reads,
+// unlike OFFSET on a table that may hold millions of rows.
+func postsPageSQL(channelID string) string {
+	query := "SELECT Id, ChannelId, UserId, CreateAt, Message, Props FROM Posts WHERE DeleteAt = 0"
+	if channelID != "" {
+		query += " AND ChannelId = ?"
+	}
+
+	return query + " AND (CreateAt < ? OR (CreateAt = ? AND Id < ?)) ORDER BY CreateAt DESC, Id DESC LIMIT ?"
+}
+
+// readPosts reads up to records undeleted posts, newest first, in pages of opts.PageSize,
+// recording the total time and the posts and bytes read on result.
+func readPosts(db *sql.DB, driverName string, opts testOptions, channelID string, records int, result *TestResult) error {
+	start := time.Now()
+
+	querySQL := opts.tagSQL(rebind(driverName, postsPageSQL(channelID)))
+	cursorCreateAt := int64(math.MaxInt64)
+	cursorID := ""
+	for result.RecordsQueried < records {
+		limit := min(opts.PageSize, records-result.RecordsQueried)
+
+		args := []any{cursorCreateAt, cursorCreateAt, cursorID, limit}
+		if channelID != "" {
+			args = append([]any{channelID}, args...)
+		}
+
+		rows, err := db.Query(querySQL, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query posts after %q: %v", cursorID, err)
+		}
+
+		read := 0
+		for rows.Next() {
+			var id, postChannelID, userID, message string
+			var createAt int64
+			var props []byte
+			if err := rows.Scan(&id, &postChannelID, &userID, &createAt, &message, &props); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan post: %v", err)
+			}
+			read++
+			result.RecordsQueried++
+			result.BytesQueried += int64(len(id) + len(postChannelID) + len(userID) + len(message) + len(props))
+			cursorCreateAt, cursorID = createAt, id
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read posts after %q: %v", cursorID, err)
+		}
+		rows.Close()
+
+		if read < limit {
+			break
+		}
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(start).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostsPageSQL(t *testing.T) {
+	all := postsPageSQL("")
+	assert.NotContains(t, all, "ChannelId = ?")
+	assert.Equal(t, 4, strings.Count(all, "?"))
+	assert.True(t, strings.HasPrefix(all, "SELECT "))
+
+	channel := postsPageSQL("channel")
+	assert.Contains(t, channel, "AND ChannelId = ? AND (CreateAt < ?")
+	assert.Equal(t, 5, strings.Count(channel, "?"))
+}
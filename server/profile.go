@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"runtime/pprof"
+
+	"github.com/gorilla/mux"
+)
+
+// startCPUProfile starts a pprof CPU profile of the plugin process, returning a function that
+// stops it and returns the profile. Only one CPU profile can be captured at a time, so it fails
+// while another is being captured, such as through the server's own profiling.
+func startCPUProfile() (func() []byte, error) {
+	var profile bytes.Buffer
+	if err := pprof.StartCPUProfile(&profile); err != nil {
+		return nil, fmt.Errorf("failed to start CPU profile: %v", err)
+	}
+
+	return func() []byte {
+		pprof.StopCPUProfile()
+		return profile.Bytes()
+	}, nil
+}
+
+// RunProfile serves the pprof profile captured during the stored run with the ID given in the
+// path, such as for go tool pprof.
+func (p *Plugin) RunProfile(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	profile, err := p.kvstore.GetProfile(id)
+	if err != nil {
+		p.API.LogError("Failed to get profile", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, TestResult{Error: err.Error()})
+		return
+	}
+	if profile == nil {
+		respondWithJSON(w, http.StatusNotFound, TestResult{Error: fmt.Sprintf("no profile was captured for run %s", id)})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "cpu-"+id+".pprof"))
+	if _, err := w.Write(profile); err != nil {
+		p.API.LogError("Failed to write profile", "error", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartCPUProfile(t *testing.T) {
+	stop, err := startCPUProfile()
+	require.NoError(t, err)
+
+	// Only one CPU profile can be captured at a time.
+	_, err = startCPUProfile()
+	assert.Error(t, err)
+
+	profile := stop()
+	assert.NotEmpty(t, profile)
+	// Profiles are gzipped protocol buffers.
+	assert.Equal(t, []byte{0x1f, 0x8b}, profile[:2])
+}
+
+func TestRunProfile(t *testing.T) {
+	store := fakeRetentionStore{KVStore: &fakeRunLockStore{}, runs: map[string]kvstore.Run{}, profiles: map[string][]byte{}}
+	p := Plugin{kvstore: store}
+	p.SetAPI(adminAPI())
+
+	result := TestResult{ConnType: "rpc", Profile: profileCPU, profileData: []byte("profile")}
+	p.saveRun("profiled", "rpc", url.Values{"profile": {profileCPU}}, "", &result)
+	assert.Equal(t, []byte("profile"), store.profiles["profiled"])
+	assert.Contains(t, string(store.runs["profiled"].Result), `"profile":"cpu"`)
+
+	t.Run("stored profile", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, "/api/v1/results/profiled/profile"))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="cpu-profiled.pprof"`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "profile", w.Body.String())
+	})
+
+	t.Run("run without a profile", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, "/api/v1/results/other/profile"))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// rebind rewrites the `?` placeholders in query into the `$n` form expected by Postgres. Queries
+// for other drivers are returned unchanged. Placeholders must not appear inside string literals.
+func rebind(driverName, query string) string {
+	if driverName != "postgres" {
+		return query
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(query) + 8)
+
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			builder.WriteByte('$')
+			builder.WriteString(strconv.Itoa(n))
+			continue
+		}
+		builder.WriteRune(c)
+	}
+
+	return builder.String()
+}
+
+// maxPlaceholders is the most bind parameters a single statement may carry on any supported
+// driver (SQLite allows 32766 by default, fewer than the 16-bit count of Postgres).
+const maxPlaceholders = 32766
+
+// multiRowInsert builds an INSERT into table of rows tuples of columns, with placeholders in the
+// driver's style.
+func multiRowInsert(driverName, table string, columns []string, rows int) string {
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+	var builder strings.Builder
+	builder.WriteString("INSERT INTO ")
+	builder.WriteString(table)
+	builder.WriteString(" (")
+	builder.WriteString(strings.Join(columns, ", "))
+	builder.WriteString(") VALUES ")
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(tuple)
+	}
+
+	return rebind(driverName, builder.String())
+}
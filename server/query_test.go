@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebind(t *testing.T) {
+	assert.Equal(t, "SELECT * FROM t WHERE a = ? LIMIT ?", rebind("mysql", "SELECT * FROM t WHERE a = ? LIMIT ?"))
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 LIMIT $2", rebind("postgres", "SELECT * FROM t WHERE a = ? LIMIT ?"))
+}
+
+func TestMultiRowInsert(t *testing.T) {
+	assert.Equal(t, "INSERT INTO t (a, b) VALUES (?, ?), (?, ?)", multiRowInsert("mysql", "t", []string{"a", "b"}, 2))
+	assert.Equal(t, "INSERT INTO t (a) VALUES ($1), ($2), ($3)", multiRowInsert("postgres", "t", []string{"a"}, 3))
+}
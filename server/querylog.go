@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxQueryLogQueries caps the queries recorded in a query log, so that a long run does not build
+// a log too large to store. Queries past the cap are counted but not recorded.
+const maxQueryLogQueries = 100000
+
+// QueryLogEntry is a line of a query log: a statement run by the benchmark.
+type QueryLogEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	SQL        string    `json:"sql"`
+	DurationMs float64   `json:"duration_ms"`
+
+	// Rows is the number of rows a query returned, or the number of rows a statement affected.
+	Rows  int64  `json:"rows"`
+	Error string `json:"error,omitempty"`
+}
+
+// QueryLogSummary reports the query log recorded during a run, which is stored with it.
+type QueryLogSummary struct {
+	Queries int `json:"queries"`
+
+	// Dropped is the number of queries left out once the log held maxQueryLogQueries.
+	Dropped int `json:"dropped,omitempty"`
+}
+
+// queryLog records the statements run by a benchmark as gzipped NDJSON, one QueryLogEntry per
+// line. It is safe for concurrent use by the workers of a run.
+type queryLog struct {
+	mu      sync.Mutex
+	data    bytes.Buffer
+	gzip    *gzip.Writer
+	encoder *json.Encoder
+	summary QueryLogSummary
+}
+
+// newQueryLog returns an empty query log.
+func newQueryLog() *queryLog {
+	l := &queryLog{}
+	l.gzip = gzip.NewWriter(&l.data)
+	l.encoder = json.NewEncoder(l.gzip)
+	return l
+}
+
+// record appends query to the log.
+func (l *queryLog) record(query timedQuery) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.summary.Queries >= maxQueryLogQueries {
+		l.summary.Dropped++
+		return
+	}
+
+	entry := QueryLogEntry{
+		Timestamp:  query.Start.UTC(),
+		SQL:        query.Query,
+		DurationMs: millis(query.Elapsed),
+		Rows:       query.Rows,
+	}
+	if query.Err != nil {
+		entry.Error = query.Err.Error()
+	}
+	// Encoding into a buffer only fails on values that cannot be encoded, which entries never hold.
+	_ = l.encoder.Encode(entry)
+	l.summary.Queries++
+}
+
+// finish closes the log, returning its summary and gzipped contents.
+func (l *queryLog) finish() (*QueryLogSummary, []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_ = l.gzip.Close()
+	summary := l.summary
+	return &summary, l.data.Bytes()
+}
+
+// RunQueryLog serves the query log recorded during the stored run with the ID given in the path,
+// as NDJSON for tools such as jq or pandas.
+func (p *Plugin) RunQueryLog(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	queryLog, err := p.kvstore.GetQueryLog(id)
+	if err != nil {
+		p.API.LogError("Failed to get query log", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, TestResult{Error: err.Error()})
+		return
+	}
+	if queryLog == nil {
+		respondWithJSON(w, http.StatusNotFound, TestResult{Error: fmt.Sprintf("no query log was recorded for run %s", id)})
+		return
+	}
+
+	entries, err := gzip.NewReader(bytes.NewReader(queryLog))
+	if err != nil {
+		p.API.LogError("Failed to read query log", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, TestResult{Error: fmt.Sprintf("invalid stored query log: %v", err)})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "queries-"+id+".ndjson"))
+	if _, err := io.Copy(w, entries); err != nil {
+		p.API.LogError("Failed to write query log", "error", err)
+	}
+}
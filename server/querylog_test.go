@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readQueryLog decodes every entry of NDJSON query log lines.
+func readQueryLog(t *testing.T, lines []byte) []QueryLogEntry {
+	var entries []QueryLogEntry
+	scanner := bufio.NewScanner(bytes.NewReader(lines))
+	for scanner.Scan() {
+		var entry QueryLogEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return entries
+}
+
+func TestQueryLog(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	log := newQueryLog()
+	log.record(timedQuery{Query: "SELECT n FROM t", Start: start, Elapsed: 1500 * time.Microsecond, Rows: 20})
+	log.record(timedQuery{Query: "UPDATE t SET n = ?", Start: start.Add(time.Second), Elapsed: time.Millisecond, Err: errors.New("deadlock")})
+
+	summary, data := log.finish()
+	assert.Equal(t, &QueryLogSummary{Queries: 2}, summary)
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	var lines bytes.Buffer
+	_, err = lines.ReadFrom(reader)
+	require.NoError(t, err)
+
+	assert.Equal(t, []QueryLogEntry{
+		{Timestamp: start, SQL: "SELECT n FROM t", DurationMs: 1.5, Rows: 20},
+		{Timestamp: start.Add(time.Second), SQL: "UPDATE t SET n = ?", DurationMs: 1, Error: "deadlock"},
+	}, readQueryLog(t, lines.Bytes()))
+}
+
+func TestQueryLogDropsQueriesPastCap(t *testing.T) {
+	log := newQueryLog()
+	for range maxQueryLogQueries + 3 {
+		log.record(timedQuery{Query: "SELECT 1"})
+	}
+
+	summary, _ := log.finish()
+	assert.Equal(t, &QueryLogSummary{Queries: maxQueryLogQueries, Dropped: 3}, summary)
+}
+
+func TestRunQueryLog(t *testing.T) {
+	store := fakeRetentionStore{KVStore: &fakeRunLockStore{}, runs: map[string]kvstore.Run{}, queryLogs: map[string][]byte{}}
+	p := Plugin{kvstore: store}
+	p.SetAPI(adminAPI())
+
+	log := newQueryLog()
+	log.record(timedQuery{Query: "SELECT n FROM t", Start: time.Now(), Rows: 20})
+	result := TestResult{ConnType: "rpc"}
+	result.QueryLog, result.queryLogData = log.finish()
+	p.saveRun("logged", "rpc", url.Values{"query_log": {"true"}}, "", &result)
+	assert.NotEmpty(t, store.queryLogs["logged"])
+	assert.Contains(t, string(store.runs["logged"].Result), `"query_log":{"queries":1}`)
+
+	t.Run("stored query log", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, "/api/v1/results/logged/queries.ndjson"))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="queries-logged.ndjson"`, w.Header().Get("Content-Disposition"))
+		entries := readQueryLog(t, w.Body.Bytes())
+		require.Len(t, entries, 1)
+		assert.Equal(t, "SELECT n FROM t", entries[0].SQL)
+		assert.Equal(t, int64(20), entries[0].Rows)
+	})
+
+	t.Run("run without a query log", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, "/api/v1/results/other/queries.ndjson"))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// maxQueuedRuns caps the number of benchmarks queued on each node.
+	maxQueuedRuns = 10
+
+	// runQueuePollInterval is how often the next queued benchmark retries the run lock while
+	// another run holds it.
+	runQueuePollInterval = 5 * time.Second
+)
+
+// errDequeued is recorded for a queued run cancelled before it started.
+var errDequeued = errors.New("cancelled before it started")
+
+// errRunQueueFull is returned when a benchmark is queued while the queue is at its cap.
+var errRunQueueFull = fmt.Errorf("the run queue is full: at most %d benchmarks may be queued", maxQueuedRuns)
+
+// queuedRun is a benchmark request waiting for the run lock. Queued runs live in memory on the
+// node that accepted them, and are recorded as interrupted if the plugin is deactivated before
+// they run.
+type queuedRun struct {
+	RunID    string
+	NodeID   string
+	UserID   string
+	Method   string
+	URL      *url.URL
+	QueuedAt time.Time
+
+	// ResumeOf is the ID of the interrupted job this run resumes, if any.
+	ResumeOf string
+}
+
+// runQueueLength returns the number of benchmarks queued on this node.
+func (p *Plugin) runQueueLength() int {
+	p.runQueueLock.Lock()
+	defer p.runQueueLock.Unlock()
+
+	return len(p.runQueue)
+}
+
+// dequeueRun removes the benchmark queued as runID from this node's queue, returning it and
+// whether it was queued.
+func (p *Plugin) dequeueRun(runID string) (queuedRun, bool) {
+	p.runQueueLock.Lock()
+	defer p.runQueueLock.Unlock()
+
+	for i, run := range p.runQueue {
+		if run.RunID == runID {
+			p.runQueue = slices.Delete(p.runQueue, i, i+1)
+			return run, true
+		}
+	}
+	return queuedRun{}, false
+}
+
+// job returns the record of the queued run with status, finished with jobError if failed.
+func (r queuedRun) job(status, jobError string) kvstore.Job {
+	job := kvstore.Job{
+		ID:       r.RunID,
+		NodeID:   r.NodeID,
+		Method:   r.Method,
+		Endpoint: r.URL.Path,
+		Params:   redactQuery(r.URL.RawQuery),
+		UserID:   r.UserID,
+		Status:   status,
+		Error:    jobError,
+		QueuedAt: r.QueuedAt.UnixMilli(),
+		ResumeOf: r.ResumeOf,
+	}
+	if jobFinished(status) {
+		job.FinishedAt = time.Now().UnixMilli()
+	}
+	return job
+}
+
+// enqueueRun queues the benchmark r to run as runID once the runs ahead of it have completed,
+// returning its position in the queue, counting from one.
+func (p *Plugin) enqueueRun(runID string, r *http.Request) (int, error) {
+	query := r.URL.Query()
+	query.Del("queue")
+	return p.pushQueuedRun(queuedRun{
+		RunID:    runID,
+		UserID:   r.Header.Get("Mattermost-User-ID"),
+		Method:   r.Method,
+		URL:      &url.URL{Path: r.URL.Path, RawQuery: query.Encode()},
+		QueuedAt: time.Now(),
+	})
+}
+
+// pushQueuedRun appends run to this node's queue, records it as queued and starts draining the
+// queue if it is not already, returning its position in the queue, counting from one.
+func (p *Plugin) pushQueuedRun(run queuedRun) (int, error) {
+	run.NodeID = p.nodeInfo.ID
+
+	p.runQueueLock.Lock()
+	if len(p.runQueue) >= maxQueuedRuns {
+		p.runQueueLock.Unlock()
+		return 0, errRunQueueFull
+	}
+	p.runQueue = append(p.runQueue, run)
+	position := len(p.runQueue)
+	p.runQueueLock.Unlock()
+
+	p.saveJob(run.job(jobQueued, ""))
+
+	p.runQueueLock.Lock()
+	if !p.runQueueDraining {
+		p.runQueueDraining = true
+		go p.drainRunQueue()
+	}
+	p.runQueueLock.Unlock()
+
+	return position, nil
+}
+
+// drainRunQueue runs the queued benchmarks one at a time in order, each once it can take the run
+// lock, notifying each submitter as their run completes. It returns once the queue is empty.
+func (p *Plugin) drainRunQueue() {
+	for {
+		p.runQueueLock.Lock()
+		if len(p.runQueue) == 0 {
+			p.runQueueDraining = false
+			p.runQueueLock.Unlock()
+			return
+		}
+		run := p.runQueue[0]
+		p.runQueueLock.Unlock()
+
+		ctx, end, err := p.beginRun(context.Background(), run.job(jobRunning, ""))
+		var inProgress *runInProgressError
+		if errors.As(err, &inProgress) {
+			time.Sleep(runQueuePollInterval)
+			continue
+		}
+
+		if err != nil {
+			p.dequeueRun(run.RunID)
+			p.API.LogError("Failed to acquire run lock for queued run", "run_id", run.RunID, "error", err)
+			p.saveJob(run.job(jobFailed, err.Error()))
+			p.notifyQueuedRun(run, http.StatusInternalServerError, nil, err)
+			continue
+		}
+
+		// The run may have been cancelled while waiting for the lock.
+		if _, ok := p.dequeueRun(run.RunID); !ok {
+			end(errDequeued)
+			continue
+		}
+
+		status, body := p.serveQueuedRun(ctx, run)
+		runErr := responseError(status, body)
+		end(runErr)
+		p.notifyQueuedRun(run, status, body, runErr)
+	}
+}
+
+// serveQueuedRun serves the queued benchmark through the plugin's routes as its submitter, in
+// the context of the job begun for it, and returns the response status and body.
+func (p *Plugin) serveQueuedRun(ctx context.Context, run queuedRun) (int, []byte) {
+	r := (&http.Request{Method: run.Method, URL: run.URL, Header: http.Header{}}).WithContext(ctx)
+	r.Header.Set("Mattermost-User-ID", run.UserID)
+
+	response := &queuedRunResponse{header: http.Header{}, status: http.StatusOK}
+	p.ServeHTTP(nil, response, r)
+
+	return response.status, response.body.Bytes()
+}
+
+// notifyQueuedRun sends the submitter of a queued benchmark a direct message from the plugin's
+// bot once it has run, with the response status and any error. The result of a successful run
+// of a test endpoint or replay, which respond with body, is summarized in the message.
+func (p *Plugin) notifyQueuedRun(run queuedRun, status int, body []byte, runErr error) {
+	target := url.URL{Path: run.URL.Path, RawQuery: redactQuery(run.URL.RawQuery)}
+	message := fmt.Sprintf("Your queued benchmark `%s %s` (run `%s`) finished with status %d, after waiting %s.",
+		run.Method, &target, run.RunID, status, time.Since(run.QueuedAt).Round(time.Second))
+	post := &model.Post{Message: message}
+	if runErr != nil {
+		post.Message += "\nError: " + runErr.Error()
+	} else if respondsWithTestResult(run.URL.Path) {
+		var result TestResult
+		if err := json.Unmarshal(body, &result); err == nil {
+			post = p.resultPost(message, result)
+		}
+	}
+
+	if err := p.client.Post.DM(p.botUserID, run.UserID, post); err != nil {
+		p.API.LogError("Failed to notify queued run submitter", "run_id", run.RunID, "user_id", run.UserID, "error", err)
+	}
+}
+
+// respondsWithTestResult reports whether the benchmark at path responds with a single result.
+func respondsWithTestResult(path string) bool {
+	return path == "/api/v1/test" || path == "/api/v1/test_raw" ||
+		(strings.HasPrefix(path, "/api/v1/runs/") && strings.HasSuffix(path, "/replay"))
+}
+
+// queuedRunResponse captures the response to a queued benchmark, which has no client waiting
+// for it.
+type queuedRunResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *queuedRunResponse) Header() http.Header {
+	return r.header
+}
+
+func (r *queuedRunResponse) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+func (r *queuedRunResponse) WriteHeader(status int) {
+	r.status = status
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	// quickBudget bounds the whole quick check, leaving headroom below ten seconds.
+	quickBudget = 8 * time.Second
+
+	// quickRecords is the number of rows the quick check needs in plugin_test_rpc, small
+	// enough to seed in well under a second when missing.
+	quickRecords = 1000
+
+	// quickLookups is the number of point lookups timed per connection type.
+	quickLookups = 300
+
+	// quickTransactionLookups is the number of point lookups within the timed transaction.
+	quickTransactionLookups = 10
+)
+
+// QuickConnResult reports the quick check on one connection type.
+type QuickConnResult struct {
+	ConnType          string        `json:"conn_type"`
+	Lookups           int           `json:"lookups"`
+	Latency           LatencyMillis `json:"latency"`
+	TransactionMillis float64       `json:"transaction_ms"`
+	Error             string        `json:"error,omitempty"`
+}
+
+// QuickResult reports a coarse estimate of the RPC overhead.
+type QuickResult struct {
+	Label string `json:"label,omitempty"`
+
+	// OverheadRatio is the RPC average lookup latency divided by the raw one.
+	OverheadRatio float64 `json:"overhead_ratio,omitempty"`
+
+	// OverheadMillisPerQuery is the average lookup latency the RPC layer adds.
+	OverheadMillisPerQuery float64 `json:"overhead_ms_per_query,omitempty"`
+
+	ElapsedSeconds float64           `json:"elapsed_seconds"`
+	Results        []QuickConnResult `json:"results"`
+	Error          string            `json:"error,omitempty"`
+}
+
+// QuickCheck runs a tiny, time-boxed workload over both connection types and estimates the RPC
+// overhead in a few seconds, for use when there is no time to run the full suite.
+func (p *Plugin) QuickCheck(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, QuickResult{Error: err.Error()})
+		return
+	}
+	opts.Records = quickRecords
+
+	ctx, cancel := context.WithTimeout(r.Context(), quickBudget)
+	defer cancel()
+
+	result := QuickResult{
+		Label:   opts.Label,
+		Results: []QuickConnResult{},
+	}
+
+	rawDB, rawDriverName, err := p.openRawConnection(opts)
+	if err != nil {
+		p.API.LogError("Failed to connect to database directly", "error", err)
+		result.Error = fmt.Sprintf("Failed to connect to database: %v", err)
+		respondWithJSON(w, http.StatusInternalServerError, result)
+		return
+	}
+	defer rawDB.Close()
+
+	// Reuse whatever is already seeded, only topping the table up if it is nearly empty and
+	// writes are allowed.
+	if p.readOnly() {
+		p.API.LogDebug("Skipping quick check seeding in read-only mode")
+	} else if _, _, err := p.seedTestTable(rawDB, rawDriverName, opts); err != nil {
+		p.API.LogError("Failed to seed quick check", "error", err)
+		result.Error = err.Error()
+		respondWithJSON(w, http.StatusInternalServerError, result)
+		return
+	}
+
+	rpcResult := QuickConnResult{ConnType: "rpc"}
+	if db, err := p.rpcDB(); err != nil {
+		rpcResult.Error = fmt.Sprintf("Failed to get database: %v", err)
+	} else if err := quickCheckDB(ctx, db, p.client.Store.DriverName(), opts, &rpcResult); err != nil {
+		rpcResult.Error = err.Error()
+	}
+
+	rawResult := QuickConnResult{ConnType: "raw"}
+	if err := quickCheckDB(ctx, rawDB, rawDriverName, opts, &rawResult); err != nil {
+		rawResult.Error = err.Error()
+	}
+
+	result.Results = append(result.Results, rpcResult, rawResult)
+	if rpcResult.Error == "" && rawResult.Error == "" && rawResult.Latency.Avg > 0 {
+		result.OverheadRatio = rpcResult.Latency.Avg / rawResult.Latency.Avg
+		result.OverheadMillisPerQuery = rpcResult.Latency.Avg - rawResult.Latency.Avg
+	}
+	result.ElapsedSeconds = time.Since(start).Seconds()
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// quickCheckDB times quickLookups point lookups and one short transaction on db, stopping early
+// if ctx expires, and records the outcome on result.
+func quickCheckDB(ctx context.Context, db *sql.DB, driverName string, opts testOptions, result *QuickConnResult) error {
+	var firstID int
+	if err := db.QueryRowContext(ctx, opts.tagSQL("SELECT COALESCE(MIN(id), 0) FROM plugin_test_rpc")).Scan(&firstID); err != nil {
+		return fmt.Errorf("failed to find first id: %v", err)
+	}
+
+	querySQL := opts.tagSQL(rebind(driverName, pointLookupSQL))
+	random := rand.New(rand.NewSource(quickRecords))
+	durations := make([]time.Duration, 0, quickLookups)
+
+	lookup := func(query func(ctx context.Context, query string, args ...any) *sql.Row) error {
+		var id int
+		var data string
+		err := query(ctx, querySQL, firstID+random.Intn(opts.Records)).Scan(&id, &data)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	for i := 0; i < quickLookups; i++ {
+		start := time.Now()
+		if err := lookup(db.QueryRowContext); err != nil {
+			return fmt.Errorf("failed to look up row: %v", err)
+		}
+		durations = append(durations, time.Since(start))
+	}
+	result.Lookups = len(durations)
+	result.Latency = summarizeLatencies(durations).millis()
+
+	start := time.Now()
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	for i := 0; i < quickTransactionLookups; i++ {
+		if err := lookup(tx.QueryRowContext); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to look up row in transaction: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	result.TransactionMillis = millis(time.Since(start))
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+)
+
+// errRawModeDisabled is returned for any raw connection run while the EnableRawMode setting is
+// off.
+var errRawModeDisabled = errors.New("raw mode is disabled: enable the plugin's EnableRawMode setting to benchmark raw connections")
+
+// rawModeEnabled reports whether the EnableRawMode setting allows raw connection runs, which read
+// the database credentials from the unsanitized server configuration.
+func (p *Plugin) rawModeEnabled() bool {
+	return p.getConfiguration().EnableRawMode
+}
+
+// checkRawMode refuses a raw connection unless raw mode is enabled, and records an audit entry
+// for every one it allows, attributed to the admin who requested the run, or to the system for
+// runs started by the plugin itself, such as autoruns and scheduled benchmarks.
+func (p *Plugin) checkRawMode(opts testOptions) error {
+	if !p.rawModeEnabled() {
+		return errRawModeDisabled
+	}
+
+	userID := opts.userID
+	if userID == "" {
+		userID = "system"
+	}
+	p.API.LogInfo("Audit: raw database mode used",
+		"user_id", userID,
+		"mode", opts.Mode,
+		"phase", opts.Phase,
+		"label", opts.Label,
+		"alternate_dsn", opts.DSN != "",
+		"dsn_options", len(opts.DSNOptions) > 0,
+	)
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRawMode(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		p := Plugin{}
+		p.setConfiguration(&configuration{})
+
+		assert.ErrorIs(t, p.checkRawMode(testOptions{userID: "admin"}), errRawModeDisabled)
+
+		_, err := p.runTest("raw", httptest.NewRequest(http.MethodGet, "/api/v1/test_raw", nil))
+		assert.ErrorIs(t, err, errRawModeDisabled)
+	})
+
+	t.Run("enabled runs are audited", func(t *testing.T) {
+		api := &plugintest.API{}
+		api.On("LogInfo", "Audit: raw database mode used",
+			"user_id", "admin", "mode", modeScan, "phase", phaseQuery, "label", "nightly",
+			"alternate_dsn", false, "dsn_options", true).Return().Once()
+		api.On("LogInfo", "Audit: raw database mode used",
+			"user_id", "system", "mode", modeScan, "phase", phaseAll, "label", "",
+			"alternate_dsn", true, "dsn_options", false).Return().Once()
+		defer api.AssertExpectations(t)
+
+		p := Plugin{}
+		p.SetAPI(api)
+		p.setConfiguration(&configuration{EnableRawMode: true})
+
+		assert.NoError(t, p.checkRawMode(testOptions{userID: "admin", Mode: modeScan, Phase: phaseQuery, Label: "nightly", DSNOptions: map[string]string{"connect_timeout": "5"}}))
+		assert.NoError(t, p.checkRawMode(testOptions{Mode: modeScan, Phase: phaseAll, DSN: "postgres://replica/mattermost"}))
+	})
+}
+
+func TestOpenRawConnectionRequiresRawMode(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetUnsanitizedConfig").Return(nil)
+	api.On("LogInfo", "Audit: raw database mode used",
+		"user_id", "admin", "mode", modeScan, "phase", phaseAll, "label", "",
+		"alternate_dsn", false, "dsn_options", false).Return()
+
+	p := Plugin{}
+	p.SetAPI(api)
+
+	opts, err := parseTestOptions(adminRequest(http.MethodGet, "/api/v1/ping_db"))
+	require.NoError(t, err)
+
+	// Every benchmark opening a raw connection is refused, such as the raw legs of comparisons
+	// and the background noise of RPC runs, without reading the credentials.
+	p.setConfiguration(&configuration{})
+	_, _, err = p.openRawConnection(opts)
+	assert.ErrorIs(t, err, errRawModeDisabled)
+	_, err = p.startNoise(opts)
+	assert.ErrorIs(t, err, errRawModeDisabled)
+	api.AssertNotCalled(t, "GetUnsanitizedConfig")
+	api.AssertNotCalled(t, "LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// Once enabled, the connection is audited as the admin who requested it.
+	p.setConfiguration(&configuration{EnableRawMode: true})
+	_, _, err = p.openRawConnection(opts)
+	assert.Error(t, err)
+	api.AssertCalled(t, "GetUnsanitizedConfig")
+	api.AssertNumberOfCalls(t, "LogInfo", 1)
+}
+
+func TestDatabaseRawRequiresRawMode(t *testing.T) {
+	api := adminAPI()
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	api.On("GetUnsanitizedConfig").Return(nil)
+	api.On("LogError", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	p := Plugin{kvstore: &fakeRunLockStore{}}
+	p.SetAPI(api)
+
+	serve := func() (int, TestResult) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, "/api/v1/test_raw?phase=query"))
+
+		var result TestResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return w.Code, result
+	}
+
+	p.setConfiguration(&configuration{})
+	code, result := serve()
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, errRawModeDisabled.Error(), result.Error)
+	api.AssertNotCalled(t, "GetUnsanitizedConfig")
+
+	// Once enabled, the run is audited and goes on to read the server configuration.
+	p.setConfiguration(&configuration{EnableRawMode: true})
+	code, _ = serve()
+	assert.Equal(t, http.StatusInternalServerError, code)
+	api.AssertCalled(t, "GetUnsanitizedConfig")
+	api.AssertNumberOfCalls(t, "LogInfo", 1)
+}
+
+func TestSynthesizedRawRunsAreAuditedAsRequester(t *testing.T) {
+	api := adminAPI()
+	api.On("GetUnsanitizedConfig").Return(nil)
+	api.On("LogInfo", "Audit: raw database mode used",
+		"user_id", "admin", "mode", modeScan, "phase", phaseAll, "label", "",
+		"alternate_dsn", false, "dsn_options", false).Return()
+	api.On("LogError", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	p := Plugin{kvstore: fakeRunStore{KVStore: &fakeRunLockStore{}, runs: map[string]kvstore.Run{
+		"raw": {ID: "raw", ConnType: "raw", GeneratorVersion: datasetGeneratorVersion},
+	}}}
+	p.SetAPI(api)
+	p.setConfiguration(&configuration{EnableRawMode: true})
+
+	// A cluster leg, whether run here or sent to another node.
+	results := p.runNodeBenchmark(context.Background(), "", "raw", "admin")
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Error)
+	api.AssertNumberOfCalls(t, "LogInfo", 1)
+
+	// A replay of a stored run.
+	w := httptest.NewRecorder()
+	p.ServeHTTP(nil, w, adminRequest(http.MethodPost, "/api/v1/runs/raw/replay"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	api.AssertNumberOfCalls(t, "LogInfo", 2)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+)
+
+// errReadOnlyMode is returned for any run that would issue DDL or DML while the ReadOnlyMode
+// setting is enabled.
+var errReadOnlyMode = errors.New("the plugin is in read-only mode: only phase=query runs of read-only modes without rebuild_index, noise_ops or analyze=optimize are allowed")
+
+// writes reports whether running with opts issues DDL or DML: seeding creates and fills tables
+// and indexes, rebuilding the index drops and recreates it, the noise, deadlock and row lock
+// workloads update rows, the savepoint workload inserts rows even though it rolls them back, and
+// optimizing rebuilds tables. Analyzing only refreshes statistics, so it is allowed.
+// The timeout workload needs no data, so it never writes itself.
+func (o testOptions) writes() bool {
+	switch o.Mode {
+	case modeSavepoint, modeDeadlock, modeRowLock:
+		return true
+	case modeTimeout, modeNoop:
+		return o.NoiseOps > 0
+	}
+
+	return o.Phase != phaseQuery || o.RebuildIndex || o.NoiseOps > 0 || o.Analyze == analyzeOptimize
+}
+
+// readOnly reports whether the ReadOnlyMode setting is enabled.
+func (p *Plugin) readOnly() bool {
+	return p.getConfiguration().ReadOnlyMode
+}
+
+// checkReadOnly refuses opts if they would write while the plugin is in read-only mode.
+func (p *Plugin) checkReadOnly(opts testOptions) error {
+	if p.readOnly() && opts.writes() {
+		return errReadOnlyMode
+	}
+
+	return nil
+}
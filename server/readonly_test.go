@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	query := testOptions{Phase: phaseQuery}
+	analyzing := testOptions{Phase: phaseQuery, Analyze: analyzeStatistics}
+	writing := []testOptions{
+		{Phase: phaseAll},
+		{Phase: phaseSeed},
+		{Phase: phaseQuery, RebuildIndex: true},
+		{Phase: phaseQuery, NoiseOps: 10},
+		{Phase: phaseQuery, Analyze: analyzeOptimize},
+	}
+
+	p := Plugin{}
+	p.setConfiguration(&configuration{})
+	assert.NoError(t, p.checkReadOnly(query))
+	for _, opts := range writing {
+		assert.NoError(t, p.checkReadOnly(opts))
+	}
+
+	p.setConfiguration(&configuration{ReadOnlyMode: true})
+	assert.NoError(t, p.checkReadOnly(query))
+	assert.NoError(t, p.checkReadOnly(analyzing))
+	for _, opts := range writing {
+		assert.ErrorIs(t, p.checkReadOnly(opts), errReadOnlyMode)
+	}
+}
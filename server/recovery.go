@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// errJobInterrupted is recorded for a job that was still running or queued when the plugin
+// running it stopped.
+var errJobInterrupted = errors.New("the plugin stopped before the run finished")
+
+// interruptLocalJobs records every job running or queued on this node as interrupted, and
+// cancels the running ones, so that they do not appear to run forever once the plugin stops.
+// It is called as the plugin deactivates.
+func (p *Plugin) interruptLocalJobs() {
+	p.jobsLock.Lock()
+	running := make([]*runningJob, 0, len(p.jobs))
+	for _, job := range p.jobs {
+		running = append(running, job)
+	}
+	p.jobsLock.Unlock()
+
+	for _, job := range running {
+		job.cancel()
+		if job.holderNodeID == "" {
+			p.interruptJob(job.record)
+		}
+	}
+
+	p.runQueueLock.Lock()
+	queued := p.runQueue
+	p.runQueue = nil
+	p.runQueueLock.Unlock()
+
+	for _, run := range queued {
+		p.saveJob(run.job(jobInterrupted, errJobInterrupted.Error()))
+	}
+}
+
+// interruptJob records job as interrupted unless its record has changed since it was read,
+// reporting whether it did.
+func (p *Plugin) interruptJob(job kvstore.Job) bool {
+	interrupted := job
+	interrupted.Status = jobInterrupted
+	interrupted.Error = errJobInterrupted.Error()
+	interrupted.FinishedAt = time.Now().UnixMilli()
+	return p.updateJob(job, interrupted)
+}
+
+// recoverJobs records as interrupted the jobs left running or queued by nodes that stopped
+// without finishing them, then queues again on this node the interrupted jobs requested with
+// resume=true. A running job is interrupted once its run no longer holds the run lock, which
+// expires within runLockExpiry of its node dying, and a queued job once its node no longer
+// answers. It runs as the plugin activates, once more after runLockExpiry, and hourly on a
+// single node.
+func (p *Plugin) recoverJobs() {
+	jobs, err := p.kvstore.ListJobs()
+	if err != nil {
+		p.API.LogError("Failed to list jobs to recover", "error", err)
+		return
+	}
+
+	holder, err := p.kvstore.GetRunLock()
+	if err != nil {
+		p.API.LogError("Failed to get run lock to recover jobs", "error", err)
+		return
+	}
+
+	// Other nodes are only pinged if they left jobs queued, since that takes a while.
+	var liveNodes map[string]bool
+	nodeAlive := func(nodeID string) bool {
+		if liveNodes == nil {
+			liveNodes = map[string]bool{p.nodeInfo.ID: true}
+			nodes, err := p.discoverNodes()
+			if err != nil {
+				p.API.LogError("Failed to discover cluster nodes to recover jobs", "error", err)
+				return true
+			}
+			for _, node := range nodes {
+				liveNodes[node.ID] = true
+			}
+		}
+		return liveNodes[nodeID]
+	}
+
+	for i, job := range jobs {
+		interrupted := false
+		switch job.Status {
+		case jobRunning:
+			if p.isLocalJob(job.ID) || (holder != nil && holder.RunID == job.ID) {
+				continue
+			}
+			interrupted = p.interruptJob(job)
+		case jobQueued:
+			if job.NodeID == p.nodeInfo.ID {
+				if p.isQueuedRun(job.ID) {
+					continue
+				}
+			} else if nodeAlive(job.NodeID) {
+				continue
+			}
+			interrupted = p.interruptJob(job)
+		}
+
+		if interrupted {
+			p.API.LogInfo("Recorded job interrupted by a plugin restart", "run_id", job.ID, "endpoint", job.Endpoint)
+			jobs[i].Status = jobInterrupted
+			jobs[i].Error = errJobInterrupted.Error()
+		}
+	}
+
+	for _, job := range jobs {
+		if job.Status == jobInterrupted && resumable(job) {
+			p.resumeJob(job)
+		}
+	}
+}
+
+// resumable reports whether job was requested with resume=true and may be resumed. Jobs that
+// already resume another are not resumed again, so that a benchmark that keeps bringing the
+// plugin down is not retried forever. Autorun and scheduled legs are started by the plugin itself
+// rather than by a request, and are not resumed either, nor are jobs whose params were stored
+// with a database password redacted.
+func resumable(job kvstore.Job) bool {
+	if job.ResumedAs != "" || job.ResumeOf != "" || !strings.HasPrefix(job.Endpoint, "/api/v1/") {
+		return false
+	}
+
+	query, err := url.ParseQuery(job.Params)
+	if err != nil {
+		return false
+	}
+	resume, _ := strconv.ParseBool(query.Get("resume"))
+	return resume && !hasRedactedPassword(query)
+}
+
+// resumeJob queues the interrupted job again on this node as its submitter, with the params it
+// was requested with. The interrupted job is first marked as resumed, so that nodes recovering
+// jobs at the same time resume it only once. The resumed run starts over, since the seeding
+// transaction of the interrupted run is rolled back rather than kept.
+func (p *Plugin) resumeJob(job kvstore.Job) {
+	runID := model.NewId()
+	resumed := job
+	resumed.ResumedAs = runID
+	if !p.updateJob(job, resumed) {
+		return
+	}
+
+	method := job.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	position, err := p.pushQueuedRun(queuedRun{
+		RunID:    runID,
+		UserID:   job.UserID,
+		Method:   method,
+		URL:      &url.URL{Path: job.Endpoint, RawQuery: job.Params},
+		QueuedAt: time.Now(),
+		ResumeOf: job.ID,
+	})
+	if err != nil {
+		p.API.LogError("Failed to queue resumed job", "run_id", job.ID, "error", err)
+		return
+	}
+	p.API.LogInfo("Resumed interrupted job", "run_id", job.ID, "resumed_as", runID, "position", position)
+}
+
+// isLocalJob reports whether the job runID is running on this node.
+func (p *Plugin) isLocalJob(runID string) bool {
+	p.jobsLock.Lock()
+	defer p.jobsLock.Unlock()
+
+	_, ok := p.jobs[runID]
+	return ok
+}
+
+// isQueuedRun reports whether the run runID is queued on this node.
+func (p *Plugin) isQueuedRun(runID string) bool {
+	p.runQueueLock.Lock()
+	defer p.runQueueLock.Unlock()
+
+	for _, run := range p.runQueue {
+		if run.RunID == runID {
+			return true
+		}
+	}
+	return false
+}
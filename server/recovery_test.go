@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterruptLocalJobs(t *testing.T) {
+	api := adminAPI()
+	api.On("LogDebug", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	store := &fakeRunLockStore{}
+	p := Plugin{kvstore: store, nodeInfo: NodeInfo{ID: "self"}}
+	p.SetAPI(api)
+
+	ctx, end, err := p.beginRun(context.Background(), kvstore.Job{ID: "running", Endpoint: "/api/v1/test"})
+	require.NoError(t, err)
+	p.runQueue = []queuedRun{{RunID: "queued", URL: &url.URL{Path: "/api/v1/test"}}}
+
+	p.interruptLocalJobs()
+	assert.Error(t, ctx.Err())
+	assert.Empty(t, p.runQueue)
+	assert.Equal(t, jobInterrupted, store.jobs["running"].Status)
+	assert.Equal(t, jobInterrupted, store.jobs["queued"].Status)
+	assert.Equal(t, errJobInterrupted.Error(), store.jobs["queued"].Error)
+
+	// The run stopping at its next checkpoint does not overwrite the interruption.
+	end(errRunCancelled)
+	assert.Equal(t, jobInterrupted, store.jobs["running"].Status)
+	assert.Nil(t, store.holder)
+}
+
+func TestRecoverJobs(t *testing.T) {
+	api := adminAPI()
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	store := &fakeRunLockStore{
+		holder: &kvstore.RunLock{RunID: "held"},
+		jobs: map[string]kvstore.Job{
+			"orphan":  {ID: "orphan", Status: jobRunning, Endpoint: "/api/v1/test"},
+			"held":    {ID: "held", Status: jobRunning, Endpoint: "/api/v1/test"},
+			"lost":    {ID: "lost", NodeID: "self", Status: jobQueued, Endpoint: "/api/v1/test"},
+			"waiting": {ID: "waiting", NodeID: "self", Status: jobQueued, Endpoint: "/api/v1/test"},
+			"seed": {ID: "seed", Status: jobInterrupted, Method: http.MethodGet, Endpoint: "/api/v1/test_growth",
+				Params: "label=big&resume=true", UserID: "admin"},
+			"autorun": {ID: "autorun", Status: jobInterrupted, Endpoint: "autorun:scan", Params: "resume=true"},
+			"again":   {ID: "again", Status: jobInterrupted, Endpoint: "/api/v1/test", Params: "resume=true", ResumeOf: "earlier"},
+		},
+	}
+	p := Plugin{kvstore: store, nodeInfo: NodeInfo{ID: "self"}}
+	p.SetAPI(api)
+
+	// Keep the queue from draining so that it can be inspected.
+	p.runQueueDraining = true
+	p.runQueue = []queuedRun{{RunID: "waiting", URL: &url.URL{Path: "/api/v1/test"}}}
+
+	p.recoverJobs()
+
+	assert.Equal(t, jobInterrupted, store.jobs["orphan"].Status)
+	assert.Equal(t, jobRunning, store.jobs["held"].Status)
+	assert.Equal(t, jobInterrupted, store.jobs["lost"].Status)
+	assert.Equal(t, jobQueued, store.jobs["waiting"].Status)
+	assert.Empty(t, store.jobs["autorun"].ResumedAs)
+	assert.Empty(t, store.jobs["again"].ResumedAs)
+
+	resumedAs := store.jobs["seed"].ResumedAs
+	require.NotEmpty(t, resumedAs)
+	require.Len(t, p.runQueue, 2)
+	assert.Equal(t, resumedAs, p.runQueue[1].RunID)
+	assert.Equal(t, "admin", p.runQueue[1].UserID)
+	assert.Equal(t, "/api/v1/test_growth?label=big&resume=true", p.runQueue[1].URL.String())
+	assert.Equal(t, jobQueued, store.jobs[resumedAs].Status)
+	assert.Equal(t, "seed", store.jobs[resumedAs].ResumeOf)
+
+	// A job is resumed only once.
+	p.recoverJobs()
+	assert.Len(t, p.runQueue, 2)
+}
+
+func TestRunLockRequiredInvalidResume(t *testing.T) {
+	p := Plugin{kvstore: &fakeRunLockStore{}}
+	p.SetAPI(adminAPI())
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(nil, w, adminRequest(http.MethodGet, "/api/v1/test?resume=maybe"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// defaultRegressionThreshold is used when RegressionThreshold is not positive.
+const defaultRegressionThreshold = 20
+
+// Regression compares a run against the baseline of its series.
+type Regression struct {
+	BaselineRunID    string             `json:"baseline_run_id"`
+	ThresholdPercent float64            `json:"threshold_percent"`
+	Metrics          []RegressionMetric `json:"metrics"`
+
+	// Regressed is true when any metric is slower than the baseline by more than the threshold.
+	Regressed bool `json:"regressed"`
+}
+
+// RegressionMetric compares one metric of a run against the baseline.
+type RegressionMetric struct {
+	Name     string  `json:"name"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+
+	// PercentSlower is how much slower the run was than the baseline, negative when faster.
+	// Rates are compared by the time they imply, so halving a rate is 100% slower.
+	PercentSlower float64 `json:"percent_slower"`
+	Regressed     bool    `json:"regressed"`
+}
+
+// regressionMetric extracts one metric compared against the baseline from a result.
+type regressionMetric struct {
+	Name string
+
+	// Rate is true for metrics where higher is better.
+	Rate  bool
+	Value func(result TestResult) float64
+}
+
+// regressionMetrics are the metrics compared against the baseline, when both runs report them.
+var regressionMetrics = []regressionMetric{
+	{Name: "insert_time_seconds", Value: func(r TestResult) float64 { return r.InsertTimeSeconds }},
+	{Name: "total_query_time_seconds", Value: func(r TestResult) float64 { return r.TotalQueryTimeSeconds }},
+	{Name: "query_rows_per_second", Rate: true, Value: func(r TestResult) float64 { return r.QueryRowsPerSecond }},
+	{Name: "lookup_latency_p99_ms", Value: func(r TestResult) float64 {
+		if r.LookupLatency == nil {
+			return 0
+		}
+		return r.LookupLatency.P99
+	}},
+}
+
+// compareToBaseline compares current against baseline, flagging every metric that is slower by
+// more than thresholdPercent.
+func compareToBaseline(baseline TestResult, current TestResult, thresholdPercent float64) *Regression {
+	regression := &Regression{
+		BaselineRunID:    baseline.RunID,
+		ThresholdPercent: thresholdPercent,
+		Metrics:          []RegressionMetric{},
+	}
+
+	for _, metric := range regressionMetrics {
+		baselineValue, currentValue := metric.Value(baseline), metric.Value(current)
+		if baselineValue <= 0 || currentValue <= 0 {
+			continue
+		}
+
+		percentSlower := (currentValue/baselineValue - 1) * 100
+		if metric.Rate {
+			percentSlower = (baselineValue/currentValue - 1) * 100
+		}
+
+		regressed := percentSlower > thresholdPercent
+		regression.Metrics = append(regression.Metrics, RegressionMetric{
+			Name:          metric.Name,
+			Baseline:      baselineValue,
+			Current:       currentValue,
+			PercentSlower: percentSlower,
+			Regressed:     regressed,
+		})
+		regression.Regressed = regression.Regressed || regressed
+	}
+
+	return regression
+}
+
+// baselineIgnoredParams are the params that leave the workload unchanged, so a run is compared
+// against its baseline even if they differ. The label is part of the series already.
+var baselineIgnoredParams = []string{
+	"label", "tags", "notes", "dsn", "dsn_options", "sslmode", "tls", "queue", "resume", "node_id", "profile",
+	"query_log", "keep_data",
+}
+
+// workloadMismatch returns the names of the params, other than baselineIgnoredParams, given
+// different values by the baseline run and the current one, in sorted order. Runs whose workloads
+// differ, such as in records, page_size or insert_batch, cannot be compared.
+func workloadMismatch(baseline, current url.Values) []string {
+	names := make(map[string]bool)
+	for name := range baseline {
+		names[name] = true
+	}
+	for name := range current {
+		names[name] = true
+	}
+
+	var mismatched []string
+	for name := range names {
+		if !slices.Contains(baselineIgnoredParams, name) && !slices.Equal(baseline[name], current[name]) {
+			mismatched = append(mismatched, name)
+		}
+	}
+	sort.Strings(mismatched)
+
+	return mismatched
+}
+
+// reason explains, in Markdown, which metrics regressed.
+func (r *Regression) reason() string {
+	var lines []string
+	for _, metric := range r.Metrics {
+		if metric.Regressed {
+			lines = append(lines, fmt.Sprintf("- `%s` is %.1f%% slower than baseline run `%s` (%g vs %g)",
+				metric.Name, metric.PercentSlower, r.BaselineRunID, metric.Current, metric.Baseline))
+		}
+	}
+
+	return fmt.Sprintf("Slower than the baseline by more than %g%%:\n%s", r.ThresholdPercent, strings.Join(lines, "\n"))
+}
+
+// checkAlerts compares result, run with params, against the baseline of its series, if one is
+// set and was run with the same workload, and against the configured alert thresholds, recording
+// the outcome on result. Unless neither applies, the outcome is tracked for regression alerts,
+// counting towards a streak if scheduled is set.
+func (p *Plugin) checkAlerts(result *TestResult, params url.Values, scheduled bool) {
+	config := p.getConfiguration()
+	checked := false
+	var reasons []string
+
+	series := regressionSeries(*result)
+	baseline, baselineParams := p.getBaselineResult(series)
+	switch mismatched := workloadMismatch(baselineParams, params); {
+	case baseline == nil:
+	case len(mismatched) > 0:
+		p.API.LogWarn("Skipping baseline comparison of a run with a different workload", "series", series, "params", strings.Join(mismatched, ","))
+	default:
+		threshold := float64(config.RegressionThreshold)
+		if threshold <= 0 {
+			threshold = defaultRegressionThreshold
+		}
+
+		checked = true
+		result.Regression = compareToBaseline(*baseline, *result, threshold)
+		if result.Regression.Regressed {
+			reasons = append(reasons, result.Regression.reason())
+		}
+	}
+
+	thresholds, err := parseAlertThresholds(config.AlertThresholds)
+	if err != nil {
+		p.API.LogWarn("Ignoring invalid alert thresholds", "error", err)
+	}
+	if len(thresholds) > 0 {
+		checked = true
+		for _, threshold := range thresholds {
+			if breach := threshold.check(*result); breach != nil {
+				result.ThresholdBreaches = append(result.ThresholdBreaches, *breach)
+			}
+		}
+		if len(result.ThresholdBreaches) > 0 {
+			reasons = append(reasons, thresholdReason(result.ThresholdBreaches))
+		}
+	}
+
+	if !checked {
+		return
+	}
+
+	var alert *regressionAlert
+	if len(reasons) > 0 {
+		alert = &regressionAlert{Reason: strings.Join(reasons, "\n\n"), Result: *result}
+	}
+	go p.recordRegressionCheck(*result, alert, scheduled)
+}
+
+// getBaselineResult returns the result of the baseline run of series and the params it was run
+// with, or nil if there is none.
+func (p *Plugin) getBaselineResult(series string) (*TestResult, url.Values) {
+	baseline, err := p.kvstore.GetBaseline(series)
+	if err != nil {
+		p.API.LogError("Failed to get baseline", "series", series, "error", err)
+		return nil, nil
+	}
+	if baseline == nil {
+		return nil, nil
+	}
+
+	result, err := decodeTestResult(baseline.Result)
+	if err != nil {
+		p.API.LogError("Failed to decode baseline result", "series", series, "error", err)
+		return nil, nil
+	}
+	params, err := url.ParseQuery(baseline.Params)
+	if err != nil {
+		p.API.LogError("Failed to decode baseline params", "series", series, "error", err)
+		return nil, nil
+	}
+
+	return &result, params
+}
+
+// BaselineResponse reports the baseline set by SetBaseline.
+type BaselineResponse struct {
+	Series string `json:"series,omitempty"`
+	RunID  string `json:"run_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SetBaseline makes the stored run given by run_id the baseline of its series (connection
+// type, mode and label), against which later runs of the series are compared.
+func (p *Plugin) SetBaseline(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("run_id")
+	if !model.IsValidId(id) {
+		respondWithJSON(w, http.StatusBadRequest, BaselineResponse{Error: "invalid run_id"})
+		return
+	}
+
+	run, err := p.kvstore.GetRun(id)
+	if err != nil {
+		p.API.LogError("Failed to get run", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, BaselineResponse{Error: err.Error()})
+		return
+	}
+	if run == nil {
+		respondWithJSON(w, http.StatusNotFound, BaselineResponse{Error: fmt.Sprintf("unknown run %s", id)})
+		return
+	}
+
+	result, err := decodeTestResult(run.Result)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, BaselineResponse{Error: fmt.Sprintf("invalid result stored for run %s: %v", id, err)})
+		return
+	}
+
+	baseline := kvstore.Baseline{
+		Series: regressionSeries(result),
+		RunID:  run.ID,
+		Params: run.Params,
+		SetAt:  time.Now().UnixMilli(),
+		Result: run.Result,
+	}
+	if err := p.kvstore.SaveBaseline(baseline); err != nil {
+		p.API.LogError("Failed to save baseline", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, BaselineResponse{Error: err.Error()})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, BaselineResponse{Series: baseline.Series, RunID: baseline.RunID})
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// reportChartWidth is the width in pixels of the longest bar of a report chart.
+const reportChartWidth = 400
+
+//go:embed templates/report.html
+var reportTemplateSource string
+
+// reportTemplate renders a stored run as a self-contained HTML page, with its charts drawn in
+// inline SVG so the page needs nothing but a browser.
+var reportTemplate = template.Must(template.New("report").Parse(reportTemplateSource))
+
+// runReport is the data the report template renders.
+type runReport struct {
+	RunID       string
+	ConnType    string
+	Params      string
+	Notes       string
+	CreatedAt   string
+	GeneratedAt string
+	Result      TestResult
+	Parameters  [][]string
+	Timings     [][]string
+	Latencies   []namedLatency
+	Charts      []reportChart
+
+	// LatencyTrend is the SVG chart of the run's page latency over the scan, if it recorded one.
+	LatencyTrend template.HTML
+
+	// Comparison compares the run against the run it is compared with, or against the baseline
+	// of its series when it was stored.
+	Comparison *Regression
+}
+
+// reportChart is a horizontal bar chart of a report.
+type reportChart struct {
+	Title  string
+	Unit   string
+	Bars   []reportBar
+	Height int
+}
+
+// reportBar is one bar of a report chart, scaled against the longest bar of its chart.
+type reportBar struct {
+	Label string
+	Value float64
+	Width float64
+	Y     int
+
+	// Highlight marks a bar that regressed.
+	Highlight bool
+}
+
+// newReportChart returns a chart of bars, scaling each against the largest value.
+func newReportChart(title, unit string, bars []reportBar) reportChart {
+	largest := 0.0
+	for _, bar := range bars {
+		largest = max(largest, bar.Value)
+	}
+
+	for i := range bars {
+		bars[i].Y = i * 24
+		if largest > 0 {
+			bars[i].Width = bars[i].Value / largest * reportChartWidth
+		}
+	}
+	return reportChart{Title: title, Unit: unit, Bars: bars, Height: len(bars) * 24}
+}
+
+// reportCharts charts the latency percentiles of result and each metric compared in comparison.
+func reportCharts(result TestResult, comparison *Regression) []reportChart {
+	var charts []reportChart
+	for _, latency := range resultLatencies(result) {
+		m := latency.Millis
+		charts = append(charts, newReportChart(latency.Name+" latency", "ms", []reportBar{
+			{Label: "min", Value: m.Min}, {Label: "avg", Value: m.Avg}, {Label: "p50", Value: m.P50},
+			{Label: "p95", Value: m.P95}, {Label: "p99", Value: m.P99}, {Label: "max", Value: m.Max},
+		}))
+	}
+
+	if h := result.LatencyHistogram; h != nil {
+		charts = append(charts, newReportChart("Latency distribution", "ms", []reportBar{
+			{Label: "p50", Value: h.P50}, {Label: "p90", Value: h.P90}, {Label: "p99", Value: h.P99},
+			{Label: "p99.9", Value: h.P999}, {Label: "p99.99", Value: h.P9999}, {Label: "max", Value: h.Max},
+		}))
+	}
+
+	if comparison != nil {
+		for _, metric := range comparison.Metrics {
+			charts = append(charts, newReportChart(metric.Name, "", []reportBar{
+				{Label: "compared run", Value: metric.Baseline},
+				{Label: "this run", Value: metric.Current, Highlight: metric.Regressed},
+			}))
+		}
+	}
+	return charts
+}
+
+// RunReport renders the stored run with the ID given in the path as a self-contained HTML report
+// of its parameters, timings and latency percentiles, with inline charts, for sharing with
+// people who would rather not read JSON. The run is compared against its baseline as it was
+// when stored, or against the stored run given by the compare query param. With download=true
+// the report is served as an attachment.
+func (p *Plugin) RunReport(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	query := r.URL.Query()
+
+	download := false
+	if value := query.Get("download"); value != "" {
+		var err error
+		if download, err = strconv.ParseBool(value); err != nil {
+			respondWithJSON(w, http.StatusBadRequest, TestResult{Error: fmt.Sprintf("invalid download %q: must be a boolean", value)})
+			return
+		}
+	}
+
+	run, result, status, err := p.getStoredResult(id)
+	if err != nil {
+		respondWithJSON(w, status, TestResult{Error: err.Error()})
+		return
+	}
+
+	comparison := result.Regression
+	if compareID := query.Get("compare"); compareID != "" {
+		_, other, status, err := p.getStoredResult(compareID)
+		if err != nil {
+			respondWithJSON(w, status, TestResult{Error: err.Error()})
+			return
+		}
+
+		threshold := float64(p.getConfiguration().RegressionThreshold)
+		if threshold <= 0 {
+			threshold = defaultRegressionThreshold
+		}
+		comparison = compareToBaseline(other, result, threshold)
+	}
+
+	report := runReport{
+		RunID:       run.ID,
+		ConnType:    run.ConnType,
+		Params:      run.Params,
+		Notes:       run.Notes,
+		CreatedAt:   time.UnixMilli(run.CreatedAt).UTC().Format(time.RFC1123),
+		GeneratedAt: time.Now().UTC().Format(time.RFC1123),
+		Result:      result,
+		Parameters:  resultParameters(result),
+		Timings:     resultTimings(result),
+		Latencies:   resultLatencies(result),
+		Charts:      reportCharts(result, comparison),
+		// The chart escapes the only text it draws from the run, its label.
+		LatencyTrend: template.HTML(latencyTrendSVG(latencyTrendTitle(result), result.LatencyTrend)),
+		Comparison:   comparison,
+	}
+
+	var page bytes.Buffer
+	if err := reportTemplate.Execute(&page, report); err != nil {
+		p.API.LogError("Failed to render report", "run_id", id, "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, TestResult{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if download {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "benchmark-"+run.ID+".html"))
+	}
+	if _, err := w.Write(page.Bytes()); err != nil {
+		p.API.LogError("Failed to write report", "error", err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunReport(t *testing.T) {
+	p := Plugin{kvstore: fakeRunStore{KVStore: &fakeRunLockStore{}, runs: map[string]kvstore.Run{
+		"current": {ID: "current", ConnType: "rpc", Params: "mode=scan", CreatedAt: 1000, Result: []byte(`{
+			"conn_type": "rpc", "mode": "scan", "label": "<script>", "total_query_time_seconds": 3,
+			"lookup_latency": {"min_ms": 1, "avg_ms": 2, "p50_ms": 2, "p95_ms": 3, "p99_ms": 4, "max_ms": 5}
+		}`)},
+		"previous": {ID: "previous", ConnType: "rpc", CreatedAt: 500, Result: []byte(`{"run_id": "previous", "conn_type": "rpc", "total_query_time_seconds": 1}`)},
+	}}}
+	p.SetAPI(adminAPI())
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, target))
+		return w
+	}
+
+	t.Run("run", func(t *testing.T) {
+		w := serve("/api/v1/results/current/report")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+
+		page := w.Body.String()
+		assert.Contains(t, page, "Run <code>current</code>")
+		assert.Contains(t, page, "&lt;script&gt;")
+		assert.NotContains(t, page, "<script>")
+		assert.Contains(t, page, "<th>Total query time</th><td class=\"number\">3.000 s</td>")
+		assert.Contains(t, page, "<h3>Lookup latency (ms)</h3>")
+		assert.Contains(t, page, "<svg")
+		assert.NotContains(t, page, "Comparison with run")
+	})
+
+	t.Run("comparison", func(t *testing.T) {
+		w := serve("/api/v1/results/current/report?compare=previous&download=true")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `attachment; filename="benchmark-current.html"`, w.Header().Get("Content-Disposition"))
+
+		page := w.Body.String()
+		assert.Contains(t, page, "Comparison with run <code>previous</code>")
+		assert.Contains(t, page, "This run regressed.")
+		assert.Contains(t, page, "200.0%")
+		assert.Contains(t, page, `class="highlight"`)
+	})
+
+	t.Run("unknown run", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve("/api/v1/results/missing/report").Code)
+		assert.Equal(t, http.StatusNotFound, serve("/api/v1/results/current/report?compare=missing").Code)
+	})
+
+	t.Run("invalid download", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve("/api/v1/results/current/report?download=maybe").Code)
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// resetTables truncates those of the workload's tables that already exist, so that seeding
+// starts from empty and every run inserts and reads the same data rather than whatever a
+// previous run left behind. Identities restart, so ids are the same from run to run.
+func resetTables(db *sql.DB, driverName string, opts testOptions) error {
+	sizes, err := measureTableSizes(db, driverName, opts)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool, len(sizes))
+	for _, size := range sizes {
+		existing[size.Table] = true
+	}
+
+	for _, table := range modeTables(opts) {
+		if !existing[table] {
+			continue
+		}
+
+		// MySQL always resets AUTO_INCREMENT on TRUNCATE. SQLite has no TRUNCATE, but assigns
+		// rowids from one again once a table is empty.
+		statement := "TRUNCATE TABLE " + table
+		if driverName == "postgres" {
+			statement += " RESTART IDENTITY"
+		} else if driverName == "sqlite" {
+			statement = "DELETE FROM " + table
+		}
+		if _, err := db.Exec(opts.tagSQL(statement)); err != nil {
+			return fmt.Errorf("failed to truncate %s: %v", table, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxRESTPerPage is the largest page the Mattermost REST API serves for channel posts.
+	maxRESTPerPage = 200
+
+	// defaultRESTRecords is the number of posts fetched per leg when records is not given.
+	defaultRESTRecords = 1000
+
+	// restRequestTimeout bounds each REST API request.
+	restRequestTimeout = 30 * time.Second
+)
+
+// RESTComparison reports the same channel's posts read over the Mattermost REST API, the plugin
+// RPC database connection, and a raw database connection.
+type RESTComparison struct {
+	ChannelID string       `json:"channel_id"`
+	Label     string       `json:"label,omitempty"`
+	Results   []TestResult `json:"results"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// TestDatabaseREST answers "should this be a plugin or an external app?" with data: it pages
+// through a channel's posts as a remote integration would, via the REST API with an access
+// token, and compares that against reading the same posts with SQL over both connection types.
+func (p *Plugin) TestDatabaseREST(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, RESTComparison{Error: err.Error()})
+		return
+	}
+
+	channelID := r.URL.Query().Get("channel_id")
+	if !model.IsValidId(channelID) {
+		respondWithJSON(w, http.StatusBadRequest, RESTComparison{Error: "a valid channel_id is required"})
+		return
+	}
+
+	records := defaultRESTRecords
+	if r.URL.Query().Get("records") != "" {
+		records = opts.Records
+	}
+	pageSize := min(opts.PageSize, maxRESTPerPage)
+
+	comparison := RESTComparison{
+		ChannelID: channelID,
+		Label:     opts.Label,
+	}
+
+	token := strings.TrimSpace(p.getConfiguration().RESTAccessToken)
+	siteURL := ""
+	if config := p.API.GetConfig(); config != nil && config.ServiceSettings.SiteURL != nil {
+		siteURL = *config.ServiceSettings.SiteURL
+	}
+
+	restResult := TestResult{ConnType: "rest", Label: opts.Label, PageSize: pageSize}
+	switch {
+	case token == "":
+		restResult.Error = "the REST Access Token setting is not configured"
+	case siteURL == "":
+		restResult.Error = "the server Site URL is not configured"
+	default:
+		client := &http.Client{Timeout: restRequestTimeout}
+		if err := fetchPostsViaREST(client, siteURL, token, channelID, pageSize, records, &restResult); err != nil {
+			restResult.Error = err.Error()
+		}
+	}
+	comparison.Results = append(comparison.Results, restResult)
+
+	rpcResult := TestResult{ConnType: "rpc", Label: opts.Label, PageSize: pageSize}
+	if db, err := p.rpcDB(); err != nil {
+		rpcResult.Error = fmt.Sprintf("Failed to get database: %v", err)
+	} else if err := fetchPostsViaSQL(db, p.client.Store.DriverName(), opts, channelID, pageSize, records, &rpcResult); err != nil {
+		rpcResult.Error = err.Error()
+	}
+	comparison.Results = append(comparison.Results, rpcResult)
+
+	rawResult := TestResult{ConnType: "raw", Label: opts.Label, PageSize: pageSize}
+	if db, driverName, err := p.openRawConnection(opts); err != nil {
+		rawResult.Error = fmt.Sprintf("Failed to connect to database: %v", err)
+	} else {
+		if err := fetchPostsViaSQL(db, driverName, opts, channelID, pageSize, records, &rawResult); err != nil {
+			rawResult.Error = err.Error()
+		}
+		db.Close()
+	}
+	comparison.Results = append(comparison.Results, rawResult)
+
+	respondWithJSON(w, http.StatusOK, comparison)
+}
+
+// fetchPostsViaREST pages through up to records posts of channelID, newest first, using the
+// REST API at siteURL, recording the end-to-end time and the posts and message bytes read.
+func fetchPostsViaREST(client *http.Client, siteURL, token, channelID string, pageSize, records int, result *TestResult) error {
+	start := time.Now()
+
+	for page := 0; result.RecordsQueried < records; page++ {
+		endpoint := fmt.Sprintf("%s/api/v4/channels/%s/posts?page=%d&per_page=%d",
+			strings.TrimSuffix(siteURL, "/"), url.PathEscape(channelID), page, pageSize)
+
+		request, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		if err != nil {
+			return errors.Wrap(err, "failed to create REST request")
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		response, err := client.Do(request)
+		if err != nil {
+			return errors.Wrapf(err, "failed to fetch page %d", page)
+		}
+
+		var postList model.PostList
+		if response.StatusCode != http.StatusOK {
+			message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+			response.Body.Close()
+			return errors.Errorf("REST API returned %d for page %d: %s", response.StatusCode, page, strings.TrimSpace(string(message)))
+		}
+		err = json.NewDecoder(response.Body).Decode(&postList)
+		response.Body.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode page %d", page)
+		}
+
+		for _, id := range postList.Order {
+			if result.RecordsQueried == records {
+				break
+			}
+			if post, ok := postList.Posts[id]; ok {
+				result.RecordsQueried++
+				result.BytesQueried += int64(len(post.Message))
+			}
+		}
+
+		if len(postList.Order) < pageSize {
+			break
+		}
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(start).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
+
+// fetchPostsViaSQL pages through up to records undeleted posts of channelID, newest first, by
+// querying the Posts table directly, mirroring what the REST API returns.
+func fetchPostsViaSQL(db *sql.DB, driverName string, opts testOptions, channelID string, pageSize, records int, result *TestResult) error {
+	start := time.Now()
+
+	querySQL := opts.tagSQL(rebind(driverName, "SELECT Id, Message FROM Posts WHERE ChannelId = ? AND DeleteAt = 0 ORDER BY CreateAt DESC LIMIT ? OFFSET ?"))
+	for offset := 0; offset < records; offset += pageSize {
+		limit := min(pageSize, records-offset)
+
+		rows, err := db.Query(querySQL, channelID, limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to query posts at offset %d: %v", offset, err)
+		}
+
+		read := 0
+		for rows.Next() {
+			var id, message string
+			if err := rows.Scan(&id, &message); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan post: %v", err)
+			}
+			read++
+			result.RecordsQueried++
+			result.BytesQueried += int64(len(message))
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read posts at offset %d: %v", offset, err)
+		}
+		rows.Close()
+
+		if read < limit {
+			break
+		}
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(start).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchPostsViaREST(t *testing.T) {
+	channelID := model.NewId()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/channels/"+channelID+"/posts", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		// Serve five posts in total, two per page.
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		postList := model.NewPostList()
+		for i := page * 2; i < min(page*2+2, 5); i++ {
+			post := &model.Post{Id: model.NewId(), Message: "hello"}
+			postList.AddPost(post)
+			postList.AddOrder(post.Id)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(postList))
+	}))
+	defer server.Close()
+
+	t.Run("reads every page", func(t *testing.T) {
+		result := TestResult{}
+		err := fetchPostsViaREST(server.Client(), server.URL+"/", "token", channelID, 2, 100, &result)
+		require.NoError(t, err)
+		assert.Equal(t, 5, result.RecordsQueried)
+		assert.Equal(t, int64(25), result.BytesQueried)
+	})
+
+	t.Run("stops at records", func(t *testing.T) {
+		result := TestResult{}
+		err := fetchPostsViaREST(server.Client(), server.URL, "token", channelID, 2, 3, &result)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.RecordsQueried)
+	})
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+)
+
+// Bounds on the page size of the results listing.
+const (
+	defaultResultsPerPage = 20
+	maxResultsPerPage     = 100
+)
+
+// Fields the results listing can be sorted by.
+const (
+	resultsSortStartedAt = "started_at"
+	resultsSortCreatedAt = "created_at"
+	resultsSortConnType  = "conn_type"
+)
+
+// ResultList is a page of stored runs.
+type ResultList struct {
+	Runs    []kvstore.Run `json:"runs"`
+	Page    int           `json:"page"`
+	PerPage int           `json:"per_page"`
+	Total   int           `json:"total"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// runStartedAt returns when run started in Unix milliseconds, or when it was stored for a run
+// stored before start times were recorded.
+func runStartedAt(run kvstore.Run) int64 {
+	if run.StartedAt > 0 {
+		return run.StartedAt
+	}
+	return run.CreatedAt
+}
+
+// runSortKey returns the value of run that sort orders it by, for the sort fields that are times.
+func runSortKey(run kvstore.Run, sortBy string) int64 {
+	if sortBy == resultsSortStartedAt {
+		return runStartedAt(run)
+	}
+	return run.CreatedAt
+}
+
+// resultFilter selects the stored runs listed by the results listing. Empty fields select every
+// run.
+type resultFilter struct {
+	ConnTypes map[string]bool
+	Modes     map[string]bool
+	Labels    map[string]bool
+
+	// Tags selects the runs given any of the tags.
+	Tags map[string]bool
+
+	// From and To bound when the runs started, From inclusively and To exclusively.
+	From time.Time
+	To   time.Time
+}
+
+// parseResultFilter parses the conn_type, mode, label and tag filters, each a comma-separated
+// list, and the from and to bounds on when runs started, each an RFC 3339 time or a date. A date
+// given as to includes the whole of that day.
+func parseResultFilter(query url.Values) (resultFilter, error) {
+	var filter resultFilter
+
+	list := func(value string) map[string]bool {
+		if value == "" {
+			return nil
+		}
+		values := map[string]bool{}
+		for _, v := range strings.Split(value, ",") {
+			values[strings.TrimSpace(v)] = true
+		}
+		return values
+	}
+	filter.ConnTypes = list(query.Get("conn_type"))
+	filter.Modes = list(query.Get("mode"))
+	filter.Labels = list(query.Get("label"))
+	filter.Tags = list(query.Get("tag"))
+	for mode := range filter.Modes {
+		if !slices.Contains(workloadModes, mode) {
+			return filter, fmt.Errorf("unknown mode %q", mode)
+		}
+	}
+
+	bound := func(param string, endOfDay bool) (time.Time, error) {
+		value := query.Get(param)
+		if value == "" {
+			return time.Time{}, nil
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t, nil
+		}
+		t, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s %q: must be an RFC 3339 time or a date such as 2006-01-02", param, value)
+		}
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	var err error
+	if filter.From, err = bound("from", false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = bound("to", true); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// matches reports whether the filter selects run.
+func (f resultFilter) matches(run kvstore.Run) bool {
+	if f.ConnTypes != nil && !f.ConnTypes[run.ConnType] {
+		return false
+	}
+
+	startedAt := runStartedAt(run)
+	if !f.From.IsZero() && startedAt < f.From.UnixMilli() {
+		return false
+	}
+	if !f.To.IsZero() && startedAt >= f.To.UnixMilli() {
+		return false
+	}
+
+	if f.Modes == nil && f.Labels == nil && f.Tags == nil {
+		return true
+	}
+
+	var result struct {
+		Mode  string   `json:"mode"`
+		Label string   `json:"label"`
+		Tags  []string `json:"tags"`
+	}
+	if err := json.Unmarshal(run.Result, &result); err != nil {
+		return false
+	}
+	if f.Modes != nil && !f.Modes[result.Mode] {
+		return false
+	}
+	if f.Labels != nil && !f.Labels[result.Label] {
+		return false
+	}
+	if f.Tags != nil && !slices.ContainsFunc(result.Tags, func(tag string) bool { return f.Tags[tag] }) {
+		return false
+	}
+	return true
+}
+
+// sortRuns orders runs by sortBy, descending if desc, breaking ties by run ID so that pages of
+// the same runs are stable.
+func sortRuns(runs []kvstore.Run, sortBy string, desc bool) {
+	sort.SliceStable(runs, func(i, j int) bool {
+		a, b := runs[i], runs[j]
+		if desc {
+			a, b = b, a
+		}
+
+		if sortBy == resultsSortConnType {
+			if a.ConnType != b.ConnType {
+				return a.ConnType < b.ConnType
+			}
+		} else if keyA, keyB := runSortKey(a, sortBy), runSortKey(b, sortBy); keyA != keyB {
+			return keyA < keyB
+		}
+		return a.ID < b.ID
+	})
+}
+
+// ListResults returns a page of the stored runs, newest first unless sort and order say
+// otherwise. page counts from zero, and per_page runs are returned on each. The runs listed may
+// be filtered by connection type, mode, label, tag and when they started.
+func (p *Plugin) ListResults(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := parseResultFilter(query)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, ResultList{Error: err.Error()})
+		return
+	}
+
+	sortBy := resultsSortStartedAt
+	if value := query.Get("sort"); value != "" {
+		switch value {
+		case resultsSortStartedAt, resultsSortCreatedAt, resultsSortConnType:
+			sortBy = value
+		default:
+			respondWithJSON(w, http.StatusBadRequest, ResultList{Error: fmt.Sprintf("unknown sort %q", value)})
+			return
+		}
+	}
+
+	desc := true
+	if value := query.Get("order"); value != "" {
+		switch value {
+		case "asc":
+			desc = false
+		case "desc":
+		default:
+			respondWithJSON(w, http.StatusBadRequest, ResultList{Error: fmt.Sprintf("unknown order %q: must be asc or desc", value)})
+			return
+		}
+	}
+
+	page := 0
+	if value := query.Get("page"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			page = n
+		}
+	}
+	perPage := defaultResultsPerPage
+	if value := query.Get("per_page"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			perPage = min(n, maxResultsPerPage)
+		}
+	}
+
+	runs, err := p.kvstore.ListRuns()
+	if err != nil {
+		p.API.LogError("Failed to list runs", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, ResultList{Error: err.Error()})
+		return
+	}
+	runs = slices.DeleteFunc(runs, func(run kvstore.Run) bool { return !filter.matches(run) })
+	sortRuns(runs, sortBy, desc)
+
+	list := ResultList{Runs: []kvstore.Run{}, Page: page, PerPage: perPage, Total: len(runs)}
+	if page <= len(runs)/perPage {
+		start := page * perPage
+		list.Runs = runs[start:min(start+perPage, len(runs))]
+	}
+	respondWithJSON(w, http.StatusOK, list)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortRuns(t *testing.T) {
+	runs := []kvstore.Run{
+		{ID: "b", ConnType: "rpc", StartedAt: 100, CreatedAt: 400},
+		{ID: "a", ConnType: "raw", StartedAt: 200, CreatedAt: 300},
+		{ID: "legacy", ConnType: "raw", CreatedAt: 150},
+		{ID: "c", ConnType: "rpc", StartedAt: 200, CreatedAt: 500},
+	}
+	ids := func() []string {
+		var ids []string
+		for _, run := range runs {
+			ids = append(ids, run.ID)
+		}
+		return ids
+	}
+
+	sortRuns(runs, resultsSortStartedAt, true)
+	assert.Equal(t, []string{"c", "a", "legacy", "b"}, ids())
+
+	sortRuns(runs, resultsSortStartedAt, false)
+	assert.Equal(t, []string{"b", "legacy", "a", "c"}, ids())
+
+	sortRuns(runs, resultsSortCreatedAt, true)
+	assert.Equal(t, []string{"c", "b", "a", "legacy"}, ids())
+
+	sortRuns(runs, resultsSortConnType, false)
+	assert.Equal(t, []string{"a", "legacy", "b", "c"}, ids())
+}
+
+func TestListResults(t *testing.T) {
+	runs := map[string]kvstore.Run{}
+	for i, id := range []string{"r0", "r1", "r2", "r3", "r4"} {
+		runs[id] = kvstore.Run{ID: id, ConnType: "rpc", StartedAt: int64(i + 1), CreatedAt: int64(i + 10)}
+	}
+	p := Plugin{kvstore: fakeRetentionStore{KVStore: &fakeRunLockStore{}, runs: runs}}
+	p.SetAPI(adminAPI())
+
+	list := func(target string) (int, ResultList) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, target))
+
+		var list ResultList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		return w.Code, list
+	}
+	ids := func(list ResultList) []string {
+		ids := []string{}
+		for _, run := range list.Runs {
+			ids = append(ids, run.ID)
+		}
+		return ids
+	}
+
+	status, page := list("/api/v1/results")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"r4", "r3", "r2", "r1", "r0"}, ids(page))
+	assert.Equal(t, 5, page.Total)
+	assert.Equal(t, defaultResultsPerPage, page.PerPage)
+
+	_, page = list("/api/v1/results?page=1&per_page=2&sort=started_at")
+	assert.Equal(t, []string{"r2", "r1"}, ids(page))
+	assert.Equal(t, 1, page.Page)
+
+	_, page = list("/api/v1/results?page=2&per_page=2&order=asc")
+	assert.Equal(t, []string{"r4"}, ids(page))
+
+	_, page = list("/api/v1/results?page=3&per_page=2")
+	assert.Empty(t, page.Runs)
+	assert.Equal(t, 5, page.Total)
+
+	_, page = list("/api/v1/results?page=99999999999999999&per_page=100")
+	assert.Empty(t, page.Runs)
+
+	status, page = list("/api/v1/results?sort=duration")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, `unknown sort "duration"`, page.Error)
+
+	status, _ = list("/api/v1/results?order=sideways")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestSaveRunRecordsStartedAt(t *testing.T) {
+	runs := map[string]kvstore.Run{}
+	p := Plugin{kvstore: fakeRetentionStore{KVStore: &fakeRunLockStore{}, runs: runs}}
+	p.SetAPI(adminAPI())
+
+	_, end, err := p.beginRun(context.Background(), kvstore.Job{ID: "running", Endpoint: "/api/v1/test"})
+	require.NoError(t, err)
+	p.saveRun("running", "rpc", url.Values{}, "", &TestResult{})
+	end(nil)
+	p.saveRun("finished", "rpc", url.Values{}, "", &TestResult{})
+
+	assert.NotZero(t, runs["running"].StartedAt)
+	assert.LessOrEqual(t, runs["running"].StartedAt, runs["running"].CreatedAt)
+	assert.Zero(t, runs["finished"].StartedAt)
+}
+
+func TestParseResultFilter(t *testing.T) {
+	filter, err := parseResultFilter(url.Values{"conn_type": {"raw, rpc"}, "mode": {"scan"}, "from": {"2026-09-01"}, "to": {"2026-09-30"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"raw": true, "rpc": true}, filter.ConnTypes)
+	assert.Equal(t, map[string]bool{"scan": true}, filter.Modes)
+	assert.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), filter.From)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), filter.To, "a date includes the whole day")
+
+	filter, err = parseResultFilter(url.Values{"to": {"2026-09-30T12:00:00Z"}})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 9, 30, 12, 0, 0, 0, time.UTC), filter.To)
+
+	_, err = parseResultFilter(url.Values{"mode": {"bogus"}})
+	assert.EqualError(t, err, `unknown mode "bogus"`)
+
+	_, err = parseResultFilter(url.Values{"from": {"last month"}})
+	assert.ErrorContains(t, err, `invalid from "last month"`)
+}
+
+func TestListResultsFilters(t *testing.T) {
+	at := func(day int) int64 { return time.Date(2026, 9, day, 12, 0, 0, 0, time.UTC).UnixMilli() }
+	p := Plugin{kvstore: fakeRetentionStore{KVStore: &fakeRunLockStore{}, runs: map[string]kvstore.Run{
+		"raw-scan-aug":  {ID: "raw-scan-aug", ConnType: "raw", StartedAt: at(0), Result: []byte(`{"mode": "scan"}`)},
+		"raw-scan-sep":  {ID: "raw-scan-sep", ConnType: "raw", StartedAt: at(10), Result: []byte(`{"mode": "scan"}`)},
+		"raw-join-sep":  {ID: "raw-join-sep", ConnType: "raw", StartedAt: at(30), Result: []byte(`{"mode": "join", "label": "nightly", "tags": ["pg15"]}`)},
+		"rpc-scan-sep":  {ID: "rpc-scan-sep", ConnType: "rpc", StartedAt: at(15), Result: []byte(`{"mode": "scan", "tags": ["before-upgrade", "pg14"]}`)},
+		"legacy-stored": {ID: "legacy-stored", ConnType: "raw", CreatedAt: at(20), Result: []byte(`{"mode": "scan"}`)},
+	}}}
+	p.SetAPI(adminAPI())
+
+	list := func(target string) (int, []string) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, target))
+
+		var list ResultList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		ids := []string{}
+		for _, run := range list.Runs {
+			ids = append(ids, run.ID)
+		}
+		return w.Code, ids
+	}
+
+	_, ids := list("/api/v1/results?conn_type=raw&mode=scan&from=2026-09-01&to=2026-09-30")
+	assert.Equal(t, []string{"legacy-stored", "raw-scan-sep"}, ids)
+
+	_, ids = list("/api/v1/results?mode=join,scan&to=2026-09-15T00:00:00Z")
+	assert.Equal(t, []string{"raw-scan-sep", "raw-scan-aug"}, ids)
+
+	_, ids = list("/api/v1/results?label=nightly")
+	assert.Equal(t, []string{"raw-join-sep"}, ids)
+
+	_, ids = list("/api/v1/results?tag=pg15,before-upgrade")
+	assert.Equal(t, []string{"raw-join-sep", "rpc-scan-sep"}, ids)
+
+	status, _ := list("/api/v1/results?from=yesterday")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+)
+
+// runsToPrune returns the IDs of the stored runs beyond the maxCount most recent or created
+// more than maxAge before now, where a non-positive limit keeps any number or age. Runs in keep
+// are never pruned, though they count towards maxCount.
+func runsToPrune(runs []kvstore.Run, keep map[string]bool, maxCount int, maxAge time.Duration, now time.Time) []string {
+	sort.Slice(runs, func(i, j int) bool { return runs[i].CreatedAt > runs[j].CreatedAt })
+
+	var pruned []string
+	for i, run := range runs {
+		if keep[run.ID] {
+			continue
+		}
+		if (maxCount > 0 && i >= maxCount) || (maxAge > 0 && now.Sub(time.UnixMilli(run.CreatedAt)) > maxAge) {
+			pruned = append(pruned, run.ID)
+		}
+	}
+	return pruned
+}
+
+// pruneRuns deletes the stored runs beyond the configured retention, so the history of installs
+// running scheduled benchmarks does not grow without bound. Baseline runs are kept for as long
+// as they are baselines, so they can still be replayed. It runs hourly on a single node.
+func (p *Plugin) pruneRuns() {
+	config := p.getConfiguration()
+	if config.ResultRetentionCount <= 0 && config.ResultRetentionDays <= 0 {
+		return
+	}
+
+	runs, err := p.kvstore.ListRuns()
+	if err != nil {
+		p.API.LogError("Failed to list runs to prune", "error", err)
+		return
+	}
+
+	baselines, err := p.kvstore.ListBaselines()
+	if err != nil {
+		p.API.LogError("Failed to list baselines to prune runs", "error", err)
+		return
+	}
+	keep := make(map[string]bool, len(baselines))
+	for _, baseline := range baselines {
+		keep[baseline.RunID] = true
+	}
+
+	maxAge := time.Duration(config.ResultRetentionDays) * 24 * time.Hour
+	pruned := 0
+	for _, id := range runsToPrune(runs, keep, config.ResultRetentionCount, maxAge, time.Now()) {
+		if err := p.kvstore.DeleteRun(id); err != nil {
+			p.API.LogError("Failed to prune run", "run_id", id, "error", err)
+			continue
+		}
+		pruned++
+	}
+
+	if pruned > 0 {
+		p.API.LogInfo("Pruned stored runs", "pruned", pruned, "stored", len(runs)-pruned)
+	}
+}
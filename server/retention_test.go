@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeRetentionStore serves runs and baselines from memory.
+type fakeRetentionStore struct {
+	kvstore.KVStore
+	runs      map[string]kvstore.Run
+	baselines []kvstore.Baseline
+	profiles  map[string][]byte
+	queryLogs map[string][]byte
+}
+
+func (s fakeRetentionStore) ListRuns() ([]kvstore.Run, error) {
+	runs := []kvstore.Run{}
+	for _, run := range s.runs {
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func (s fakeRetentionStore) GetRun(id string) (*kvstore.Run, error) {
+	run, ok := s.runs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &run, nil
+}
+
+func (s fakeRetentionStore) SaveRun(run kvstore.Run) error {
+	s.runs[run.ID] = run
+	return nil
+}
+
+func (s fakeRetentionStore) SaveProfile(runID string, profile []byte) error {
+	s.profiles[runID] = profile
+	return nil
+}
+
+func (s fakeRetentionStore) GetProfile(runID string) ([]byte, error) {
+	return s.profiles[runID], nil
+}
+
+func (s fakeRetentionStore) SaveQueryLog(runID string, queryLog []byte) error {
+	s.queryLogs[runID] = queryLog
+	return nil
+}
+
+func (s fakeRetentionStore) GetQueryLog(runID string) ([]byte, error) {
+	return s.queryLogs[runID], nil
+}
+
+func (s fakeRetentionStore) DeleteRun(id string) error {
+	delete(s.runs, id)
+	return nil
+}
+
+func (s fakeRetentionStore) ListBaselines() ([]kvstore.Baseline, error) {
+	return s.baselines, nil
+}
+
+func TestRunsToPrune(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) int64 { return now.Add(-time.Duration(days) * 24 * time.Hour).UnixMilli() }
+	runs := []kvstore.Run{
+		{ID: "oldest", CreatedAt: daysAgo(40)},
+		{ID: "newest", CreatedAt: daysAgo(0)},
+		{ID: "old", CreatedAt: daysAgo(20)},
+		{ID: "recent", CreatedAt: daysAgo(1)},
+	}
+
+	assert.Empty(t, runsToPrune(runs, nil, 0, 0, now))
+	assert.Equal(t, []string{"old", "oldest"}, runsToPrune(runs, nil, 2, 0, now))
+	assert.Equal(t, []string{"old", "oldest"}, runsToPrune(runs, nil, 0, 7*24*time.Hour, now))
+	assert.Equal(t, []string{"oldest"}, runsToPrune(runs, nil, 3, 30*24*time.Hour, now))
+	assert.Equal(t, []string{"old"}, runsToPrune(runs, map[string]bool{"oldest": true}, 2, 0, now))
+}
+
+func TestPruneRuns(t *testing.T) {
+	api := adminAPI()
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	now := time.Now()
+	store := fakeRetentionStore{
+		runs: map[string]kvstore.Run{
+			"baseline": {ID: "baseline", CreatedAt: now.Add(-3 * time.Hour).UnixMilli()},
+			"first":    {ID: "first", CreatedAt: now.Add(-2 * time.Hour).UnixMilli()},
+			"second":   {ID: "second", CreatedAt: now.Add(-time.Hour).UnixMilli()},
+			"third":    {ID: "third", CreatedAt: now.UnixMilli()},
+		},
+		baselines: []kvstore.Baseline{{Series: "raw|scan|", RunID: "baseline"}},
+	}
+	p := Plugin{kvstore: store}
+	p.SetAPI(api)
+
+	p.setConfiguration(&configuration{})
+	p.pruneRuns()
+	assert.Len(t, store.runs, 4)
+
+	p.setConfiguration(&configuration{ResultRetentionCount: 2})
+	p.pruneRuns()
+	assert.Contains(t, store.runs, "baseline")
+	assert.Contains(t, store.runs, "second")
+	assert.Contains(t, store.runs, "third")
+	assert.NotContains(t, store.runs, "first")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRetryAttempts caps retry_attempts.
+	maxRetryAttempts = 10
+
+	// defaultRetryBackoff is the delay before the first retry when retry_backoff is not given,
+	// doubling with each further one.
+	defaultRetryBackoff = 100 * time.Millisecond
+
+	// maxRetryBackoff caps retry_backoff and every doubled delay.
+	maxRetryBackoff = 10 * time.Second
+)
+
+// transientErrors are fragments of the messages of errors caused by a passing network or server
+// blip, after which the same query may succeed. As with serialization failures, errors are
+// matched by message because the RPC driver only carries their text across the plugin boundary.
+var transientErrors = []string{
+	"bad connection",           // database/sql driver.ErrBadConn
+	"invalid connection",       // MySQL driver ErrInvalidConn
+	"unexpected EOF",           // connection closed mid-response
+	"connection reset by peer", // TCP reset
+	"broken pipe",              // write to a closed connection
+	"connection refused",       // server restarting
+	"i/o timeout",              // network deadline
+	"terminating connection due to administrator command", // Postgres 57P01
+	"the database system is starting up",                  // Postgres 57P03
+	"the database system is shutting down",                // Postgres 57P03
+	"Error 1040",                                          // MySQL ER_CON_COUNT_ERROR
+}
+
+// isTransientError reports whether err may not recur if the query is retried.
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	message := err.Error()
+	for _, fragment := range transientErrors {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withRetries runs op, retrying it up to opts.RetryAttempts times with exponential backoff when
+// it fails with a transient error, so a passing blip does not abort a long run. Retries are
+// counted on result. Each attempt must start afresh, since op may have failed part way through.
+func (p *Plugin) withRetries(opts testOptions, result *TestResult, op func() error) error {
+	backoff := opts.RetryBackoff
+	if backoff == 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	for retries := 0; ; retries++ {
+		err := op()
+		if retries >= opts.RetryAttempts || !isTransientError(err) {
+			return err
+		}
+
+		p.API.LogWarn("Retrying query after transient error", "attempt", retries+1, "error", err)
+		if opts.ctx == nil {
+			time.Sleep(min(backoff<<retries, maxRetryBackoff))
+		} else {
+			select {
+			case <-time.After(min(backoff<<retries, maxRetryBackoff)):
+			case <-opts.ctx.Done():
+				return errRunCancelled
+			}
+		}
+		result.QueryRetries++
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIsTransientError(t *testing.T) {
+	assert.False(t, isTransientError(nil))
+	assert.True(t, isTransientError(fmt.Errorf("failed to query rows: %w", driver.ErrBadConn)))
+	assert.True(t, isTransientError(errors.New("read tcp 10.0.0.1:5432: connection reset by peer")))
+	assert.True(t, isTransientError(errors.New("pq: terminating connection due to administrator command")))
+	assert.True(t, isTransientError(errors.New("Error 1040: Too many connections")))
+	assert.False(t, isTransientError(errors.New(`pq: relation "plugin_test_rpc" does not exist`)))
+	assert.False(t, isTransientError(errors.New("Error 1064: You have an error in your SQL syntax")))
+}
+
+func TestWithRetries(t *testing.T) {
+	newPlugin := func() *Plugin {
+		api := &plugintest.API{}
+		api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+		p := &Plugin{}
+		p.SetAPI(api)
+		return p
+	}
+	opts := testOptions{RetryAttempts: 2, RetryBackoff: time.Millisecond}
+
+	t.Run("transient errors are retried", func(t *testing.T) {
+		var result TestResult
+		calls := 0
+
+		err := newPlugin().withRetries(opts, &result, func() error {
+			calls++
+			if calls < 3 {
+				return driver.ErrBadConn
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 2, result.QueryRetries)
+	})
+
+	t.Run("attempts are bounded", func(t *testing.T) {
+		var result TestResult
+		calls := 0
+
+		err := newPlugin().withRetries(opts, &result, func() error {
+			calls++
+			return driver.ErrBadConn
+		})
+
+		assert.ErrorIs(t, err, driver.ErrBadConn)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 2, result.QueryRetries)
+	})
+
+	t.Run("other errors fail at once", func(t *testing.T) {
+		var result TestResult
+		calls := 0
+
+		err := newPlugin().withRetries(opts, &result, func() error {
+			calls++
+			return errors.New("syntax error")
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Zero(t, result.QueryRetries)
+	})
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultRowLockRecords is the number of row locks taken when records is not given.
+	defaultRowLockRecords = 1000
+
+	// defaultLockWorkers and maxLockWorkers bound the lock_workers query param.
+	defaultLockWorkers = 8
+	maxLockWorkers     = 64
+
+	// defaultHotRows and maxHotRows bound the hot_rows query param.
+	defaultHotRows = 4
+	maxHotRows     = 1000
+)
+
+// runRowLockTest has opts.LockWorkers workers take opts.Records row locks in total with
+// SELECT ... FOR UPDATE on the opts.HotRows rows of plugin_test_rpc_hot, each incrementing the
+// locked row before committing, to compare lock handling latency across connection types.
+func (p *Plugin) runRowLockTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:       opts.Label,
+		Mode:        modeRowLock,
+		Phase:       opts.Phase,
+		LockWorkers: opts.LockWorkers,
+		HotRows:     opts.HotRows,
+	}
+
+	if opts.Phase != phaseQuery {
+		if err := seedHotTable(db, driverName, opts); err != nil {
+			return result, err
+		}
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.contendRowLocks(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// seedHotTable creates plugin_test_rpc_hot if needed and tops it up to opts.HotRows rows.
+func seedHotTable(db *sql.DB, driverName string, opts testOptions) error {
+	createTableSQL := "CREATE TABLE IF NOT EXISTS plugin_test_rpc_hot (id INT PRIMARY KEY, counter BIGINT NOT NULL)"
+	if _, err := db.Exec(opts.tagSQL(createTableSQL)); err != nil {
+		return fmt.Errorf("failed to create hot table: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(opts.tagSQL("SELECT COUNT(*) FROM plugin_test_rpc_hot")).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check hot rows: %v", err)
+	}
+	if count >= opts.HotRows {
+		return nil
+	}
+
+	args := make([]any, 0, 2*(opts.HotRows-count))
+	for id := count + 1; id <= opts.HotRows; id++ {
+		args = append(args, id, 0)
+	}
+	insertSQL := opts.tagSQL(multiRowInsert(driverName, "plugin_test_rpc_hot", []string{"id", "counter"}, opts.HotRows-count))
+	if _, err := db.Exec(insertSQL, args...); err != nil {
+		return fmt.Errorf("failed to insert hot rows: %v", err)
+	}
+
+	return nil
+}
+
+// contendRowLocks runs the workers until opts.Records locks have been taken, recording the lock
+// rate and the distribution of the time each SELECT ... FOR UPDATE waited on result. It fails if
+// any lock fails, or if the counters show lost updates.
+func (p *Plugin) contendRowLocks(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	before, err := hotRowsTotal(db, opts)
+	if err != nil {
+		return err
+	}
+
+	lockSQL := opts.tagSQL(rebind(driverName, "SELECT counter FROM plugin_test_rpc_hot WHERE id = ? FOR UPDATE"))
+	updateSQL := opts.tagSQL(rebind(driverName, "UPDATE plugin_test_rpc_hot SET counter = ? WHERE id = ?"))
+
+	var remaining atomic.Int64
+	remaining.Store(int64(opts.Records))
+
+	var mu sync.Mutex
+	var firstErr error
+	waits := make([]time.Duration, 0, opts.Records)
+
+	var workers sync.WaitGroup
+	startTotalQuery := time.Now()
+	for worker := 0; worker < opts.LockWorkers; worker++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+
+			// Each worker draws rows from its own fixed seed so every run contends the same way.
+			random := rand.New(rand.NewSource(int64(worker)))
+			for remaining.Add(-1) >= 0 {
+				wait, err := p.lockHotRow(db, opts, lockSQL, updateSQL, 1+random.Intn(opts.HotRows))
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				waits = append(waits, wait)
+				mu.Unlock()
+			}
+		}()
+	}
+	workers.Wait()
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	result.Locks = len(waits)
+	if result.TotalQueryTimeSeconds > 0 {
+		result.LocksPerSecond = float64(result.Locks) / result.TotalQueryTimeSeconds
+	}
+	latency := summarizeLatencies(waits).millis()
+	result.LockWaitLatency = &latency
+	if err := result.setLatencyHistogram(waits); err != nil {
+		return err
+	}
+
+	after, err := hotRowsTotal(db, opts)
+	if err != nil {
+		return err
+	}
+	if updates := after - before; updates != int64(opts.Records) {
+		return fmt.Errorf("hot rows were incremented %d times, expected %d", updates, opts.Records)
+	}
+
+	return nil
+}
+
+// lockHotRow locks row id with SELECT ... FOR UPDATE, increments it and commits, returning how
+// long acquiring the lock took.
+func (p *Plugin) lockHotRow(db *sql.DB, opts testOptions, lockSQL, updateSQL string, id int) (time.Duration, error) {
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	var counter int64
+	start := time.Now()
+	err = tx.QueryRow(lockSQL, id).Scan(&counter)
+	wait := time.Since(start)
+	if err == nil {
+		_, err = tx.Exec(updateSQL, counter+1, id)
+	}
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			p.API.LogError("Failed to rollback transaction", "error", rbErr)
+		}
+		return 0, fmt.Errorf("failed to lock hot row %d: %v", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit hot row %d: %v", id, err)
+	}
+
+	return wait, nil
+}
+
+// hotRowsTotal sums the counters of plugin_test_rpc_hot.
+func hotRowsTotal(db *sql.DB, opts testOptions) (int64, error) {
+	var total int64
+	if err := db.QueryRow(opts.tagSQL("SELECT COALESCE(SUM(counter), 0) FROM plugin_test_rpc_hot")).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum hot rows: %v", err)
+	}
+
+	return total, nil
+}
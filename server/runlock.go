@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// runLockExpiry bounds how long the run lock outlives a node that dies mid-run.
+	runLockExpiry = 5 * time.Minute
+
+	// runLockRefreshInterval is how often a run renews its lock while in progress.
+	runLockRefreshInterval = time.Minute
+
+	// runLockAttempts is how many times taking the run lock is tried when its holder releases
+	// it before it can be read.
+	runLockAttempts = 3
+)
+
+// runInProgressError is returned when a benchmark is requested while another one holds the run
+// lock anywhere in the cluster.
+type runInProgressError struct {
+	Lock kvstore.RunLock
+}
+
+func (e *runInProgressError) Error() string {
+	return fmt.Sprintf("benchmark run %s (%s) is already in progress on node %s", e.Lock.RunID, e.Lock.Endpoint, e.Lock.NodeID)
+}
+
+// acquireRunLock takes the cluster-wide run lock for the run of endpoint with ID runID, so that
+// concurrent benchmarks cannot distort each other. It returns a function releasing the lock,
+// which is renewed until then, or a *runInProgressError naming the run holding the lock.
+func (p *Plugin) acquireRunLock(runID, endpoint string) (func(), error) {
+	lock := kvstore.RunLock{
+		RunID:     runID,
+		NodeID:    p.nodeInfo.ID,
+		Endpoint:  endpoint,
+		StartedAt: time.Now().UnixMilli(),
+	}
+
+	for attempt := 0; ; attempt++ {
+		acquired, err := p.kvstore.AcquireRunLock(lock, runLockExpiry)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+
+		holder, err := p.kvstore.GetRunLock()
+		if err != nil {
+			return nil, err
+		}
+		if holder != nil {
+			return nil, &runInProgressError{Lock: *holder}
+		}
+		if attempt == runLockAttempts-1 {
+			return nil, errors.New("failed to acquire run lock")
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(runLockRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				refreshed, err := p.kvstore.RefreshRunLock(lock, runLockExpiry)
+				if err != nil {
+					p.API.LogWarn("Failed to refresh run lock", "run_id", lock.RunID, "error", err)
+				} else if !refreshed {
+					p.API.LogWarn("Lost run lock", "run_id", lock.RunID)
+					return
+				}
+			}
+		}
+	}()
+
+	release := func() {
+		close(done)
+		if err := p.kvstore.ReleaseRunLock(lock); err != nil {
+			p.API.LogError("Failed to release run lock", "run_id", lock.RunID, "error", err)
+		}
+	}
+
+	return release, nil
+}
+
+// runIDContextKey carries the ID of the run holding the run lock in a request's context.
+type runIDContextKey struct{}
+
+// requestRunID returns the ID of the run r holds the run lock for, or a new ID if it holds none.
+func requestRunID(r *http.Request) string {
+	if id, ok := r.Context().Value(runIDContextKey{}).(string); ok {
+		return id
+	}
+	return model.NewId()
+}
+
+// RunInProgress reports that a benchmark was refused because another run holds the run lock.
+type RunInProgress struct {
+	Error string `json:"error"`
+	RunID string `json:"run_id,omitempty"`
+}
+
+// QueuedRun reports a benchmark queued to run once the runs ahead of it have completed.
+type QueuedRun struct {
+	RunID    string `json:"run_id"`
+	Position int    `json:"position"`
+}
+
+// RunLockRequired holds the run lock while serving a benchmark, refusing it with 409 Conflict
+// and the ID of the run in progress while any other benchmark runs in the cluster. With
+// queue=true the benchmark is queued instead, and 202 Accepted is returned with its run ID and
+// position in the queue. Queued benchmarks already hold the lock when they are served. With
+// resume=true a run interrupted by a plugin restart is queued again once the plugin is back.
+func (p *Plugin) RunLockRequired(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, held := r.Context().Value(runIDContextKey{}).(string); held {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		queue := false
+		if value := r.URL.Query().Get("queue"); value != "" {
+			var err error
+			if queue, err = strconv.ParseBool(value); err != nil {
+				respondWithJSON(w, http.StatusBadRequest, RunInProgress{Error: fmt.Sprintf("invalid queue %q: must be a boolean", value)})
+				return
+			}
+		}
+		if value := r.URL.Query().Get("resume"); value != "" {
+			if _, err := strconv.ParseBool(value); err != nil {
+				respondWithJSON(w, http.StatusBadRequest, RunInProgress{Error: fmt.Sprintf("invalid resume %q: must be a boolean", value)})
+				return
+			}
+		}
+
+		runID := model.NewId()
+
+		// Queued benchmarks run in order, so a new one may not overtake them.
+		if queue && p.runQueueLength() > 0 {
+			p.queueRun(w, r, runID, "")
+			return
+		}
+
+		job := kvstore.Job{
+			ID:       runID,
+			Method:   r.Method,
+			Endpoint: r.URL.Path,
+			Params:   r.URL.RawQuery,
+			UserID:   r.Header.Get("Mattermost-User-ID"),
+		}
+		ctx, end, err := p.beginRun(r.Context(), job)
+		var inProgress *runInProgressError
+		if errors.As(err, &inProgress) {
+			if queue {
+				p.queueRun(w, r, runID, inProgress.Lock.RunID)
+				return
+			}
+			respondWithJSON(w, http.StatusConflict, RunInProgress{Error: err.Error(), RunID: inProgress.Lock.RunID})
+			return
+		} else if err != nil {
+			p.API.LogError("Failed to acquire run lock", "error", err)
+			respondWithJSON(w, http.StatusInternalServerError, RunInProgress{Error: err.Error()})
+			return
+		}
+
+		response := &jobResponse{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			end(responseError(response.status, response.body.Bytes()))
+		}()
+
+		next.ServeHTTP(response, r.WithContext(ctx))
+	})
+}
+
+// jobResponse records the status of a benchmark's response while writing it, along with the
+// body of a failed one, so that the job can be recorded with its outcome.
+type jobResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *jobResponse) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *jobResponse) Write(data []byte) (int, error) {
+	if r.status >= http.StatusBadRequest {
+		r.body.Write(data)
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// queueRun queues the benchmark r as run runID, responding with its place in the queue, or with
+// 409 Conflict and the ID of the run in progress, if any, when the queue is full.
+func (p *Plugin) queueRun(w http.ResponseWriter, r *http.Request, runID, inProgressID string) {
+	position, err := p.enqueueRun(runID, r)
+	if err != nil {
+		respondWithJSON(w, http.StatusConflict, RunInProgress{Error: err.Error(), RunID: inProgressID})
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, QueuedRun{RunID: runID, Position: position})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRunLockStore holds the run lock and job records in memory.
+type fakeRunLockStore struct {
+	kvstore.KVStore
+
+	mu     sync.Mutex
+	holder *kvstore.RunLock
+	jobs   map[string]kvstore.Job
+}
+
+func (s *fakeRunLockStore) SaveJob(job kvstore.Job, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.jobs == nil {
+		s.jobs = make(map[string]kvstore.Job)
+	}
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *fakeRunLockStore) UpdateJob(old, job kvstore.Job, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.jobs[job.ID]; !ok || current != old {
+		return false, nil
+	}
+	s.jobs[job.ID] = job
+	return true, nil
+}
+
+func (s *fakeRunLockStore) ListJobs() ([]kvstore.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := []kvstore.Job{}
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (s *fakeRunLockStore) AcquireRunLock(lock kvstore.RunLock, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.holder != nil {
+		return false, nil
+	}
+	s.holder = &lock
+	return true, nil
+}
+
+func (s *fakeRunLockStore) RefreshRunLock(lock kvstore.RunLock, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.holder != nil && *s.holder == lock, nil
+}
+
+func (s *fakeRunLockStore) ReleaseRunLock(lock kvstore.RunLock) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.holder != nil && *s.holder == lock {
+		s.holder = nil
+	}
+	return nil
+}
+
+func (s *fakeRunLockStore) GetRunLock() (*kvstore.RunLock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.holder, nil
+}
+
+func TestAcquireRunLock(t *testing.T) {
+	store := &fakeRunLockStore{}
+	p := Plugin{kvstore: store, nodeInfo: NodeInfo{ID: "node1"}}
+
+	release, err := p.acquireRunLock("run1", "/api/v1/test")
+	require.NoError(t, err)
+
+	_, err = p.acquireRunLock("run2", "/api/v1/test_raw")
+	var inProgress *runInProgressError
+	require.ErrorAs(t, err, &inProgress)
+	assert.Equal(t, "run1", inProgress.Lock.RunID)
+	assert.Equal(t, "/api/v1/test", inProgress.Lock.Endpoint)
+	assert.Equal(t, "node1", inProgress.Lock.NodeID)
+
+	release()
+	release, err = p.acquireRunLock("run2", "/api/v1/test_raw")
+	require.NoError(t, err)
+	assert.Equal(t, "run2", store.holder.RunID)
+	release()
+}
+
+func TestRunLockRequired(t *testing.T) {
+	store := &fakeRunLockStore{}
+	p := Plugin{kvstore: store}
+	p.SetAPI(adminAPI())
+
+	release, err := p.acquireRunLock("holding", "autorun:scan")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(nil, w, adminRequest(http.MethodGet, "/api/v1/test"))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var conflict RunInProgress
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &conflict))
+	assert.Equal(t, "holding", conflict.RunID)
+	assert.Contains(t, conflict.Error, "already in progress")
+
+	// Routes that only read plugin state are not benchmarks and never wait for the lock.
+	w = httptest.NewRecorder()
+	p.ServeHTTP(nil, w, adminRequest(http.MethodGet, "/api/v1/status"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	release()
+	var seen string
+	handler := p.RunLockRequired(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestRunID(r)
+		assert.Equal(t, seen, store.holder.RunID)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), adminRequest(http.MethodGet, "/api/v1/test"))
+	assert.NotEmpty(t, seen)
+	assert.Nil(t, store.holder)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+)
+
+// saveRun stores the params, notes and outcome of a completed run under id so it can be replayed
+// later, and records the ID on result. The params are stored redacted of any database password.
+// A run saved while its job is still running records when the job started. Failures are logged
+// but never fail the run itself.
+func (p *Plugin) saveRun(id, connType string, params url.Values, replayOf string, result *TestResult) {
+	// The notes describe this run rather than its options, so they are kept apart from the params
+	// a replay reuses.
+	notes := params.Get("notes")
+	if notes != "" {
+		params = maps.Clone(params)
+		params.Del("notes")
+	}
+
+	run := kvstore.Run{
+		ID:               id,
+		ConnType:         connType,
+		Params:           redactParams(params).Encode(),
+		GeneratorVersion: datasetGeneratorVersion,
+		ReplayOf:         replayOf,
+		Notes:            notes,
+		CreatedAt:        time.Now().UnixMilli(),
+	}
+	if startedAt, ok := p.localJobStartedAt(id); ok {
+		run.StartedAt = startedAt.UnixMilli()
+	}
+
+	result.RunID = run.ID
+	result.ReplayOf = replayOf
+
+	if len(result.profileData) > 0 {
+		if err := p.kvstore.SaveProfile(run.ID, result.profileData); err != nil {
+			p.API.LogError("Failed to save profile", "error", err)
+			result.Profile = ""
+		}
+	}
+	if len(result.queryLogData) > 0 {
+		if err := p.kvstore.SaveQueryLog(run.ID, result.queryLogData); err != nil {
+			p.API.LogError("Failed to save query log", "error", err)
+			result.QueryLog = nil
+		}
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		p.API.LogError("Failed to encode run result", "error", err)
+		result.RunID = ""
+		return
+	}
+	run.Result = encoded
+
+	if err := p.kvstore.SaveRun(run); err != nil {
+		p.API.LogError("Failed to save run", "error", err)
+		result.RunID = ""
+	}
+}
+
+// finishRun checks result for alerts and saves it as run id, where scheduled is set for runs of
+// the scheduled benchmark. The ID is recorded on result before the check, so the alerts it raises
+// link to the run's report.
+func (p *Plugin) finishRun(id, connType string, params url.Values, replayOf string, scheduled bool, result *TestResult) {
+	result.RunID = id
+	p.checkAlerts(result, params, scheduled)
+	p.saveRun(id, connType, params, replayOf, result)
+}
+
+// ReplayRun re-executes a stored run with exactly the same options. The data generators are
+// deterministic, so a replay issues the same operation sequence as long as the generator version
+// the run was recorded with is still current. The notes of the stored run are not carried over,
+// but the replay may be given its own with the notes query param.
+func (p *Plugin) ReplayRun(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	run, err := p.kvstore.GetRun(id)
+	if err != nil {
+		p.API.LogError("Failed to get run", "error", err)
+		respondWithJSON(w, http.StatusInternalServerError, TestResult{Error: err.Error()})
+		return
+	}
+	if run == nil {
+		respondWithJSON(w, http.StatusNotFound, TestResult{Error: fmt.Sprintf("unknown run %s", id)})
+		return
+	}
+	if run.GeneratorVersion != datasetGeneratorVersion {
+		respondWithJSON(w, http.StatusConflict, TestResult{
+			Error:    fmt.Sprintf("run %s was recorded with generator version %d, but the current version is %d", id, run.GeneratorVersion, datasetGeneratorVersion),
+			ConnType: run.ConnType,
+		})
+		return
+	}
+
+	params, err := url.ParseQuery(run.Params)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, TestResult{
+			Error:    fmt.Sprintf("invalid params stored for run %s: %v", id, err),
+			ConnType: run.ConnType,
+		})
+		return
+	}
+	if notes := r.URL.Query().Get("notes"); notes != "" {
+		params.Set("notes", notes)
+	}
+	// The stored dsn is redacted of its password, which the replay must be given again.
+	if dataSource := r.URL.Query().Get("dsn"); dataSource != "" {
+		params.Set("dsn", dataSource)
+	}
+	// The replay keeps the context of r, so it is cancelled along with the job it runs as, and
+	// its user, on whose behalf raw connections are audited.
+	replay := (&http.Request{Method: http.MethodGet, URL: &url.URL{RawQuery: params.Encode()}, Header: http.Header{}}).WithContext(r.Context())
+	replay.Header.Set("Mattermost-User-ID", r.Header.Get("Mattermost-User-ID"))
+	result, err := p.runTest(run.ConnType, replay)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, TestResult{
+			Error:    err.Error(),
+			ConnType: run.ConnType,
+		})
+		return
+	}
+
+	p.finishRun(requestRunID(r), run.ConnType, params, run.ID, false, &result)
+	p.publishResult(result)
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// getStoredResult returns the run stored under id along with its decoded result. On failure, it
+// also returns the HTTP status to respond with: 404 Not Found for an unknown run.
+func (p *Plugin) getStoredResult(id string) (*kvstore.Run, TestResult, int, error) {
+	run, err := p.kvstore.GetRun(id)
+	if err != nil {
+		p.API.LogError("Failed to get run", "error", err)
+		return nil, TestResult{}, http.StatusInternalServerError, err
+	}
+	if run == nil {
+		return nil, TestResult{}, http.StatusNotFound, fmt.Errorf("unknown run %s", id)
+	}
+
+	result, err := decodeTestResult(run.Result)
+	if err != nil {
+		return nil, TestResult{}, http.StatusInternalServerError, fmt.Errorf("invalid result stored for run %s: %v", id, err)
+	}
+	return run, result, http.StatusOK, nil
+}
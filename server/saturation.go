@@ -0,0 +1,287 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMaxWorkers is the highest worker count tried when max_workers is not given.
+	defaultMaxWorkers = 64
+
+	// maxMaxWorkers bounds the max_workers query param.
+	maxMaxWorkers = 1024
+
+	// defaultStepDuration is how long each worker count is measured for.
+	defaultStepDuration = 5 * time.Second
+
+	// maxStepDuration bounds the step_duration query param.
+	maxStepDuration = time.Minute
+
+	// defaultMaxErrorRate is the fraction of failed operations tolerated at a worker count.
+	defaultMaxErrorRate = 0.01
+
+	// defaultMinGain is the relative throughput improvement below which doubling the workers is
+	// considered to no longer help.
+	defaultMinGain = 0.05
+)
+
+// Reasons a saturation search stopped increasing the worker count.
+const (
+	stopPlateau   = "throughput_plateau"
+	stopErrorRate = "error_rate"
+	stopLatency   = "latency_slo"
+	stopMaxWorker = "max_workers"
+)
+
+// saturationOptions captures the query params of a saturation search.
+type saturationOptions struct {
+	MaxWorkers   int
+	StepDuration time.Duration
+	MaxErrorRate float64
+	MaxP99       time.Duration
+	MinGain      float64
+}
+
+// SaturationLevel reports the workload at one worker count.
+type SaturationLevel struct {
+	Workers      int     `json:"workers"`
+	Operations   int     `json:"operations"`
+	Errors       int     `json:"errors"`
+	OpsPerSecond float64 `json:"ops_per_second"`
+	MinMillis    float64 `json:"min_ms"`
+	AvgMillis    float64 `json:"avg_ms"`
+	P99Millis    float64 `json:"p99_ms"`
+}
+
+// SaturationResult reports the saturation search over one connection type.
+type SaturationResult struct {
+	ConnType          string            `json:"conn_type"`
+	SaturationWorkers int               `json:"saturation_workers"`
+	PeakOpsPerSecond  float64           `json:"peak_ops_per_second"`
+	StopReason        string            `json:"stop_reason,omitempty"`
+	Levels            []SaturationLevel `json:"levels"`
+	Error             string            `json:"error,omitempty"`
+}
+
+// SaturationResponse collects the saturation results for every connection type.
+type SaturationResponse struct {
+	Label   string             `json:"label,omitempty"`
+	Results []SaturationResult `json:"results"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// parseSaturationOptions reads the saturation query params from r. Invalid values fall back to
+// their defaults.
+func parseSaturationOptions(r *http.Request) saturationOptions {
+	opts := saturationOptions{
+		MaxWorkers:   defaultMaxWorkers,
+		StepDuration: defaultStepDuration,
+		MaxErrorRate: defaultMaxErrorRate,
+		MinGain:      defaultMinGain,
+	}
+
+	query := r.URL.Query()
+	if value := query.Get("max_workers"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			opts.MaxWorkers = min(n, maxMaxWorkers)
+		}
+	}
+	if value := query.Get("step_duration"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			opts.StepDuration = min(d, maxStepDuration)
+		}
+	}
+	if value := query.Get("max_error_rate"); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil && f >= 0 && f <= 1 {
+			opts.MaxErrorRate = f
+		}
+	}
+	if value := query.Get("max_p99_ms"); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil && f > 0 {
+			opts.MaxP99 = time.Duration(f * float64(time.Millisecond))
+		}
+	}
+	if value := query.Get("min_gain"); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil && f >= 0 {
+			opts.MinGain = f
+		}
+	}
+
+	return opts
+}
+
+// TestDatabaseSaturation seeds plugin_test_rpc over the raw connection, unless in read-only mode,
+// and then searches for the worker count at which each connection type stops scaling.
+func (p *Plugin) TestDatabaseSaturation(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, SaturationResponse{Error: err.Error()})
+		return
+	}
+	if opts.Mode != modeScan {
+		respondWithJSON(w, http.StatusBadRequest, SaturationResponse{Error: fmt.Sprintf("saturation runs only support %s mode", modeScan)})
+		return
+	}
+	satOpts := parseSaturationOptions(r)
+
+	response := SaturationResponse{
+		Label:   opts.Label,
+		Results: []SaturationResult{},
+	}
+
+	rawDB, rawDriverName, err := p.openRawConnection(opts)
+	if err != nil {
+		p.API.LogError("Failed to connect to database directly", "error", err)
+		response.Error = fmt.Sprintf("Failed to connect to database: %v", err)
+		respondWithJSON(w, http.StatusInternalServerError, response)
+		return
+	}
+	defer rawDB.Close()
+	parsePoolSettings(r).apply(rawDB)
+
+	if opts.Phase != phaseQuery && !p.readOnly() {
+		if _, _, err := p.seedTestTable(rawDB, rawDriverName, opts); err != nil {
+			p.API.LogError("Failed to seed saturation run", "error", err)
+			response.Error = err.Error()
+			respondWithJSON(w, http.StatusInternalServerError, response)
+			return
+		}
+	}
+
+	rpcResult := SaturationResult{ConnType: "rpc", Levels: []SaturationLevel{}}
+	if db, err := p.rpcDB(); err != nil {
+		rpcResult.Error = fmt.Sprintf("Failed to get database: %v", err)
+	} else {
+		discoverSaturation(db, p.client.Store.DriverName(), opts, satOpts, &rpcResult)
+	}
+	response.Results = append(response.Results, rpcResult)
+
+	rawResult := SaturationResult{ConnType: "raw", Levels: []SaturationLevel{}}
+	discoverSaturation(rawDB, rawDriverName, opts, satOpts, &rawResult)
+	response.Results = append(response.Results, rawResult)
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// discoverSaturation measures the page query on db at doubling worker counts, recording the
+// levels and the discovered saturation point on result.
+func discoverSaturation(db *sql.DB, driverName string, opts testOptions, satOpts saturationOptions, result *SaturationResult) {
+	operation := randomPageRead(db, driverName, opts)
+
+	findSaturation(satOpts, result, func(workers int) SaturationLevel {
+		return measureLevel(workers, satOpts.StepDuration, operation)
+	})
+}
+
+// randomPageRead returns the operation run by each concurrent worker: reading a page of
+// opts.PageSize rows of plugin_test_rpc from a random starting id.
+func randomPageRead(db *sql.DB, driverName string, opts testOptions) func(random *rand.Rand) error {
+	querySQL := opts.tagSQL(rebind(driverName, "SELECT id, data FROM plugin_test_rpc WHERE id > ? ORDER BY id LIMIT ?"))
+
+	return func(random *rand.Rand) error {
+		rows, err := db.Query(querySQL, random.Intn(opts.Records), opts.PageSize)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int
+			var data string
+			if err := rows.Scan(&id, &data); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}
+}
+
+// findSaturation doubles the worker count from one, measuring each level with measure, until
+// throughput improves by less than satOpts.MinGain, a level breaches the error rate or latency
+// SLO, or satOpts.MaxWorkers is reached. The saturation point is the last level that both met
+// the SLO and improved throughput.
+func findSaturation(satOpts saturationOptions, result *SaturationResult, measure func(workers int) SaturationLevel) {
+	for workers := 1; ; workers *= 2 {
+		workers = min(workers, satOpts.MaxWorkers)
+
+		level := measure(workers)
+		result.Levels = append(result.Levels, level)
+
+		if level.Operations == 0 || float64(level.Errors)/float64(level.Operations) > satOpts.MaxErrorRate {
+			result.StopReason = stopErrorRate
+			return
+		}
+		if satOpts.MaxP99 > 0 && level.P99Millis > millis(satOpts.MaxP99) {
+			result.StopReason = stopLatency
+			return
+		}
+
+		if result.PeakOpsPerSecond > 0 && level.OpsPerSecond < result.PeakOpsPerSecond*(1+satOpts.MinGain) {
+			result.StopReason = stopPlateau
+			return
+		}
+		result.SaturationWorkers = level.Workers
+		result.PeakOpsPerSecond = level.OpsPerSecond
+
+		if workers >= satOpts.MaxWorkers {
+			result.StopReason = stopMaxWorker
+			return
+		}
+	}
+}
+
+// measureLevel runs operation in a loop on each of workers goroutines for duration, summarizing
+// the throughput and latency of the successful operations.
+func measureLevel(workers int, duration time.Duration, operation func(random *rand.Rand) error) SaturationLevel {
+	level := SaturationLevel{Workers: workers}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	var durations []time.Duration
+
+	deadline := time.Now().Add(duration)
+	start := time.Now()
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			random := rand.New(rand.NewSource(int64(worker)))
+			var workerDurations []time.Duration
+			workerErrors := 0
+			for time.Now().Before(deadline) {
+				operationStart := time.Now()
+				if err := operation(random); err != nil {
+					workerErrors++
+					continue
+				}
+				workerDurations = append(workerDurations, time.Since(operationStart))
+			}
+
+			lock.Lock()
+			durations = append(durations, workerDurations...)
+			level.Errors += workerErrors
+			lock.Unlock()
+		}(worker)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	level.Operations = len(durations) + level.Errors
+	if elapsed > 0 {
+		level.OpsPerSecond = float64(len(durations)) / elapsed.Seconds()
+	}
+
+	summary := summarizeLatencies(durations)
+	level.MinMillis = millis(summary.Min)
+	level.AvgMillis = millis(summary.Avg)
+	level.P99Millis = millis(summary.P99)
+
+	return level
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSaturationOptions(t *testing.T) {
+	opts := parseSaturationOptions(httptest.NewRequest("GET", "/api/v1/test_saturation", nil))
+	assert.Equal(t, saturationOptions{
+		MaxWorkers:   defaultMaxWorkers,
+		StepDuration: defaultStepDuration,
+		MaxErrorRate: defaultMaxErrorRate,
+		MinGain:      defaultMinGain,
+	}, opts)
+
+	opts = parseSaturationOptions(httptest.NewRequest("GET", "/api/v1/test_saturation?max_workers=5000&step_duration=2h&max_error_rate=2&max_p99_ms=12.5&min_gain=0.1", nil))
+	assert.Equal(t, maxMaxWorkers, opts.MaxWorkers)
+	assert.Equal(t, maxStepDuration, opts.StepDuration)
+	assert.Equal(t, defaultMaxErrorRate, opts.MaxErrorRate)
+	assert.Equal(t, 12500*time.Microsecond, opts.MaxP99)
+	assert.Equal(t, 0.1, opts.MinGain)
+}
+
+func TestFindSaturation(t *testing.T) {
+	satOpts := saturationOptions{MaxWorkers: 64, MaxErrorRate: 0.01, MinGain: 0.05, MaxP99: 50 * time.Millisecond}
+
+	t.Run("plateau", func(t *testing.T) {
+		throughput := map[int]float64{1: 100, 2: 190, 4: 350, 8: 360}
+		var result SaturationResult
+		findSaturation(satOpts, &result, func(workers int) SaturationLevel {
+			return SaturationLevel{Workers: workers, Operations: 100, OpsPerSecond: throughput[workers]}
+		})
+		assert.Equal(t, stopPlateau, result.StopReason)
+		assert.Equal(t, 4, result.SaturationWorkers)
+		assert.Equal(t, 350.0, result.PeakOpsPerSecond)
+		assert.Len(t, result.Levels, 4)
+	})
+
+	t.Run("latency slo", func(t *testing.T) {
+		var result SaturationResult
+		findSaturation(satOpts, &result, func(workers int) SaturationLevel {
+			return SaturationLevel{Workers: workers, Operations: 100, OpsPerSecond: float64(workers * 100), P99Millis: float64(workers * 10)}
+		})
+		assert.Equal(t, stopLatency, result.StopReason)
+		assert.Equal(t, 4, result.SaturationWorkers)
+	})
+
+	t.Run("error rate", func(t *testing.T) {
+		var result SaturationResult
+		findSaturation(satOpts, &result, func(workers int) SaturationLevel {
+			return SaturationLevel{Workers: workers, Operations: 100, Errors: workers, OpsPerSecond: float64(workers * 100)}
+		})
+		assert.Equal(t, stopErrorRate, result.StopReason)
+		assert.Equal(t, 1, result.SaturationWorkers)
+	})
+
+	t.Run("max workers", func(t *testing.T) {
+		var result SaturationResult
+		findSaturation(saturationOptions{MaxWorkers: 6, MaxErrorRate: 0.01, MinGain: 0.05}, &result, func(workers int) SaturationLevel {
+			return SaturationLevel{Workers: workers, Operations: 100, OpsPerSecond: float64(workers * 100)}
+		})
+		assert.Equal(t, stopMaxWorker, result.StopReason)
+		assert.Equal(t, 6, result.SaturationWorkers)
+		assert.Len(t, result.Levels, 4)
+	})
+}
+
+func TestMeasureLevel(t *testing.T) {
+	calls := 0
+	level := measureLevel(1, 20*time.Millisecond, func(random *rand.Rand) error {
+		calls++
+		time.Sleep(time.Millisecond)
+		if calls%2 == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	assert.Equal(t, 1, level.Workers)
+	assert.Equal(t, calls, level.Operations)
+	assert.Equal(t, calls/2, level.Errors)
+	assert.Greater(t, level.OpsPerSecond, 0.0)
+	assert.GreaterOrEqual(t, level.MinMillis, 1.0)
+}
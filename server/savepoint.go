@@ -0,0 +1,139 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// defaultSavepointRecords keeps the default savepoint run, six statements per round, short.
+const defaultSavepointRecords = 1000
+
+// runSavepointTest runs opts.Records rounds of nested savepoints within a single transaction
+// against plugin_test_rpc_savepoint, verifying that rolled back work is discarded and released
+// work kept, since store helpers rely on savepoints and the RPC driver's handling of them is
+// otherwise unverified. The transaction is rolled back at the end, so the table stays empty.
+func (p *Plugin) runSavepointTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label: opts.Label,
+		Mode:  modeSavepoint,
+		Phase: opts.Phase,
+	}
+
+	if opts.Phase != phaseQuery {
+		if err := createSavepointTable(db, driverName, opts); err != nil {
+			return result, err
+		}
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.runSavepoints(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// createSavepointTable creates plugin_test_rpc_savepoint if needed.
+func createSavepointTable(db *sql.DB, driverName string, opts testOptions) error {
+	createTableSQL := `
+		CREATE TABLE IF NOT EXISTS plugin_test_rpc_savepoint (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			round INT NOT NULL,
+			data VARCHAR(255) NOT NULL
+		)
+	`
+	if driverName == "postgres" {
+		createTableSQL = `
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_savepoint (
+				id SERIAL PRIMARY KEY,
+				round INT NOT NULL,
+				data VARCHAR(255) NOT NULL
+			)
+		`
+	}
+
+	if _, err := db.Exec(opts.tagSQL(createTableSQL)); err != nil {
+		return fmt.Errorf("failed to create savepoint table: %v", err)
+	}
+
+	return nil
+}
+
+// savepointRound returns the statements of round i: an outer savepoint around a kept insert,
+// and an inner savepoint around an insert that is always rolled back. Even rounds release the
+// outer savepoint, keeping their first insert, while odd rounds roll it back too.
+func savepointRound(i int) []string {
+	statements := []string{
+		"SAVEPOINT plugin_test_rpc_outer",
+		"INSERT INTO plugin_test_rpc_savepoint (round, data) VALUES (?, ?)",
+		"SAVEPOINT plugin_test_rpc_inner",
+		"INSERT INTO plugin_test_rpc_savepoint (round, data) VALUES (?, ?)",
+		"ROLLBACK TO SAVEPOINT plugin_test_rpc_inner",
+	}
+	if i%2 == 0 {
+		return append(statements, "RELEASE SAVEPOINT plugin_test_rpc_outer")
+	}
+
+	return append(statements, "ROLLBACK TO SAVEPOINT plugin_test_rpc_outer")
+}
+
+// savepointRowsKept is the number of rows that survive the first rounds rounds.
+func savepointRowsKept(rounds int) int {
+	return (rounds + 1) / 2
+}
+
+// runSavepoints runs the savepoint rounds in one transaction, recording their rate and latency
+// distribution on result, and fails unless exactly the released inserts remain visible.
+func (p *Plugin) runSavepoints(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		// Rolling back leaves the table empty for the next run.
+		if err := tx.Rollback(); err != nil {
+			p.API.LogError("Failed to rollback transaction", "error", err)
+		}
+	}()
+
+	durations := make([]time.Duration, 0, opts.Records)
+	startTotalQuery := time.Now()
+
+	for i := 0; i < opts.Records; i++ {
+		start := time.Now()
+		for _, statement := range savepointRound(i) {
+			var args []any
+			if statement[0] == 'I' {
+				args = []any{i, testData(i, opts.RowBytes)}
+			}
+			if _, err := tx.Exec(opts.tagSQL(rebind(driverName, statement)), args...); err != nil {
+				return fmt.Errorf("failed to run %q in round %d: %v", statement, i, err)
+			}
+		}
+		durations = append(durations, time.Since(start))
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+
+	var kept, oddRounds int
+	countSQL := "SELECT COUNT(*), COALESCE(SUM(round % 2), 0) FROM plugin_test_rpc_savepoint"
+	if err := tx.QueryRow(opts.tagSQL(countSQL)).Scan(&kept, &oddRounds); err != nil {
+		return fmt.Errorf("failed to count rows kept: %v", err)
+	}
+	if kept != savepointRowsKept(opts.Records) || oddRounds != 0 {
+		return fmt.Errorf("savepoints misbehaved: expected %d rows from even rounds only, found %d rows of which %d from odd rounds",
+			savepointRowsKept(opts.Records), kept, oddRounds)
+	}
+
+	result.Savepoints = 2 * opts.Records
+	result.RecordsQueried = kept
+	if result.TotalQueryTimeSeconds > 0 {
+		result.SavepointsPerSecond = float64(result.Savepoints) / result.TotalQueryTimeSeconds
+	}
+	latency := summarizeLatencies(durations).millis()
+	result.SavepointLatency = &latency
+
+	return result.setLatencyHistogram(durations)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavepointRound(t *testing.T) {
+	even := savepointRound(0)
+	assert.Equal(t, "RELEASE SAVEPOINT plugin_test_rpc_outer", even[len(even)-1])
+
+	odd := savepointRound(1)
+	assert.Equal(t, "ROLLBACK TO SAVEPOINT plugin_test_rpc_outer", odd[len(odd)-1])
+
+	inserts := 0
+	for _, statement := range even {
+		if strings.HasPrefix(statement, "INSERT") {
+			inserts++
+			assert.Equal(t, 2, strings.Count(statement, "?"))
+		}
+	}
+	assert.Equal(t, 2, inserts)
+}
+
+func TestSavepointRowsKept(t *testing.T) {
+	assert.Equal(t, 0, savepointRowsKept(0))
+	assert.Equal(t, 1, savepointRowsKept(1))
+	assert.Equal(t, 1, savepointRowsKept(2))
+	assert.Equal(t, 500, savepointRowsKept(1000))
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultMaxConnections is the highest connection count swept when max_connections is not
+	// given.
+	defaultMaxConnections = 32
+
+	// maxMaxConnections bounds the max_connections query param.
+	maxMaxConnections = 256
+)
+
+// scalingOptions captures the query params of a connection-count sweep.
+type scalingOptions struct {
+	MaxConnections int
+	StepDuration   time.Duration
+}
+
+// ScalingResult reports the sweep over one connection type.
+type ScalingResult struct {
+	ConnType string            `json:"conn_type"`
+	Levels   []SaturationLevel `json:"levels"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// ScalingResponse collects the sweep results for every connection type.
+type ScalingResponse struct {
+	Label   string          `json:"label,omitempty"`
+	Results []ScalingResult `json:"results"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// parseScalingOptions reads the sweep query params from r. Invalid values fall back to their
+// defaults.
+func parseScalingOptions(r *http.Request) scalingOptions {
+	opts := scalingOptions{
+		MaxConnections: defaultMaxConnections,
+		StepDuration:   defaultStepDuration,
+	}
+
+	query := r.URL.Query()
+	if value := query.Get("max_connections"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			opts.MaxConnections = min(n, maxMaxConnections)
+		}
+	}
+	if value := query.Get("step_duration"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			opts.StepDuration = min(d, maxStepDuration)
+		}
+	}
+
+	return opts
+}
+
+// scalingLevels returns the connection counts swept: powers of two up to, and always including,
+// maxConnections.
+func scalingLevels(maxConnections int) []int {
+	var levels []int
+	for n := 1; n < maxConnections; n *= 2 {
+		levels = append(levels, n)
+	}
+
+	return append(levels, maxConnections)
+}
+
+// TestDatabaseScaling repeats a fixed random page read at 1, 2, 4, … max_connections concurrent
+// connections on each connection type, returning throughput against concurrency. Unlike the
+// saturation search it always sweeps every level, so the curves can be compared directly.
+func (p *Plugin) TestDatabaseScaling(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, ScalingResponse{Error: err.Error()})
+		return
+	}
+	if opts.Mode != modeScan {
+		respondWithJSON(w, http.StatusBadRequest, ScalingResponse{Error: fmt.Sprintf("scaling runs only support %s mode", modeScan)})
+		return
+	}
+	scalingOpts := parseScalingOptions(r)
+
+	response := ScalingResponse{
+		Label:   opts.Label,
+		Results: []ScalingResult{},
+	}
+
+	rawDB, rawDriverName, err := p.openRawConnection(opts)
+	if err != nil {
+		p.API.LogError("Failed to connect to database directly", "error", err)
+		response.Error = fmt.Sprintf("Failed to connect to database: %v", err)
+		respondWithJSON(w, http.StatusInternalServerError, response)
+		return
+	}
+	defer rawDB.Close()
+
+	if opts.Phase != phaseQuery && !p.readOnly() {
+		if _, _, err := p.seedTestTable(rawDB, rawDriverName, opts); err != nil {
+			p.API.LogError("Failed to seed scaling run", "error", err)
+			response.Error = err.Error()
+			respondWithJSON(w, http.StatusInternalServerError, response)
+			return
+		}
+	}
+
+	rpcResult := ScalingResult{ConnType: "rpc", Levels: []SaturationLevel{}}
+	if db, err := p.rpcDB(); err != nil {
+		rpcResult.Error = fmt.Sprintf("Failed to get database: %v", err)
+	} else {
+		rpcResult.Levels = sweepConnections(db, p.client.Store.DriverName(), opts, scalingOpts, nil)
+	}
+	response.Results = append(response.Results, rpcResult)
+
+	// The raw pool is sized to each level, so the level is the number of database connections.
+	// The RPC connection's pool belongs to the server and cannot be sized from the plugin.
+	rawResult := ScalingResult{ConnType: "raw"}
+	rawResult.Levels = sweepConnections(rawDB, rawDriverName, opts, scalingOpts, func(connections int) {
+		rawDB.SetMaxOpenConns(connections)
+		rawDB.SetMaxIdleConns(connections)
+	})
+	response.Results = append(response.Results, rawResult)
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// sweepConnections measures the random page read on db at every scaling level, calling resize,
+// if given, before each.
+func sweepConnections(db *sql.DB, driverName string, opts testOptions, scalingOpts scalingOptions, resize func(connections int)) []SaturationLevel {
+	operation := randomPageRead(db, driverName, opts)
+
+	levels := []SaturationLevel{}
+	for _, connections := range scalingLevels(scalingOpts.MaxConnections) {
+		if resize != nil {
+			resize(connections)
+		}
+		levels = append(levels, measureLevel(connections, scalingOpts.StepDuration, operation))
+	}
+
+	return levels
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScalingOptions(t *testing.T) {
+	opts := parseScalingOptions(httptest.NewRequest("GET", "/api/v1/test_scaling", nil))
+	assert.Equal(t, scalingOptions{MaxConnections: defaultMaxConnections, StepDuration: defaultStepDuration}, opts)
+
+	opts = parseScalingOptions(httptest.NewRequest("GET", "/api/v1/test_scaling?max_connections=1000&step_duration=2s", nil))
+	assert.Equal(t, scalingOptions{MaxConnections: maxMaxConnections, StepDuration: 2 * time.Second}, opts)
+}
+
+func TestScalingLevels(t *testing.T) {
+	assert.Equal(t, []int{1}, scalingLevels(1))
+	assert.Equal(t, []int{1, 2, 4, 8}, scalingLevels(8))
+	assert.Equal(t, []int{1, 2, 4, 8, 10}, scalingLevels(10))
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// runDatabaseTest is a helper method that runs the database test with a given DB connection
+func (p *Plugin) runDatabaseTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeScan,
+		Phase:    opts.Phase,
+		RowBytes: opts.RowBytes,
+	}
+
+	p.API.LogInfo("Database driver", "name", driverName)
+
+	if opts.Phase != phaseQuery {
+		memory := startMemoryPhase(phaseSeed)
+		inserted, insertTime, err := p.seedTestTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+		result.setInsertMethod(opts)
+		if inserted > 0 {
+			result.Memory = append(result.Memory, memory.end(inserted))
+		}
+	}
+
+	if opts.RebuildIndex {
+		dropTime, buildTime, err := rebuildTestDataIndex(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.IndexDropTimeSeconds = dropTime.Seconds()
+		result.IndexBuildTimeSeconds = buildTime.Seconds()
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+
+		memory := startMemoryPhase(phaseQuery)
+		if err := p.queryTestTable(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		result.Memory = append(result.Memory, memory.end(result.RecordsQueried))
+	}
+
+	return result, nil
+}
+
+// maxRowBytes is the width of the data column in plugin_test_rpc.
+const maxRowBytes = 255
+
+// testData generates the data value for row i, padded or truncated to rowBytes when non-zero.
+func testData(i, rowBytes int) string {
+	data := fmt.Sprintf("Test data %d", i)
+	if rowBytes == 0 {
+		return data
+	}
+	if len(data) >= rowBytes {
+		return data[:rowBytes]
+	}
+
+	return data + strings.Repeat("x", rowBytes-len(data))
+}
+
+// createTestTable creates plugin_test_rpc if it does not exist yet.
+func createTestTable(db *sql.DB, driverName string, opts testOptions) error {
+	var createTableSQL string
+	if driverName == "postgres" {
+		createTableSQL = `
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc (
+				id SERIAL PRIMARY KEY,
+				data VARCHAR(255) NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)
+		`
+	} else if driverName == "sqlite" {
+		// An INTEGER PRIMARY KEY aliases the rowid, which is assigned automatically.
+		createTableSQL = `
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc (
+				id INTEGER PRIMARY KEY,
+				data VARCHAR(255) NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)
+		`
+	} else {
+		// MySQL syntax
+		createTableSQL = `
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				data VARCHAR(255) NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)
+		`
+	}
+
+	if _, err := db.Exec(opts.tagSQL(createTableSQL)); err != nil {
+		return fmt.Errorf("failed to create table: %v", err)
+	}
+
+	return nil
+}
+
+// seedTestTable creates plugin_test_rpc if needed and inserts rows until it holds opts.Records,
+// returning the number of rows inserted and the time spent inserting.
+func (p *Plugin) seedTestTable(db *sql.DB, driverName string, opts testOptions) (int, time.Duration, error) {
+	totalRecords := opts.Records
+	p.trackTables(opts, "plugin_test_rpc")
+
+	// Create test table (no timing metrics)
+	if err := createTestTable(db, driverName, opts); err != nil {
+		return 0, 0, err
+	}
+
+	// Check if we need to insert data
+	var count int
+	countSQL := "SELECT COUNT(*) FROM plugin_test_rpc"
+	err := db.QueryRow(opts.tagSQL(countSQL)).Scan(&count)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to check record count: %v", err)
+	}
+
+	if count >= totalRecords {
+		p.API.LogInfo(fmt.Sprintf("Table already has %d or more records", totalRecords))
+		return 0, 0, nil
+	}
+
+	p.API.LogInfo(fmt.Sprintf("Inserting records: %d of %d", count, totalRecords))
+	startInsert := time.Now()
+
+	// Use transaction for faster inserts
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	if opts.Bulk != "" || opts.InsertBatch > 1 {
+		if opts.Bulk != "" {
+			err = bulkLoadTestRows(tx, driverName, opts, count)
+		} else {
+			err = p.insertTestRowsBatched(tx, driverName, opts, count)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				p.API.LogError("Failed to rollback transaction", "error", rbErr)
+			}
+			return 0, 0, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+		}
+
+		return totalRecords - count, time.Since(startInsert), nil
+	}
+
+	var insertStmt *sql.Stmt
+	if driverName == "postgres" {
+		insertStmt, err = tx.Prepare(opts.tagSQL("INSERT INTO plugin_test_rpc (data) VALUES ($1)"))
+	} else {
+		insertStmt, err = tx.Prepare(opts.tagSQL("INSERT INTO plugin_test_rpc (data) VALUES (?)"))
+	}
+
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			p.API.LogError("Failed to rollback transaction", "error", rbErr)
+		}
+		return 0, 0, fmt.Errorf("failed to prepare statement: %v", err)
+	}
+	defer insertStmt.Close()
+
+	for i := count; i < totalRecords; i++ {
+		if err = opts.cancelled(); err == nil {
+			_, err = insertStmt.Exec(testData(i, opts.RowBytes))
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				p.API.LogError("Failed to rollback transaction", "error", rbErr)
+			}
+			return 0, 0, fmt.Errorf("failed to insert row %d: %v", i, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return totalRecords - count, time.Since(startInsert), nil
+}
+
+// insertTestRowsBatched tops plugin_test_rpc up to opts.Records rows from count using multi-row
+// INSERT statements of opts.InsertBatch rows each.
+func (p *Plugin) insertTestRowsBatched(tx *sql.Tx, driverName string, opts testOptions, count int) error {
+	batchSQL := opts.tagSQL(multiRowInsert(driverName, "plugin_test_rpc", []string{"data"}, opts.InsertBatch))
+	batchStmt, err := tx.Prepare(batchSQL)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch statement: %v", err)
+	}
+	defer batchStmt.Close()
+
+	for start := count; start < opts.Records; start += opts.InsertBatch {
+		if err := opts.cancelled(); err != nil {
+			return err
+		}
+		rows := min(opts.InsertBatch, opts.Records-start)
+
+		args := make([]any, 0, rows)
+		for i := start; i < start+rows; i++ {
+			args = append(args, testData(i, opts.RowBytes))
+		}
+
+		if rows == opts.InsertBatch {
+			_, err = batchStmt.Exec(args...)
+		} else {
+			// The final partial batch has its own shape, so run it unprepared.
+			_, err = tx.Exec(opts.tagSQL(multiRowInsert(driverName, "plugin_test_rpc", []string{"data"}, rows)), args...)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to insert rows %d-%d: %v", start, start+rows-1, err)
+		}
+	}
+
+	return nil
+}
+
+// queryTestTable pages through the first opts.Records rows of plugin_test_rpc with the paginator
+// selected by opts.Pagination, recording the total query time, including any preparation, and
+// the number of rows read on result. With opts.ThinkTime or opts.Jitter, it pauses between
+// pages, excluding the pauses from the query time. The latency of each page, including any
+// retries, is recorded in the result's latency histogram. Pages failing with a transient error
+// are retried as configured by opts.
+func (p *Plugin) queryTestTable(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+	result.PageSize = opts.PageSize
+
+	pager, err := newPaginator(db, driverName, opts, result)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := pager.Close(); err != nil {
+			p.API.LogError("Failed to close paginator", "pagination", pager.Name(), "error", err)
+		}
+	}()
+	result.Pagination = pager.Name()
+
+	thinker := newThinker(opts)
+	var durations []time.Duration
+	var offsets []int
+	for result.RecordsQueried < opts.Records {
+		if err := opts.cancelled(); err != nil {
+			return err
+		}
+		if result.RecordsQueried > 0 {
+			thinker.think()
+		}
+
+		limit := min(opts.PageSize, opts.Records-result.RecordsQueried)
+
+		// Rows and bytes are only counted once the whole page has been read, so a retried page
+		// is never counted twice.
+		var records, lastID int
+		var bytes int64
+		start := time.Now()
+		err := p.withRetries(opts, result, func() error {
+			records, bytes = 0, 0
+
+			rows, err := pager.Page(limit)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			// Read all rows to measure full query time
+			for rows.Next() {
+				var data string
+				if err := rows.Scan(&lastID, &data); err != nil {
+					return fmt.Errorf("failed to scan row: %w", err)
+				}
+				records++
+				bytes += int64(len(data))
+			}
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("failed to read rows after %d: %w", result.RecordsQueried, err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		durations = append(durations, time.Since(start))
+		offsets = append(offsets, result.RecordsQueried)
+		result.RecordsQueried += records
+		result.BytesQueried += bytes
+		pager.Advance(lastID, records)
+
+		// A short page means the table holds fewer rows than requested.
+		if records < limit {
+			break
+		}
+	}
+
+	result.TotalQueryTimeSeconds = (time.Since(startTotalQuery) - thinker.total).Seconds()
+	thinker.report(result)
+	result.setQueryThroughput()
+	result.LatencyTrend = latencyTrend(durations, offsets)
+
+	return result.setLatencyHistogram(durations)
+}
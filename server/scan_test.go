@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestData(t *testing.T) {
+	assert.Equal(t, "Test data 7", testData(7, 0))
+	assert.Equal(t, "Test data 7xxxxxxxx", testData(7, 19))
+	assert.Equal(t, "Test", testData(7, 4))
+	assert.Len(t, testData(49999, maxRowBytes), maxRowBytes)
+}
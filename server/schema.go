@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// resultSchemaVersion is the version of the TestResult JSON schema, reported as schema_version
+// so that tooling parsing results strictly can tell which fields to expect.
+//
+// The schema evolves under these rules:
+//   - Any change to the JSON of TestResult or a type nested in it, including adding an optional
+//     field, bumps resultSchemaVersion, so strict consumers see a new version rather than an
+//     unknown field. TestResultSchemaVersion fails until it is bumped.
+//   - Fields are added, never renamed, retyped or removed, so a consumer of one version can
+//     always read results of an older one. A field that must change is replaced by a new one and
+//     the old one kept until the next major release of the plugin.
+//   - Results encoded before schema_version existed carry none and decode as version 1.
+//
+// Version history:
+//  1. The first versioned schema.
+//  2. Adds environment.
+//  3. Adds server_stats.
+//  4. Adds table_sizes.
+//  5. Adds analyze and analyze_time_seconds.
+//  6. Adds reset.
+//  7. Adds dropped_tables.
+//  8. Adds latency_trend.
+//  9. Adds tags.
+//  10. Adds gomaxprocs, go_memory_limit_bytes and container_memory_limit_bytes to environment.
+//  11. Adds memory.
+//  12. Adds gc_count, gc_pause_total_ms and gc_pause_max_ms to memory.
+//  13. Adds profile.
+//  14. Adds noops.
+//  15. Adds query_log.
+const resultSchemaVersion = 15
+
+// MarshalJSON stamps every encoded result with the current schema version.
+func (r TestResult) MarshalJSON() ([]byte, error) {
+	type plainResult TestResult
+	r.SchemaVersion = resultSchemaVersion
+
+	return json.Marshal(plainResult(r))
+}
+
+// decodeTestResult decodes a result encoded by this or any earlier version of the plugin, such
+// as one stored with a run, refusing results of a newer schema whose fields it cannot know.
+func decodeTestResult(data []byte) (TestResult, error) {
+	var result TestResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, err
+	}
+
+	if result.SchemaVersion > resultSchemaVersion {
+		return result, fmt.Errorf("result schema version %d is newer than the supported version %d", result.SchemaVersion, resultSchemaVersion)
+	}
+	if result.SchemaVersion == 0 {
+		result.SchemaVersion = 1
+	}
+
+	return result, nil
+}
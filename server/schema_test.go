@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resultSchemaFingerprints records the fingerprint of the TestResult JSON schema at each
+// version. Add the new fingerprint here when bumping resultSchemaVersion.
+var resultSchemaFingerprints = map[int]string{
+	1:  "131f6ed931af40bb",
+	2:  "9a3c5d5487935f7f",
+	3:  "012b2fc2dfc4ea56",
+	4:  "cdbd743e0227bb55",
+	5:  "c1ecc7c7c8543eac",
+	6:  "f7ab8a6813d51069",
+	7:  "d46847093dfd2d5f",
+	8:  "ba0684731bffd940",
+	9:  "7c8f4ec4c10cb114",
+	10: "ff17c4300a59c5d2",
+	11: "78a6433789d99534",
+	12: "0df4d5205f5ab967",
+	13: "e8ecd7fcd0f094a8",
+	14: "05a01c32a334f8db",
+	15: "9b37540991d48e1b",
+}
+
+// schemaFields lists the JSON field paths and kinds of typ, recursing into nested types.
+func schemaFields(typ reflect.Type, path string, fields *[]string) {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		path += "[" + typ.Kind().String() + "]"
+		typ = typ.Elem()
+	}
+	*fields = append(*fields, path+":"+typ.Kind().String())
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schemaFields(field.Type, path+"."+name+"("+options+")", fields)
+	}
+}
+
+// resultSchemaFingerprint hashes the JSON schema of TestResult.
+func resultSchemaFingerprint() string {
+	var fields []string
+	schemaFields(reflect.TypeOf(TestResult{}), "", &fields)
+	sort.Strings(fields)
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+func TestResultSchemaVersion(t *testing.T) {
+	assert.Equal(t, resultSchemaFingerprints[resultSchemaVersion], resultSchemaFingerprint(),
+		"the TestResult JSON schema changed: bump resultSchemaVersion and record its fingerprint")
+}
+
+func TestTestResultMarshalJSON(t *testing.T) {
+	encoded, err := json.Marshal(TestResult{ConnType: "rpc"})
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(encoded, &fields))
+	assert.Equal(t, float64(resultSchemaVersion), fields["schema_version"])
+	assert.Equal(t, "rpc", fields["conn_type"])
+}
+
+func TestDecodeTestResult(t *testing.T) {
+	t.Run("current", func(t *testing.T) {
+		encoded, err := json.Marshal(TestResult{ConnType: "raw", RecordsQueried: 10})
+		require.NoError(t, err)
+
+		result, err := decodeTestResult(encoded)
+
+		require.NoError(t, err)
+		assert.Equal(t, TestResult{SchemaVersion: resultSchemaVersion, ConnType: "raw", RecordsQueried: 10}, result)
+	})
+
+	t.Run("unversioned", func(t *testing.T) {
+		result, err := decodeTestResult([]byte(`{"conn_type":"rpc","records_queried":5}`))
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.SchemaVersion)
+		assert.Equal(t, 5, result.RecordsQueried)
+	})
+
+	t.Run("newer", func(t *testing.T) {
+		_, err := decodeTestResult([]byte(`{"schema_version":1000}`))
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// testDataSearchIndex is the full-text index on plugin_test_rpc.data created by the search
+// workload.
+const testDataSearchIndex = "idx_plugin_test_rpc_data_fts"
+
+// searchQueriesPerKind is the number of queries run for each kind of search.
+const searchQueriesPerKind = 20
+
+// Kinds of full-text search run by the search workload, from least to most selective.
+const (
+	// searchCommon matches a word present in every row.
+	searchCommon = "common"
+
+	// searchPrefix matches a three-digit prefix shared by roughly a hundred rows.
+	searchPrefix = "prefix"
+
+	// searchSelective matches the full number of a single row.
+	searchSelective = "selective"
+)
+
+// searchKinds lists the kinds of search in the order they are run.
+var searchKinds = []string{searchCommon, searchPrefix, searchSelective}
+
+// SearchResult reports the timing of the queries of one kind of search.
+type SearchResult struct {
+	Kind         string  `json:"kind"`
+	Queries      int     `json:"queries"`
+	RowsReturned int     `json:"rows_returned"`
+	TimeSeconds  float64 `json:"time_seconds"`
+}
+
+// runSearchTest seeds plugin_test_rpc, ensures a full-text index on its data column, and then
+// runs full-text searches of varying selectivity, each returning at most opts.PageSize rows.
+func (p *Plugin) runSearchTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeSearch,
+		Phase:    opts.Phase,
+		PageSize: opts.PageSize,
+		RowBytes: opts.RowBytes,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedTestTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+		result.setInsertMethod(opts)
+
+		buildTime, err := ensureSearchIndex(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.IndexBuildTimeSeconds = buildTime.Seconds()
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := querySearches(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// ensureSearchIndex creates the full-text index on plugin_test_rpc.data if it is missing,
+// returning how long the build took, or zero if the index already existed.
+func ensureSearchIndex(db *sql.DB, driverName string, opts testOptions) (time.Duration, error) {
+	exists, err := indexExists(db, driverName, opts, testDataSearchIndex)
+	if err != nil || exists {
+		return 0, err
+	}
+
+	var createIndexSQL string
+	if driverName == "postgres" {
+		createIndexSQL = "CREATE INDEX " + testDataSearchIndex + " ON plugin_test_rpc USING GIN (to_tsvector('english', data))"
+	} else {
+		createIndexSQL = "CREATE FULLTEXT INDEX " + testDataSearchIndex + " ON plugin_test_rpc (data)"
+	}
+
+	start := time.Now()
+	if _, err := db.Exec(opts.tagSQL(createIndexSQL)); err != nil {
+		return 0, fmt.Errorf("failed to create search index: %v", err)
+	}
+
+	return time.Since(start), nil
+}
+
+// searchTerms returns the terms searched for by each query of kind, drawn from the numbers of
+// the second half of the first records rows so they remain selective.
+func searchTerms(kind string, records int) []string {
+	terms := make([]string, 0, searchQueriesPerKind)
+	for k := 0; k < searchQueriesPerKind; k++ {
+		number := strconv.Itoa(records/2 + k*(records-records/2)/searchQueriesPerKind)
+
+		switch kind {
+		case searchCommon:
+			terms = append(terms, "data")
+		case searchPrefix:
+			terms = append(terms, number[:min(len(number), 3)])
+		default:
+			terms = append(terms, number)
+		}
+	}
+
+	return terms
+}
+
+// searchSQL returns the driver-specific full-text search over plugin_test_rpc.data and the term
+// as the driver expects it. Numeric terms are matched as prefixes so that rows padded by
+// row_bytes, whose padding runs into the number, still match.
+func searchSQL(driverName, term string, prefix bool) (string, string) {
+	if driverName == "postgres" {
+		if prefix {
+			term += ":*"
+		}
+		return "SELECT id, data FROM plugin_test_rpc WHERE to_tsvector('english', data) @@ to_tsquery('english', $1) ORDER BY id LIMIT $2", term
+	}
+
+	if prefix {
+		term += "*"
+	}
+	return "SELECT id, data FROM plugin_test_rpc WHERE MATCH (data) AGAINST (? IN BOOLEAN MODE) ORDER BY id LIMIT ?", term
+}
+
+// querySearches runs searchQueriesPerKind queries of each kind of search, recording each kind's
+// timing on result along with the total time and the rows and bytes read.
+func querySearches(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+
+	for _, kind := range searchKinds {
+		search := SearchResult{Kind: kind}
+		start := time.Now()
+
+		for _, term := range searchTerms(kind, opts.Records) {
+			querySQL, arg := searchSQL(driverName, term, kind != searchCommon)
+
+			rows, err := db.Query(opts.tagSQL(querySQL), arg, opts.PageSize)
+			if err != nil {
+				return fmt.Errorf("failed to search for %q: %v", term, err)
+			}
+
+			for rows.Next() {
+				var id int
+				var data string
+				if err := rows.Scan(&id, &data); err != nil {
+					rows.Close()
+					return fmt.Errorf("failed to scan search result: %v", err)
+				}
+				search.RowsReturned++
+				result.RecordsQueried++
+				result.BytesQueried += int64(len(data))
+			}
+			if err := rows.Err(); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to read search for %q: %v", term, err)
+			}
+			rows.Close()
+
+			search.Queries++
+		}
+
+		search.TimeSeconds = time.Since(start).Seconds()
+		result.Searches = append(result.Searches, search)
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
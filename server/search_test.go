@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTerms(t *testing.T) {
+	common := searchTerms(searchCommon, defaultRecords)
+	assert.Len(t, common, searchQueriesPerKind)
+	assert.Equal(t, "data", common[0])
+
+	selective := searchTerms(searchSelective, defaultRecords)
+	assert.Equal(t, "25000", selective[0])
+	assert.Equal(t, "26250", selective[1])
+
+	prefix := searchTerms(searchPrefix, defaultRecords)
+	assert.Equal(t, "250", prefix[0])
+	assert.Equal(t, "262", prefix[1])
+
+	assert.Equal(t, "5", searchTerms(searchPrefix, 10)[0])
+}
+
+func TestSearchSQL(t *testing.T) {
+	query, term := searchSQL("postgres", "250", true)
+	assert.Contains(t, query, "to_tsquery('english', $1)")
+	assert.Equal(t, "250:*", term)
+
+	query, term = searchSQL("mysql", "250", true)
+	assert.Contains(t, query, "MATCH (data) AGAINST (? IN BOOLEAN MODE)")
+	assert.Equal(t, "250*", term)
+
+	_, term = searchSQL("mysql", "data", false)
+	assert.Equal(t, "data", term)
+}
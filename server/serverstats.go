@@ -0,0 +1,113 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// postgresDatabaseStats are the pg_stat_database counters of the current database snapshotted
+// around each run.
+var postgresDatabaseStats = []string{
+	"xact_commit", "xact_rollback",
+	"tup_returned", "tup_fetched", "tup_inserted", "tup_updated", "tup_deleted",
+	"blks_read", "blks_hit",
+	"temp_files", "temp_bytes",
+	"deadlocks",
+}
+
+// postgresBgwriterStats are the pg_stat_bgwriter counters snapshotted around each run, limited
+// to those present in every supported Postgres version.
+var postgresBgwriterStats = []string{
+	"buffers_clean", "maxwritten_clean", "buffers_alloc",
+}
+
+// mysqlStatusStats are the SHOW GLOBAL STATUS counters snapshotted around each run.
+var mysqlStatusStats = []string{
+	"Questions",
+	"Innodb_rows_read", "Innodb_rows_inserted", "Innodb_rows_updated", "Innodb_rows_deleted",
+	"Innodb_buffer_pool_read_requests", "Innodb_buffer_pool_reads", "Innodb_buffer_pool_pages_flushed",
+	"Handler_read_rnd_next",
+	"Created_tmp_tables", "Created_tmp_disk_tables", "Created_tmp_files",
+	"Bytes_received", "Bytes_sent",
+}
+
+// postgresStatsSQL reads postgresDatabaseStats and postgresBgwriterStats in one row, the
+// bgwriter counters prefixed with bgwriter_.
+func postgresStatsSQL() string {
+	columns := make([]string, 0, len(postgresDatabaseStats)+len(postgresBgwriterStats))
+	for _, name := range postgresDatabaseStats {
+		columns = append(columns, "d."+name)
+	}
+	for _, name := range postgresBgwriterStats {
+		columns = append(columns, "b."+name)
+	}
+
+	return "SELECT " + strings.Join(columns, ", ") + " FROM pg_stat_database d, pg_stat_bgwriter b WHERE d.datname = current_database()"
+}
+
+// snapshotServerStats reads the server-wide activity counters of the database behind db. SQLite
+// runs within the plugin and keeps no such counters, so none are returned for it.
+func snapshotServerStats(db *sql.DB, driverName string, opts testOptions) (map[string]int64, error) {
+	if driverName == "sqlite" {
+		return nil, nil
+	}
+
+	stats := make(map[string]int64)
+
+	if driverName == "postgres" {
+		names := append([]string{}, postgresDatabaseStats...)
+		for _, name := range postgresBgwriterStats {
+			names = append(names, "bgwriter_"+name)
+		}
+
+		values := make([]int64, len(names))
+		dest := make([]any, len(names))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := db.QueryRow(opts.tagSQL(postgresStatsSQL())).Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to read pg_stat_database: %v", err)
+		}
+		for i, name := range names {
+			stats[name] = values[i]
+		}
+
+		return stats, nil
+	}
+
+	statusSQL := "SHOW GLOBAL STATUS WHERE Variable_name IN ('" + strings.Join(mysqlStatusStats, "', '") + "')"
+	rows, err := db.Query(opts.tagSQL(statusSQL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read global status: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan global status: %v", err)
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			stats[name] = n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read global status: %v", err)
+	}
+
+	return stats, nil
+}
+
+// serverStatsDelta returns how far each counter in both snapshots advanced.
+func serverStatsDelta(before, after map[string]int64) map[string]int64 {
+	delta := make(map[string]int64, len(after))
+	for name, value := range after {
+		if start, ok := before[name]; ok {
+			delta[name] = value - start
+		}
+	}
+
+	return delta
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresStatsSQL(t *testing.T) {
+	assert.Equal(t,
+		"SELECT d.xact_commit, d.xact_rollback, d.tup_returned, d.tup_fetched, d.tup_inserted, d.tup_updated, d.tup_deleted, "+
+			"d.blks_read, d.blks_hit, d.temp_files, d.temp_bytes, d.deadlocks, b.buffers_clean, b.maxwritten_clean, b.buffers_alloc "+
+			"FROM pg_stat_database d, pg_stat_bgwriter b WHERE d.datname = current_database()",
+		postgresStatsSQL())
+}
+
+func TestServerStatsDelta(t *testing.T) {
+	before := map[string]int64{"tup_returned": 100, "temp_files": 2, "blks_hit": 50}
+	after := map[string]int64{"tup_returned": 350, "temp_files": 2, "blks_read": 7}
+
+	assert.Equal(t, map[string]int64{"tup_returned": 250, "temp_files": 0}, serverStatsDelta(before, after))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	shareddriver "github.com/mattermost/mattermost/server/public/shared/driver"
+)
+
+// maxSlowQueryArgLength caps the length of each argument logged with a slow query, so that
+// large payloads such as blobs do not flood the log.
+const maxSlowQueryArgLength = 100
+
+// slowQueryThreshold returns how long a statement may take before it is logged as slow, or zero
+// if slow queries are not logged.
+func (p *Plugin) slowQueryThreshold() time.Duration {
+	return time.Duration(p.getConfiguration().SlowQueryThresholdMs) * time.Millisecond
+}
+
+// logSlowQuery logs query as slow, with its args and duration, if it took longer than the slow
+// query threshold.
+func (p *Plugin) logSlowQuery(query timedQuery) {
+	threshold := p.slowQueryThreshold()
+	if threshold <= 0 || query.Elapsed <= threshold {
+		return
+	}
+
+	p.API.LogWarn("Slow query",
+		"sql", query.Query,
+		"args", formatQueryArgs(query.Args),
+		"duration_ms", millis(query.Elapsed),
+		"threshold_ms", millis(threshold),
+	)
+}
+
+// formatQueryArgs renders the args of a statement for the log, truncating long values and
+// summarizing binary ones by their length.
+func formatQueryArgs(args []driver.NamedValue) string {
+	formatted := make([]string, 0, len(args))
+	for _, arg := range args {
+		var value string
+		switch v := arg.Value.(type) {
+		case []byte:
+			value = fmt.Sprintf("<%d bytes>", len(v))
+		case string:
+			value = fmt.Sprintf("%q", v)
+		default:
+			value = fmt.Sprint(v)
+		}
+		if len(value) > maxSlowQueryArgLength {
+			value = value[:maxSlowQueryArgLength] + "..."
+		}
+		formatted = append(formatted, value)
+	}
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
+
+// instrumentConnector wraps connector so that every statement run over its connections is timed,
+// to be logged if slow when the slow query threshold is set, and recorded in queryLog when it is
+// not nil. Otherwise connector is returned unwrapped, so runs doing neither pay nothing for it.
+func (p *Plugin) instrumentConnector(connector driver.Connector, queryLog *queryLog) driver.Connector {
+	logSlowQueries := p.slowQueryThreshold() > 0
+	if !logSlowQueries && queryLog == nil {
+		return connector
+	}
+
+	return timedConnector{Connector: connector, log: func(query timedQuery) {
+		if logSlowQueries {
+			p.logSlowQuery(query)
+		}
+		if queryLog != nil {
+			queryLog.record(query)
+		}
+	}}
+}
+
+// rpcDB returns the RPC connection benchmarks run over. With the slow query threshold set, it is
+// a connection pool of the plugin's own over the same RPC driver, which times every statement;
+// otherwise it is the pool shared through the store service.
+func (p *Plugin) rpcDB() (*sql.DB, error) {
+	if p.slowQueryThreshold() <= 0 {
+		return p.client.Store.GetMasterDB()
+	}
+
+	p.slowQueryDBLock.Lock()
+	defer p.slowQueryDBLock.Unlock()
+
+	if p.slowQueryDB == nil {
+		p.slowQueryDB = sql.OpenDB(p.instrumentConnector(shareddriver.NewConnector(p.Driver, true), nil))
+	}
+	return p.slowQueryDB, nil
+}
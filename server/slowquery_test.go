@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogSlowQuery(t *testing.T) {
+	args := []driver.NamedValue{{Ordinal: 1, Value: "a"}}
+
+	t.Run("not configured", func(t *testing.T) {
+		p := Plugin{}
+		p.SetAPI(&plugintest.API{})
+		p.setConfiguration(&configuration{})
+
+		// The API mock fails the test on any call to LogWarn.
+		p.logSlowQuery(timedQuery{Query: "SELECT 1", Elapsed: time.Hour})
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		api := &plugintest.API{}
+		api.On("LogWarn", "Slow query", "sql", "SELECT n FROM t WHERE id = ?", "args", `["a"]`,
+			"duration_ms", 150.0, "threshold_ms", 100.0).Return().Once()
+		defer api.AssertExpectations(t)
+
+		p := Plugin{}
+		p.SetAPI(api)
+		p.setConfiguration(&configuration{SlowQueryThresholdMs: 100})
+
+		p.logSlowQuery(timedQuery{Query: "SELECT n FROM t WHERE id = ?", Args: args, Elapsed: 50 * time.Millisecond})
+		p.logSlowQuery(timedQuery{Query: "SELECT n FROM t WHERE id = ?", Args: args, Elapsed: 150 * time.Millisecond})
+	})
+}
+
+func TestInstrumentConnector(t *testing.T) {
+	p := Plugin{}
+	p.setConfiguration(&configuration{})
+	assert.Equal(t, fakeConnector{}, p.instrumentConnector(fakeConnector{}, nil))
+	require.IsType(t, timedConnector{}, p.instrumentConnector(fakeConnector{}, newQueryLog()))
+
+	api := &plugintest.API{}
+	api.On("LogWarn", "Slow query", "sql", "SELECT n FROM t", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	p.SetAPI(api)
+	p.setConfiguration(&configuration{SlowQueryThresholdMs: 1})
+	connector := p.instrumentConnector(fakeConnector{}, nil)
+	require.IsType(t, timedConnector{}, connector)
+
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	var n int
+	require.NoError(t, db.QueryRow("SELECT n FROM t").Scan(&n))
+}
+
+func TestFormatQueryArgs(t *testing.T) {
+	assert.Equal(t, "[]", formatQueryArgs(nil))
+	assert.Equal(t, `[1, "a", <3 bytes>, <nil>]`, formatQueryArgs([]driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: "a"},
+		{Ordinal: 3, Value: []byte("abc")},
+		{Ordinal: 4, Value: nil},
+	}))
+
+	long := formatQueryArgs([]driver.NamedValue{{Ordinal: 1, Value: strings.Repeat("x", 500)}})
+	assert.Len(t, long, len("[")+maxSlowQueryArgLength+len("...]"))
+}
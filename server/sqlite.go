@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"modernc.org/sqlite"
+)
+
+// sqliteModes are the workloads that run on SQLite. The others rely on column types, locking or
+// server-side settings that only Postgres and MySQL provide.
+var sqliteModes = []string{modeScan, modePointLookup, modeFullScan, modeAggregate, modeNoop, modeSquirrel}
+
+// checkSQLiteMode returns an error if the workload selected by opts cannot run on driverName.
+func checkSQLiteMode(driverName string, opts testOptions) error {
+	if driverName != "sqlite" {
+		return nil
+	}
+
+	for _, mode := range sqliteModes {
+		if opts.Mode == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("mode %s is not supported on sqlite, use one of %s", opts.Mode, strings.Join(sqliteModes, ", "))
+}
+
+// sqliteConnector opens connections to the SQLite database at dataSource, since the SQLite
+// driver provides no connector of its own.
+type sqliteConnector struct {
+	dataSource string
+}
+
+func (c sqliteConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.Driver().Open(c.dataSource)
+}
+
+func (sqliteConnector) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+// sqliteDataSource appends options to the query params of dataSource, a file name or URI such as
+// "file:/tmp/bench.db?_pragma=busy_timeout(5000)", overriding any it already sets.
+func sqliteDataSource(dataSource string, options map[string]string) (string, error) {
+	if len(options) == 0 {
+		return dataSource, nil
+	}
+
+	name, rawQuery, _ := strings.Cut(dataSource, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse sqlite data source")
+	}
+
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		query.Set(key, options[key])
+	}
+
+	return name + "?" + query.Encode(), nil
+}
+
+// sqliteTableSizesSQL reads the size of every test table in the database from the dbstat
+// virtual table, counting the pages of each table and of its indexes. SQLite keeps no row
+// estimates, so rows are reported as zero.
+const sqliteTableSizesSQL = `
+	WITH sizes AS (
+		SELECT t.name AS name,
+			COALESCE((SELECT SUM(pgsize) FROM dbstat WHERE name = t.name), 0) AS data_bytes,
+			COALESCE((SELECT SUM(s.pgsize) FROM dbstat s JOIN sqlite_master i ON i.name = s.name
+				WHERE i.type = 'index' AND i.tbl_name = t.name), 0) AS index_bytes,
+			COALESCE((SELECT SUM(unused) FROM dbstat WHERE name = t.name), 0) AS free_bytes
+		FROM sqlite_master t
+		WHERE t.type = 'table' AND t.name LIKE 'plugin\_test\_rpc%' ESCAPE '\'
+	)
+	SELECT name, data_bytes, index_bytes, data_bytes + index_bytes, 0, 0, free_bytes
+	FROM sizes
+	ORDER BY name
+`
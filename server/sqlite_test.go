@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeObjectStore accepts every registry and dataset update a run makes.
+type fakeObjectStore struct {
+	kvstore.KVStore
+}
+
+func (fakeObjectStore) DeleteObject(string, string) error { return nil }
+func (fakeObjectStore) SaveDataset(kvstore.Dataset) error { return nil }
+
+func TestSQLiteDataSource(t *testing.T) {
+	dataSource, err := sqliteDataSource("/tmp/bench.db", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/bench.db", dataSource)
+
+	dataSource, err = sqliteDataSource("file:/tmp/bench.db?_txlock=deferred&mode=rwc", map[string]string{"_txlock": "immediate", "_pragma": "busy_timeout(5000)"})
+	require.NoError(t, err)
+	assert.Equal(t, "file:/tmp/bench.db?_pragma=busy_timeout%285000%29&_txlock=immediate&mode=rwc", dataSource)
+
+	_, err = rawConnector("sqlite", "/tmp/bench.db", "", map[string]string{"sslmode": "disable"})
+	assert.Error(t, err)
+}
+
+func TestCheckSQLiteMode(t *testing.T) {
+	assert.NoError(t, checkSQLiteMode("sqlite", testOptions{Mode: modeScan}))
+	assert.NoError(t, checkSQLiteMode("sqlite", testOptions{Mode: modePointLookup}))
+	assert.ErrorContains(t, checkSQLiteMode("sqlite", testOptions{Mode: modeJSON}), "not supported on sqlite")
+	assert.NoError(t, checkSQLiteMode("postgres", testOptions{Mode: modeJSON}))
+}
+
+func TestRunWorkloadOnSQLite(t *testing.T) {
+	config := &model.Config{}
+	config.SetDefaults()
+
+	api := &plugintest.API{}
+	api.On("GetUnsanitizedConfig").Return(config)
+	api.On("GetServerVersion").Return("10.0.0")
+	api.On("GetBundlePath").Return(t.TempDir(), nil)
+	api.On("LogWarn", "Failed to read plugin manifest", mock.Anything, mock.Anything).Return()
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	api.On("LogInfo", mock.Anything).Return().Maybe()
+
+	p := Plugin{kvstore: fakeObjectStore{}}
+	p.SetAPI(api)
+	p.setConfiguration(&configuration{EnableRawMode: true})
+
+	dsn := filepath.Join(t.TempDir(), "bench.db")
+	query := url.Values{
+		"dsn":          {dsn},
+		"dsn_driver":   {"sqlite"},
+		"records":      {"50"},
+		"page_size":    {"20"},
+		"insert_batch": {"10"},
+		"keep_data":    {"true"},
+		"explain":      {"true"},
+	}
+
+	opts, err := parseTestOptions(httptest.NewRequest(http.MethodGet, "/api/v1/test_raw?"+query.Encode(), nil))
+	require.NoError(t, err)
+
+	db, driverName, err := p.openRawConnection(opts)
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, "sqlite", driverName)
+
+	result, err := p.runWorkload(db, driverName, opts)
+	require.NoError(t, err)
+	assert.Equal(t, flavorSQLite, result.DatabaseFlavor)
+	assert.NotEmpty(t, result.DatabaseVersion)
+	assert.Equal(t, 50, result.RecordsInserted)
+	assert.Equal(t, 50, result.RecordsQueried)
+	assert.NotEmpty(t, result.Explains)
+	require.Len(t, result.TableSizes, 1)
+	assert.Equal(t, "plugin_test_rpc", result.TableSizes[0].Table)
+	assert.NotZero(t, result.TableSizes[0].DataBytes)
+
+	// A reset empties the kept table, so it is seeded again from scratch.
+	opts.Reset = true
+	result, err = p.runWorkload(db, driverName, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 50, result.RecordsInserted)
+
+	// Workloads relying on other databases' features are refused before touching the database.
+	opts.Mode = modeJSON
+	_, err = p.runWorkload(db, driverName, opts)
+	assert.ErrorContains(t, err, "not supported on sqlite")
+}
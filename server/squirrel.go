@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// runSquirrelTest seeds plugin_test_rpc as scan mode does, then pages through it by id with
+// queries built by squirrel, as most of Mattermost's store code builds them, so that the cost of
+// the query builder can be weighed against the cost of the queries over each connection type.
+func (p *Plugin) runSquirrelTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeSquirrel,
+		Phase:    opts.Phase,
+		RowBytes: opts.RowBytes,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedTestTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+		result.setInsertMethod(opts)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := querySquirrelPages(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// squirrelPlaceholders returns the placeholder format squirrel must build queries in for
+// driverName, as Mattermost's store selects it.
+func squirrelPlaceholders(driverName string) sq.PlaceholderFormat {
+	if driverName == "postgres" {
+		return sq.Dollar
+	}
+	return sq.Question
+}
+
+// squirrelPage builds the query reading the page of limit rows of plugin_test_rpc after lastID.
+func squirrelPage(format sq.PlaceholderFormat, lastID, limit int) (string, []any, error) {
+	return sq.StatementBuilder.
+		PlaceholderFormat(format).
+		Select("id", "data").
+		From("plugin_test_rpc").
+		Where(sq.Gt{"id": lastID}).
+		OrderBy("id").
+		Limit(uint64(limit)).
+		ToSql()
+}
+
+// querySquirrelPages pages through the first opts.Records rows of plugin_test_rpc with queries
+// built by squirrel, recording on result the total query time, which includes building, the
+// time spent building alone, and the rows and bytes read. The first query is checked to carry
+// the placeholders the hand-written workloads use with driverName, so that a mismatched format
+// fails the run rather than being measured.
+func querySquirrelPages(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+	result.PageSize = opts.PageSize
+	format := squirrelPlaceholders(driverName)
+
+	var builderTime time.Duration
+	var durations []time.Duration
+	var offsets []int
+	lastID := 0
+	for result.RecordsQueried < opts.Records {
+		if err := opts.cancelled(); err != nil {
+			return err
+		}
+
+		limit := min(opts.PageSize, opts.Records-result.RecordsQueried)
+
+		start := time.Now()
+		query, args, err := squirrelPage(format, lastID, limit)
+		builderTime += time.Since(start)
+		if err != nil {
+			return fmt.Errorf("failed to build page query: %v", err)
+		}
+		if result.RecordsQueried == 0 {
+			if want := rebind(driverName, "?"); !strings.Contains(query, want) {
+				return fmt.Errorf("squirrel built %q without the %s placeholder %s expects", query, driverName, want)
+			}
+		}
+
+		rows, err := db.Query(opts.tagSQL(query), args...)
+		if err != nil {
+			return fmt.Errorf("failed to query rows after %d: %v", result.RecordsQueried, err)
+		}
+
+		records := 0
+		for rows.Next() {
+			var data string
+			if err := rows.Scan(&lastID, &data); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan row: %v", err)
+			}
+			records++
+			result.BytesQueried += int64(len(data))
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read rows after %d: %v", result.RecordsQueried, err)
+		}
+		durations = append(durations, time.Since(start))
+		offsets = append(offsets, result.RecordsQueried)
+		result.RecordsQueried += records
+
+		// A short page means the table holds fewer rows than requested.
+		if records < limit {
+			break
+		}
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.BuilderTimeSeconds = builderTime.Seconds()
+	result.setQueryThroughput()
+	result.LatencyTrend = latencyTrend(durations, offsets)
+
+	return result.setLatencyHistogram(durations)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSquirrelPage(t *testing.T) {
+	for _, driverName := range []string{"postgres", "mysql", "sqlite"} {
+		query, args, err := squirrelPage(squirrelPlaceholders(driverName), 40, 20)
+		require.NoError(t, err)
+		assert.Equal(t, rebind(driverName, "SELECT id, data FROM plugin_test_rpc WHERE id > ? ORDER BY id LIMIT 20"), query, driverName)
+		assert.Equal(t, []any{40}, args)
+	}
+	assert.Equal(t, sq.Dollar, squirrelPlaceholders("postgres"))
+}
+
+func TestQuerySquirrelPages(t *testing.T) {
+	db := sql.OpenDB(sqliteConnector{dataSource: filepath.Join(t.TempDir(), "bench.db")})
+	defer db.Close()
+
+	_, err := db.Exec("CREATE TABLE plugin_test_rpc (id INTEGER PRIMARY KEY, data VARCHAR(255) NOT NULL)")
+	require.NoError(t, err)
+	for i := 0; i < 45; i++ {
+		_, err = db.Exec("INSERT INTO plugin_test_rpc (data) VALUES (?)", testData(i, 0))
+		require.NoError(t, err)
+	}
+
+	t.Run("pages through the requested rows", func(t *testing.T) {
+		var result TestResult
+		require.NoError(t, querySquirrelPages(db, "sqlite", testOptions{Records: 30, PageSize: 20}, &result))
+		assert.Equal(t, 30, result.RecordsQueried)
+		assert.Equal(t, 20, result.PageSize)
+		assert.NotZero(t, result.BuilderTimeSeconds)
+		assert.GreaterOrEqual(t, result.TotalQueryTimeSeconds, result.BuilderTimeSeconds)
+	})
+
+	t.Run("stops at the end of the table", func(t *testing.T) {
+		var result TestResult
+		require.NoError(t, querySquirrelPages(db, "sqlite", testOptions{Records: 100, PageSize: 20}, &result))
+		assert.Equal(t, 45, result.RecordsQueried)
+	})
+}
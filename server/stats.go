@@ -0,0 +1,122 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// latencySummary describes the distribution of a set of operation latencies.
+type latencySummary struct {
+	Min time.Duration
+	Avg time.Duration
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// summarizeLatencies computes the latency summary for durations, which it sorts in place.
+func summarizeLatencies(durations []time.Duration) latencySummary {
+	if len(durations) == 0 {
+		return latencySummary{}
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+
+	return latencySummary{
+		Min: durations[0],
+		Avg: total / time.Duration(len(durations)),
+		P50: percentile(durations, 50),
+		P95: percentile(durations, 95),
+		P99: percentile(durations, 99),
+		Max: durations[len(durations)-1],
+	}
+}
+
+// LatencyMillis reports a latency distribution in fractional milliseconds.
+type LatencyMillis struct {
+	Min float64 `json:"min_ms"`
+	Avg float64 `json:"avg_ms"`
+	P50 float64 `json:"p50_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+}
+
+// millis converts the summary for JSON reporting.
+func (s latencySummary) millis() LatencyMillis {
+	return LatencyMillis{
+		Min: millis(s.Min),
+		Avg: millis(s.Avg),
+		P50: millis(s.P50),
+		P95: millis(s.P95),
+		P99: millis(s.P99),
+		Max: millis(s.Max),
+	}
+}
+
+// Variability summarizes repeated measurements of the same quantity.
+type Variability struct {
+	Samples []float64 `json:"samples"`
+	Mean    float64   `json:"mean"`
+	Stddev  float64   `json:"stddev"`
+
+	// CoefficientOfVariation is the standard deviation relative to the mean, comparable across
+	// quantities of different magnitudes.
+	CoefficientOfVariation float64 `json:"coefficient_of_variation"`
+}
+
+// summarizeVariability computes the mean and sample standard deviation of samples.
+func summarizeVariability(samples []float64) Variability {
+	variability := Variability{Samples: samples}
+	if len(samples) == 0 {
+		return variability
+	}
+
+	var total float64
+	for _, sample := range samples {
+		total += sample
+	}
+	variability.Mean = total / float64(len(samples))
+
+	if len(samples) > 1 {
+		var squares float64
+		for _, sample := range samples {
+			squares += (sample - variability.Mean) * (sample - variability.Mean)
+		}
+		variability.Stddev = math.Sqrt(squares / float64(len(samples)-1))
+	}
+	if variability.Mean != 0 {
+		variability.CoefficientOfVariation = variability.Stddev / variability.Mean
+	}
+
+	return variability
+}
+
+// percentile returns the nearest-rank percentile p (0-100] of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+
+	return sorted[rank-1]
+}
+
+// millis converts d to fractional milliseconds for JSON reporting.
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeLatencies(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, latencySummary{}, summarizeLatencies(nil))
+	})
+
+	t.Run("unsorted", func(t *testing.T) {
+		durations := make([]time.Duration, 0, 100)
+		for i := 100; i > 0; i-- {
+			durations = append(durations, time.Duration(i)*time.Millisecond)
+		}
+
+		summary := summarizeLatencies(durations)
+
+		assert.Equal(t, time.Millisecond, summary.Min)
+		assert.Equal(t, 50500*time.Microsecond, summary.Avg)
+		assert.Equal(t, 50*time.Millisecond, summary.P50)
+		assert.Equal(t, 95*time.Millisecond, summary.P95)
+		assert.Equal(t, 99*time.Millisecond, summary.P99)
+		assert.Equal(t, 100*time.Millisecond, summary.Max)
+		assert.Equal(t, 99.0, summary.millis().P99)
+	})
+}
+
+func TestSummarizeVariability(t *testing.T) {
+	variability := summarizeVariability([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	assert.Equal(t, 5.0, variability.Mean)
+	assert.InDelta(t, 2.138, variability.Stddev, 0.001)
+	assert.InDelta(t, 0.428, variability.CoefficientOfVariation, 0.001)
+
+	single := summarizeVariability([]float64{3})
+	assert.Equal(t, 3.0, single.Mean)
+	assert.Zero(t, single.Stddev)
+
+	assert.Zero(t, summarizeVariability(nil).Mean)
+}
@@ -0,0 +1,56 @@
+package kvstore
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// baselineKeyPrefix namespaces the regression baselines within the plugin's KV store.
+const baselineKeyPrefix = "baseline-"
+
+// Baseline records the run that later runs of the same series are compared against.
+type Baseline struct {
+	Series string          `json:"series"`
+	RunID  string          `json:"run_id"`
+	Params string          `json:"params"`
+	SetAt  int64           `json:"set_at"`
+	Result json.RawMessage `json:"result"`
+}
+
+// SaveBaseline stores baseline as the baseline of its series, replacing any previous one.
+func (kv Client) SaveBaseline(baseline Baseline) error {
+	if _, err := kv.client.KV.Set(baselineKeyPrefix+baseline.Series, baseline); err != nil {
+		return errors.Wrap(err, "failed to save baseline")
+	}
+	return nil
+}
+
+// GetBaseline returns the baseline of series, or nil if there is none.
+func (kv Client) GetBaseline(series string) (*Baseline, error) {
+	var baseline *Baseline
+	if err := kv.client.KV.Get(baselineKeyPrefix+series, &baseline); err != nil {
+		return nil, errors.Wrap(err, "failed to get baseline")
+	}
+	return baseline, nil
+}
+
+// ListBaselines returns the baseline of every series.
+func (kv Client) ListBaselines() ([]Baseline, error) {
+	keys, err := kv.listKeys(baselineKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	baselines := []Baseline{}
+	for _, key := range keys {
+		var baseline *Baseline
+		if err := kv.client.KV.Get(key, &baseline); err != nil {
+			return nil, errors.Wrapf(err, "failed to get baseline %s", key)
+		}
+		if baseline != nil {
+			baselines = append(baselines, *baseline)
+		}
+	}
+	return baselines, nil
+}
@@ -0,0 +1,86 @@
+package kvstore
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// datasetKeyPrefix namespaces the dataset registry within the plugin's KV store.
+const datasetKeyPrefix = "dataset-"
+
+// listKeysPerPage is the page size used when scanning the KV store for keys.
+const listKeysPerPage = 1000
+
+// Dataset describes a seeded benchmark dataset so that later query-phase runs can verify they
+// read exactly the data that was seeded.
+type Dataset struct {
+	Fingerprint      string `json:"fingerprint"`
+	Table            string `json:"table"`
+	Mode             string `json:"mode"`
+	Records          int    `json:"records"`
+	RowBytes         int    `json:"row_bytes,omitempty"`
+	PayloadBytes     int    `json:"payload_bytes,omitempty"`
+	GeneratorSeed    int64  `json:"generator_seed"`
+	GeneratorVersion int    `json:"generator_version"`
+	TotalBytes       int64  `json:"total_bytes"`
+	SeededAt         int64  `json:"seeded_at"`
+}
+
+// SaveDataset records dataset in the registry under its fingerprint.
+func (kv Client) SaveDataset(dataset Dataset) error {
+	if _, err := kv.client.KV.Set(datasetKeyPrefix+dataset.Fingerprint, dataset); err != nil {
+		return errors.Wrap(err, "failed to save dataset")
+	}
+	return nil
+}
+
+// GetDataset returns the dataset registered under fingerprint, or nil if there is none.
+func (kv Client) GetDataset(fingerprint string) (*Dataset, error) {
+	var dataset *Dataset
+	if err := kv.client.KV.Get(datasetKeyPrefix+fingerprint, &dataset); err != nil {
+		return nil, errors.Wrap(err, "failed to get dataset")
+	}
+	return dataset, nil
+}
+
+// ListDatasets returns every registered dataset.
+func (kv Client) ListDatasets() ([]Dataset, error) {
+	keys, err := kv.listKeys(datasetKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	datasets := []Dataset{}
+	for _, key := range keys {
+		var dataset *Dataset
+		if err := kv.client.KV.Get(key, &dataset); err != nil {
+			return nil, errors.Wrapf(err, "failed to get dataset %s", key)
+		}
+		if dataset != nil {
+			datasets = append(datasets, *dataset)
+		}
+	}
+	return datasets, nil
+}
+
+// listKeys returns every key in the plugin's KV store starting with prefix.
+func (kv Client) listKeys(prefix string) ([]string, error) {
+	var matched []string
+	for page := 0; ; page++ {
+		keys, err := kv.client.KV.ListKeys(page, listKeysPerPage)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list keys")
+		}
+
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				matched = append(matched, key)
+			}
+		}
+
+		if len(keys) < listKeysPerPage {
+			return matched, nil
+		}
+	}
+}
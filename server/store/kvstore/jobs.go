@@ -0,0 +1,79 @@
+package kvstore
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/pkg/errors"
+)
+
+// jobKeyPrefix namespaces benchmark job records within the plugin's KV store.
+const jobKeyPrefix = "job-"
+
+// Job records a benchmark run requested of any node of the cluster, from the time it is queued
+// or started until some time after it finishes.
+type Job struct {
+	ID         string `json:"id"`
+	NodeID     string `json:"node_id"`
+	Method     string `json:"method,omitempty"`
+	Endpoint   string `json:"endpoint"`
+	Params     string `json:"params,omitempty"`
+	UserID     string `json:"user_id,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	QueuedAt   int64  `json:"queued_at,omitempty"`
+	StartedAt  int64  `json:"started_at,omitempty"`
+	FinishedAt int64  `json:"finished_at,omitempty"`
+
+	// ResumeOf is the ID of the interrupted job this one resumes, and ResumedAs the ID of the
+	// job that resumed this one.
+	ResumeOf  string `json:"resume_of,omitempty"`
+	ResumedAs string `json:"resumed_as,omitempty"`
+}
+
+// SaveJob stores job under its ID, replacing any earlier record of it. A positive ttl expires
+// the record after that long.
+func (kv Client) SaveJob(job Job, ttl time.Duration) error {
+	if _, err := kv.client.KV.Set(jobKeyPrefix+job.ID, job, jobExpiry(ttl)...); err != nil {
+		return errors.Wrap(err, "failed to save job")
+	}
+	return nil
+}
+
+// UpdateJob replaces the record old of a job with job, reporting false if the record has changed
+// since old was read. A positive ttl expires the record after that long.
+func (kv Client) UpdateJob(old, job Job, ttl time.Duration) (bool, error) {
+	updated, err := kv.client.KV.Set(jobKeyPrefix+job.ID, job, append(jobExpiry(ttl), pluginapi.SetAtomic(old))...)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to update job")
+	}
+	return updated, nil
+}
+
+// jobExpiry returns the options expiring a job record after ttl, if positive.
+func jobExpiry(ttl time.Duration) []pluginapi.KVSetOption {
+	if ttl <= 0 {
+		return nil
+	}
+	return []pluginapi.KVSetOption{pluginapi.SetExpiry(ttl)}
+}
+
+// ListJobs returns every recorded job.
+func (kv Client) ListJobs() ([]Job, error) {
+	keys, err := kv.listKeys(jobKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []Job{}
+	for _, key := range keys {
+		var job *Job
+		if err := kv.client.KV.Get(key, &job); err != nil {
+			return nil, errors.Wrapf(err, "failed to get job %s", key)
+		}
+		if job != nil {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs, nil
+}
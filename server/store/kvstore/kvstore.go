@@ -1,6 +1,95 @@
 package kvstore
 
+import (
+	"time"
+)
+
 type KVStore interface {
 	// Define your methods here. This package is used to access the KVStore pluginapi methods.
 	GetTemplateData(userID string) (string, error)
+
+	// SaveDataset records a seeded dataset in the registry under its fingerprint.
+	SaveDataset(dataset Dataset) error
+
+	// GetDataset returns the dataset registered under fingerprint, or nil if there is none.
+	GetDataset(fingerprint string) (*Dataset, error)
+
+	// ListDatasets returns every registered dataset.
+	ListDatasets() ([]Dataset, error)
+
+	// GetRegressionStreak returns the number of consecutive regressed runs of a series.
+	GetRegressionStreak(series string) (int, error)
+
+	// SetRegressionStreak records the number of consecutive regressed runs of a series.
+	SetRegressionStreak(series string, streak int) error
+
+	// SaveRun stores a benchmark run under its ID.
+	SaveRun(run Run) error
+
+	// GetRun returns the run stored under id, or nil if there is none.
+	GetRun(id string) (*Run, error)
+
+	// ListRuns returns every stored run.
+	ListRuns() ([]Run, error)
+
+	// DeleteRun removes a stored run along with any profile or query log recorded during it.
+	DeleteRun(id string) error
+
+	// SaveProfile stores the pprof profile captured during a run.
+	SaveProfile(runID string, profile []byte) error
+
+	// GetProfile returns the pprof profile captured during the run runID, or nil if there is none.
+	GetProfile(runID string) ([]byte, error)
+
+	// SaveQueryLog stores the gzipped query log recorded during a run.
+	SaveQueryLog(runID string, queryLog []byte) error
+
+	// GetQueryLog returns the gzipped query log recorded during the run runID, or nil if there is
+	// none.
+	GetQueryLog(runID string) ([]byte, error)
+
+	// SaveBaseline stores the regression baseline of a series.
+	SaveBaseline(baseline Baseline) error
+
+	// GetBaseline returns the regression baseline of series, or nil if there is none.
+	GetBaseline(series string) (*Baseline, error)
+
+	// ListBaselines returns the regression baseline of every series.
+	ListBaselines() ([]Baseline, error)
+
+	// SaveLastScheduledRun records the most recent scheduled benchmark.
+	SaveLastScheduledRun(run ScheduledRun) error
+
+	// GetLastScheduledRun returns the most recent scheduled benchmark, or nil if there is none.
+	GetLastScheduledRun() (*ScheduledRun, error)
+
+	// SaveObject records a database object the plugin has created.
+	SaveObject(object Object) error
+
+	// ListObjects returns every database object the plugin has recorded creating.
+	ListObjects() ([]Object, error)
+
+	// DeleteObject removes a database object from the registry once dropped.
+	DeleteObject(kind, name string) error
+
+	// AcquireRunLock takes the cluster-wide lock allowing one benchmark run at a time.
+	AcquireRunLock(lock RunLock, ttl time.Duration) (bool, error)
+
+	// RefreshRunLock extends the expiry of the run lock while lock still holds it.
+	RefreshRunLock(lock RunLock, ttl time.Duration) (bool, error)
+
+	// ReleaseRunLock releases the run lock if lock still holds it.
+	ReleaseRunLock(lock RunLock) error
+
+	// GetRunLock returns the holder of the run lock, or nil if no run holds it.
+	GetRunLock() (*RunLock, error)
+
+	// SaveJob records a benchmark job, expiring the record after ttl when positive.
+	SaveJob(job Job, ttl time.Duration) error
+
+	// UpdateJob replaces the record old of a job unless it has changed since it was read.
+	UpdateJob(old, job Job, ttl time.Duration) (bool, error)
+
+	// ListJobs returns every recorded benchmark job.
+	ListJobs() ([]Job, error)
 }
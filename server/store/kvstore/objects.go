@@ -0,0 +1,58 @@
+package kvstore
+
+import (
+	"github.com/pkg/errors"
+)
+
+// objectKeyPrefix namespaces the registry of created database objects within the plugin's KV
+// store.
+const objectKeyPrefix = "object-"
+
+// Object records a database object the plugin has created, so that it can be dropped on
+// teardown even after the workload that created it has changed or been removed.
+type Object struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// objectKey returns the key of the object of kind named name.
+func objectKey(kind, name string) string {
+	return objectKeyPrefix + kind + "-" + name
+}
+
+// SaveObject records object in the registry, replacing any earlier record of it.
+func (kv Client) SaveObject(object Object) error {
+	if _, err := kv.client.KV.Set(objectKey(object.Kind, object.Name), object); err != nil {
+		return errors.Wrap(err, "failed to save object")
+	}
+	return nil
+}
+
+// ListObjects returns every registered object.
+func (kv Client) ListObjects() ([]Object, error) {
+	keys, err := kv.listKeys(objectKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []Object{}
+	for _, key := range keys {
+		var object *Object
+		if err := kv.client.KV.Get(key, &object); err != nil {
+			return nil, errors.Wrapf(err, "failed to get object %s", key)
+		}
+		if object != nil {
+			objects = append(objects, *object)
+		}
+	}
+	return objects, nil
+}
+
+// DeleteObject removes the object of kind named name from the registry.
+func (kv Client) DeleteObject(kind, name string) error {
+	if err := kv.client.KV.Delete(objectKey(kind, name)); err != nil {
+		return errors.Wrap(err, "failed to delete object")
+	}
+	return nil
+}
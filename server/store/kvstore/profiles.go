@@ -0,0 +1,26 @@
+package kvstore
+
+import (
+	"github.com/pkg/errors"
+)
+
+// profileKeyPrefix namespaces the profiles captured during runs within the KV store. Profiles are
+// kept apart from their runs so that listing runs does not read them.
+const profileKeyPrefix = "profile-"
+
+// SaveProfile stores the pprof profile captured during the run runID.
+func (kv Client) SaveProfile(runID string, profile []byte) error {
+	if _, err := kv.client.KV.Set(profileKeyPrefix+runID, profile); err != nil {
+		return errors.Wrap(err, "failed to save profile")
+	}
+	return nil
+}
+
+// GetProfile returns the pprof profile captured during the run runID, or nil if there is none.
+func (kv Client) GetProfile(runID string) ([]byte, error) {
+	var profile []byte
+	if err := kv.client.KV.Get(profileKeyPrefix+runID, &profile); err != nil {
+		return nil, errors.Wrap(err, "failed to get profile")
+	}
+	return profile, nil
+}
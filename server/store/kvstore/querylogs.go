@@ -0,0 +1,27 @@
+package kvstore
+
+import (
+	"github.com/pkg/errors"
+)
+
+// queryLogKeyPrefix namespaces the query logs recorded during runs within the KV store. Like
+// profiles, query logs are kept apart from their runs so that listing runs does not read them.
+const queryLogKeyPrefix = "query-log-"
+
+// SaveQueryLog stores the gzipped query log recorded during the run runID.
+func (kv Client) SaveQueryLog(runID string, queryLog []byte) error {
+	if _, err := kv.client.KV.Set(queryLogKeyPrefix+runID, queryLog); err != nil {
+		return errors.Wrap(err, "failed to save query log")
+	}
+	return nil
+}
+
+// GetQueryLog returns the gzipped query log recorded during the run runID, or nil if there is
+// none.
+func (kv Client) GetQueryLog(runID string) ([]byte, error) {
+	var queryLog []byte
+	if err := kv.client.KV.Get(queryLogKeyPrefix+runID, &queryLog); err != nil {
+		return nil, errors.Wrap(err, "failed to get query log")
+	}
+	return queryLog, nil
+}
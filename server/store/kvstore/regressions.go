@@ -0,0 +1,27 @@
+package kvstore
+
+import (
+	"github.com/pkg/errors"
+)
+
+// regressionStreakKeyPrefix namespaces the consecutive regression counters within the KV store.
+const regressionStreakKeyPrefix = "regression-streak-"
+
+// GetRegressionStreak returns the number of consecutive runs of series that breached their
+// regression criteria.
+func (kv Client) GetRegressionStreak(series string) (int, error) {
+	var streak int
+	if err := kv.client.KV.Get(regressionStreakKeyPrefix+series, &streak); err != nil {
+		return 0, errors.Wrap(err, "failed to get regression streak")
+	}
+	return streak, nil
+}
+
+// SetRegressionStreak records the number of consecutive runs of series that breached their
+// regression criteria.
+func (kv Client) SetRegressionStreak(series string, streak int) error {
+	if _, err := kv.client.KV.Set(regressionStreakKeyPrefix+series, streak); err != nil {
+		return errors.Wrap(err, "failed to set regression streak")
+	}
+	return nil
+}
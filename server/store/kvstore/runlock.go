@@ -0,0 +1,57 @@
+package kvstore
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/pkg/errors"
+)
+
+// runLockKey holds the lock of the benchmark run in progress within the plugin's KV store,
+// shared by every node of the cluster.
+const runLockKey = "run-lock"
+
+// RunLock identifies the benchmark run holding the run lock.
+type RunLock struct {
+	RunID     string `json:"run_id"`
+	NodeID    string `json:"node_id"`
+	Endpoint  string `json:"endpoint"`
+	StartedAt int64  `json:"started_at"`
+}
+
+// AcquireRunLock takes the run lock for lock, expiring after ttl, reporting false if another
+// run holds it.
+func (kv Client) AcquireRunLock(lock RunLock, ttl time.Duration) (bool, error) {
+	acquired, err := kv.client.KV.Set(runLockKey, lock, pluginapi.SetAtomic(nil), pluginapi.SetExpiry(ttl))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to acquire run lock")
+	}
+	return acquired, nil
+}
+
+// RefreshRunLock extends the expiry of the run lock held by lock to ttl from now, reporting
+// false if lock no longer holds it.
+func (kv Client) RefreshRunLock(lock RunLock, ttl time.Duration) (bool, error) {
+	refreshed, err := kv.client.KV.Set(runLockKey, lock, pluginapi.SetAtomic(lock), pluginapi.SetExpiry(ttl))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to refresh run lock")
+	}
+	return refreshed, nil
+}
+
+// ReleaseRunLock releases the run lock if lock still holds it.
+func (kv Client) ReleaseRunLock(lock RunLock) error {
+	if _, err := kv.client.KV.Set(runLockKey, nil, pluginapi.SetAtomic(lock)); err != nil {
+		return errors.Wrap(err, "failed to release run lock")
+	}
+	return nil
+}
+
+// GetRunLock returns the holder of the run lock, or nil if no run holds it.
+func (kv Client) GetRunLock() (*RunLock, error) {
+	var lock *RunLock
+	if err := kv.client.KV.Get(runLockKey, &lock); err != nil {
+		return nil, errors.Wrap(err, "failed to get run lock")
+	}
+	return lock, nil
+}
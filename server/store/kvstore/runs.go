@@ -0,0 +1,75 @@
+package kvstore
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// runKeyPrefix namespaces stored benchmark runs within the plugin's KV store.
+const runKeyPrefix = "run-"
+
+// Run records everything needed to re-execute a benchmark run exactly.
+type Run struct {
+	ID               string          `json:"id"`
+	ConnType         string          `json:"conn_type"`
+	Params           string          `json:"params"`
+	GeneratorVersion int             `json:"generator_version"`
+	ReplayOf         string          `json:"replay_of,omitempty"`
+	Notes            string          `json:"notes,omitempty"`
+	StartedAt        int64           `json:"started_at,omitempty"`
+	CreatedAt        int64           `json:"created_at"`
+	Result           json.RawMessage `json:"result"`
+}
+
+// SaveRun stores run under its ID.
+func (kv Client) SaveRun(run Run) error {
+	if _, err := kv.client.KV.Set(runKeyPrefix+run.ID, run); err != nil {
+		return errors.Wrap(err, "failed to save run")
+	}
+	return nil
+}
+
+// GetRun returns the run stored under id, or nil if there is none.
+func (kv Client) GetRun(id string) (*Run, error) {
+	var run *Run
+	if err := kv.client.KV.Get(runKeyPrefix+id, &run); err != nil {
+		return nil, errors.Wrap(err, "failed to get run")
+	}
+	return run, nil
+}
+
+// ListRuns returns every stored run.
+func (kv Client) ListRuns() ([]Run, error) {
+	keys, err := kv.listKeys(runKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []Run{}
+	for _, key := range keys {
+		var run *Run
+		if err := kv.client.KV.Get(key, &run); err != nil {
+			return nil, errors.Wrapf(err, "failed to get run %s", key)
+		}
+		if run != nil {
+			runs = append(runs, *run)
+		}
+	}
+	return runs, nil
+}
+
+// DeleteRun removes the run stored under id along with any profile or query log recorded during
+// it.
+func (kv Client) DeleteRun(id string) error {
+	if err := kv.client.KV.Delete(profileKeyPrefix + id); err != nil {
+		return errors.Wrap(err, "failed to delete profile")
+	}
+	if err := kv.client.KV.Delete(queryLogKeyPrefix + id); err != nil {
+		return errors.Wrap(err, "failed to delete query log")
+	}
+	if err := kv.client.KV.Delete(runKeyPrefix + id); err != nil {
+		return errors.Wrap(err, "failed to delete run")
+	}
+	return nil
+}
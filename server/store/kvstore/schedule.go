@@ -0,0 +1,33 @@
+package kvstore
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// lastScheduledRunKey holds the most recent scheduled benchmark within the plugin's KV store.
+const lastScheduledRunKey = "schedule-last-run"
+
+// ScheduledRun records a scheduled benchmark, so the schedule survives restarts.
+type ScheduledRun struct {
+	StartedAt int64           `json:"started_at"`
+	Report    json.RawMessage `json:"report"`
+}
+
+// SaveLastScheduledRun records run as the most recent scheduled benchmark.
+func (kv Client) SaveLastScheduledRun(run ScheduledRun) error {
+	if _, err := kv.client.KV.Set(lastScheduledRunKey, run); err != nil {
+		return errors.Wrap(err, "failed to save last scheduled run")
+	}
+	return nil
+}
+
+// GetLastScheduledRun returns the most recent scheduled benchmark, or nil if there is none.
+func (kv Client) GetLastScheduledRun() (*ScheduledRun, error) {
+	var run *ScheduledRun
+	if err := kv.client.KV.Get(lastScheduledRunKey, &run); err != nil {
+		return nil, errors.Wrap(err, "failed to get last scheduled run")
+	}
+	return run, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+)
+
+// objectKindTable is the kind of registered tables. Indexes and sequences the plugin creates all
+// belong to its tables, so dropping the tables drops them too.
+const objectKindTable = "table"
+
+// trackTables records tables created by a run with opts in the registry of created objects, so a
+// teardown can find them. Teardown only drops tables from the Mattermost database, so tables
+// created in an alternate dsn are not recorded. Failing to record them does not fail the run.
+func (p *Plugin) trackTables(opts testOptions, tables ...string) {
+	if opts.DSN != "" {
+		return
+	}
+
+	for _, table := range tables {
+		object := kvstore.Object{Kind: objectKindTable, Name: table, CreatedAt: time.Now().UnixMilli()}
+		if err := p.kvstore.SaveObject(object); err != nil {
+			p.API.LogWarn("Failed to record created table", "table", table, "error", err)
+		}
+	}
+}
+
+// TeardownResponse reports the tables dropped by Teardown.
+type TeardownResponse struct {
+	Dropped []string `json:"dropped"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Teardown drops every table the plugin has created, along with their indexes and sequences,
+// for a clean uninstall. Tables come from the registry of created objects and, to catch any
+// created before the registry existed, from the test tables found in the database. It is refused
+// in read-only mode.
+func (p *Plugin) Teardown(w http.ResponseWriter, r *http.Request) {
+	response := TeardownResponse{Dropped: []string{}}
+	if p.readOnly() {
+		response.Error = errReadOnlyMode.Error()
+		respondWithJSON(w, http.StatusForbidden, response)
+		return
+	}
+
+	db, err := p.client.Store.GetMasterDB()
+	if err != nil {
+		response.Error = fmt.Sprintf("Failed to get database: %v", err)
+		respondWithJSON(w, http.StatusInternalServerError, response)
+		return
+	}
+	driverName := p.client.Store.DriverName()
+
+	objects, err := p.kvstore.ListObjects()
+	if err != nil {
+		response.Error = err.Error()
+		respondWithJSON(w, http.StatusInternalServerError, response)
+		return
+	}
+	found, err := measureTableSizes(db, driverName, testOptions{})
+	if err != nil {
+		response.Error = err.Error()
+		respondWithJSON(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	tables := make(map[string]bool)
+	for _, object := range objects {
+		if object.Kind == objectKindTable {
+			tables[object.Name] = true
+		}
+	}
+	for _, size := range found {
+		tables[size.Table] = true
+	}
+	names := make([]string, 0, len(tables))
+	for table := range tables {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+
+	var failures []string
+	for _, table := range names {
+		if _, err := db.Exec("DROP TABLE IF EXISTS " + table); err != nil {
+			p.API.LogError("Failed to drop table", "table", table, "error", err)
+			failures = append(failures, fmt.Sprintf("%s: %v", table, err))
+			continue
+		}
+		response.Dropped = append(response.Dropped, table)
+
+		if err := p.kvstore.DeleteObject(objectKindTable, table); err != nil {
+			p.API.LogWarn("Failed to remove dropped table from the registry", "table", table, "error", err)
+		}
+	}
+
+	p.API.LogInfo("Tore down plugin tables", "dropped", strings.Join(response.Dropped, ","))
+	if len(failures) > 0 {
+		response.Error = "failed to drop " + strings.Join(failures, "; ")
+		respondWithJSON(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
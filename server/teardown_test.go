@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeardown(t *testing.T) {
+	p := Plugin{kvstore: &fakeRunLockStore{}}
+	p.SetAPI(adminAPI())
+	p.setConfiguration(&configuration{ReadOnlyMode: true})
+
+	serve := func(userID string) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/admin/teardown", nil)
+		if userID != "" {
+			r.Header.Set("Mattermost-User-ID", userID)
+		}
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(""))
+	assert.Equal(t, http.StatusForbidden, serve("user"))
+
+	// Even a system admin cannot tear down in read-only mode.
+	assert.Equal(t, http.StatusForbidden, serve("admin"))
+}
+
+// fakeRegistryStore records the database objects saved to the registry.
+type fakeRegistryStore struct {
+	kvstore.KVStore
+	objects []kvstore.Object
+}
+
+func (s *fakeRegistryStore) SaveObject(object kvstore.Object) error {
+	s.objects = append(s.objects, object)
+	return nil
+}
+
+func TestTrackTables(t *testing.T) {
+	store := &fakeRegistryStore{}
+	p := Plugin{kvstore: store}
+
+	p.trackTables(testOptions{}, "plugin_test_rpc", "plugin_test_rpc_blob")
+	require.Len(t, store.objects, 2)
+	assert.Equal(t, objectKindTable, store.objects[0].Kind)
+	assert.Equal(t, "plugin_test_rpc", store.objects[0].Name)
+	assert.Equal(t, "plugin_test_rpc_blob", store.objects[1].Name)
+
+	// Tables in an alternate data source are out of reach of the teardown.
+	p.trackTables(testOptions{DSN: "postgres://mmuser@replica/mattermost"}, "plugin_test_rpc")
+	assert.Len(t, store.objects, 2)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Character sets of the data generated in text mode, selectable with the charset query param.
+const (
+	// charsetASCII generates single-byte ASCII text, the baseline.
+	charsetASCII = "ascii"
+
+	// charsetUTF8MB4 generates text mixing CJK characters and emoji, which take three and four
+	// bytes in UTF-8 and need utf8mb4 rather than utf8 on MySQL.
+	charsetUTF8MB4 = "utf8mb4"
+)
+
+// textInsertBatch is the number of rows per multi-row INSERT when seeding plugin_test_rpc_text.
+const textInsertBatch = 100
+
+// multiByteFiller pads multi-byte values, mixing two, three and four byte characters.
+var multiByteFiller = []rune("数据é🚀テスト😀")
+
+// runTextTest seeds plugin_test_rpc_text with opts.Records values in opts.Charset and pages
+// through them, verifying that every value reads back unchanged and measuring whether multi-byte
+// text inflates transfer time. Rows of different character sets share the table and are told
+// apart by charset.
+func (p *Plugin) runTextTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeText,
+		Phase:    opts.Phase,
+		PageSize: opts.PageSize,
+		RowBytes: opts.RowBytes,
+		Charset:  opts.Charset,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedTextTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryTextTable(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// textData generates the value of row i in charset, padded or truncated to rowChars characters
+// when non-zero. Counting characters rather than bytes keeps values of either character set
+// the same length, so their byte sizes show the cost of the encoding.
+func textData(i int, charset string, rowChars int) string {
+	if charset != charsetUTF8MB4 {
+		return testData(i, rowChars)
+	}
+
+	data := []rune(fmt.Sprintf("テストデータ %d 🚀", i))
+	if rowChars == 0 {
+		return string(data)
+	}
+	if len(data) >= rowChars {
+		return string(data[:rowChars])
+	}
+
+	var builder strings.Builder
+	builder.WriteString(string(data))
+	for n := len(data); n < rowChars; n++ {
+		builder.WriteRune(multiByteFiller[n%len(multiByteFiller)])
+	}
+
+	return builder.String()
+}
+
+// seedTextTable creates plugin_test_rpc_text if needed and tops it up to opts.Records rows of
+// opts.Charset, returning the number of rows inserted and the time spent inserting.
+func (p *Plugin) seedTextTable(db *sql.DB, driverName string, opts testOptions) (int, time.Duration, error) {
+	var createTableSQL []string
+	if driverName == "postgres" {
+		createTableSQL = []string{`
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_text (
+				id SERIAL PRIMARY KEY,
+				charset VARCHAR(16) NOT NULL,
+				seq INTEGER NOT NULL,
+				data VARCHAR(255) NOT NULL
+			)
+		`,
+			"CREATE INDEX IF NOT EXISTS idx_plugin_test_rpc_text_seq ON plugin_test_rpc_text (charset, seq)",
+		}
+	} else {
+		// The table's character set is explicit, since emoji do not fit the legacy 3-byte utf8.
+		createTableSQL = []string{`
+			CREATE TABLE IF NOT EXISTS plugin_test_rpc_text (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				charset VARCHAR(16) NOT NULL,
+				seq INT NOT NULL,
+				data VARCHAR(255) NOT NULL,
+				INDEX idx_plugin_test_rpc_text_seq (charset, seq)
+			) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin
+		`}
+	}
+
+	for _, statement := range createTableSQL {
+		if _, err := db.Exec(opts.tagSQL(statement)); err != nil {
+			return 0, 0, fmt.Errorf("failed to create text table: %v", err)
+		}
+	}
+
+	var count int
+	countSQL := rebind(driverName, "SELECT COUNT(*) FROM plugin_test_rpc_text WHERE charset = ?")
+	if err := db.QueryRow(opts.tagSQL(countSQL), opts.Charset).Scan(&count); err != nil {
+		return 0, 0, fmt.Errorf("failed to check record count: %v", err)
+	}
+	if count >= opts.Records {
+		return 0, 0, nil
+	}
+
+	p.API.LogInfo(fmt.Sprintf("Inserting text records: %d of %d", count, opts.Records), "charset", opts.Charset)
+	startInsert := time.Now()
+
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	columns := []string{"charset", "seq", "data"}
+	for start := count; start < opts.Records; start += textInsertBatch {
+		rows := min(textInsertBatch, opts.Records-start)
+
+		args := make([]any, 0, rows*len(columns))
+		for seq := start; seq < start+rows; seq++ {
+			args = append(args, opts.Charset, seq, textData(seq, opts.Charset, opts.RowBytes))
+		}
+
+		if _, err := tx.Exec(opts.tagSQL(multiRowInsert(driverName, "plugin_test_rpc_text", columns, rows)), args...); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				p.API.LogError("Failed to rollback transaction", "error", rbErr)
+			}
+			return 0, 0, fmt.Errorf("failed to insert rows %d-%d: %v", start, start+rows-1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return opts.Records - count, time.Since(startInsert), nil
+}
+
+// textPageSQL reads one page of the values of a character set.
+const textPageSQL = "SELECT seq, data FROM plugin_test_rpc_text WHERE charset = ? ORDER BY seq LIMIT ? OFFSET ?"
+
+// queryTextTable pages through the values of opts.Charset, recording the rows, bytes and
+// characters read on result, and fails on the first value that does not read back as written.
+func queryTextTable(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+
+	querySQL := opts.tagSQL(rebind(driverName, textPageSQL))
+	for offset := 0; offset < opts.Records; offset += opts.PageSize {
+		limit := min(opts.PageSize, opts.Records-offset)
+
+		rows, err := db.Query(querySQL, opts.Charset, limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to query rows at offset %d: %v", offset, err)
+		}
+
+		for rows.Next() {
+			var seq int
+			var data string
+			if err := rows.Scan(&seq, &data); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan row: %v", err)
+			}
+			if expected := textData(seq, opts.Charset, opts.RowBytes); data != expected {
+				rows.Close()
+				return fmt.Errorf("row %d read back as %q, expected %q", seq, data, expected)
+			}
+			result.RecordsQueried++
+			result.BytesQueried += int64(len(data))
+			result.CharsQueried += int64(utf8.RuneCountInString(data))
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read rows at offset %d: %v", offset, err)
+		}
+		rows.Close()
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextData(t *testing.T) {
+	assert.Equal(t, testData(7, 40), textData(7, charsetASCII, 40))
+	assert.Equal(t, "テストデータ 7 🚀", textData(7, charsetUTF8MB4, 0))
+
+	for _, rowChars := range []int{5, 40, maxRowBytes} {
+		data := textData(7, charsetUTF8MB4, rowChars)
+		assert.True(t, utf8.ValidString(data))
+		assert.Equal(t, rowChars, utf8.RuneCountInString(data))
+		assert.Greater(t, len(data), len(textData(7, charsetASCII, rowChars)))
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+// alertThreshold is an absolute limit on a metric of every run, such as
+// total_query_time_seconds>30.
+type alertThreshold struct {
+	Metric regressionMetric
+
+	// Above is true when values above Limit breach the threshold, and false for values below.
+	Above bool
+	Limit float64
+}
+
+// ThresholdBreach reports a metric of a run that breached a configured alert threshold.
+type ThresholdBreach struct {
+	Metric    string  `json:"metric"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	Value     float64 `json:"value"`
+}
+
+// parseAlertThresholds parses the AlertThresholds setting: comma or newline separated
+// conditions of a metric name, > or <, and a limit.
+func parseAlertThresholds(text string) ([]alertThreshold, error) {
+	var thresholds []alertThreshold
+	for _, condition := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\n' }) {
+		condition = strings.TrimSpace(condition)
+		if condition == "" {
+			continue
+		}
+
+		i := strings.IndexAny(condition, "<>")
+		if i < 0 {
+			return nil, errors.Errorf("alert threshold %q has no < or > operator", condition)
+		}
+
+		name := strings.TrimSpace(condition[:i])
+		metric, ok := findRegressionMetric(name)
+		if !ok {
+			return nil, errors.Errorf("alert threshold %q names unknown metric %q", condition, name)
+		}
+
+		limit, err := strconv.ParseFloat(strings.TrimSpace(condition[i+1:]), 64)
+		if err != nil {
+			return nil, errors.Errorf("alert threshold %q has an invalid limit", condition)
+		}
+
+		thresholds = append(thresholds, alertThreshold{Metric: metric, Above: condition[i] == '>', Limit: limit})
+	}
+
+	return thresholds, nil
+}
+
+// findRegressionMetric returns the metric called name.
+func findRegressionMetric(name string) (regressionMetric, bool) {
+	for _, metric := range regressionMetrics {
+		if metric.Name == name {
+			return metric, true
+		}
+	}
+
+	return regressionMetric{}, false
+}
+
+// check returns the breach of t by result, or nil. Metrics the run does not report never breach.
+func (t alertThreshold) check(result TestResult) *ThresholdBreach {
+	value := t.Metric.Value(result)
+	if value <= 0 {
+		return nil
+	}
+
+	breach := &ThresholdBreach{Metric: t.Metric.Name, Operator: "<", Threshold: t.Limit, Value: value}
+	if t.Above {
+		breach.Operator = ">"
+		if value > t.Limit {
+			return breach
+		}
+	} else if value < t.Limit {
+		return breach
+	}
+
+	return nil
+}
+
+// thresholdReason explains, in Markdown, which thresholds breaches breached.
+func thresholdReason(breaches []ThresholdBreach) string {
+	lines := make([]string, 0, len(breaches))
+	for _, breach := range breaches {
+		lines = append(lines, fmt.Sprintf("- `%s` was %g, breaching the alert threshold %s %g", breach.Metric, breach.Value, breach.Operator, breach.Threshold))
+	}
+
+	return "Breached alert thresholds:\n" + strings.Join(lines, "\n")
+}
+
+// postRegressionAlert posts alert to the configured alert channel and sends it as a direct
+// message to each configured admin, so degradations are noticed without reading results. It
+// does nothing for destinations left unconfigured, and only failing to post to the channel is
+// returned.
+func (p *Plugin) postRegressionAlert(alert regressionAlert) error {
+	config := p.getConfiguration()
+
+	title := fmt.Sprintf("#### Database benchmark regression: %s %s", alert.Result.ConnType, alert.Result.Mode)
+	if alert.Result.Label != "" {
+		title += " (" + alert.Result.Label + ")"
+	}
+	message := title + "\n" + alert.Reason
+
+	if channelID := strings.TrimSpace(config.AlertChannelID); channelID != "" {
+		post := p.resultPost(message, alert.Result)
+		post.ChannelId = channelID
+		if fileID := p.uploadLatencyTrend(alert.Result, channelID); fileID != "" {
+			post.FileIds = model.StringArray{fileID}
+		}
+		if err := p.client.Post.CreatePost(post); err != nil {
+			return errors.Wrap(err, "failed to post alert to channel")
+		}
+	}
+
+	for _, username := range strings.Split(config.AlertUsernames, ",") {
+		username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+		if username == "" {
+			continue
+		}
+
+		// A missing or unreachable admin must not keep the alert from the others.
+		user, err := p.client.User.GetByUsername(username)
+		if err != nil {
+			p.API.LogError("Failed to get alert recipient", "username", username, "error", err)
+			continue
+		}
+		if err := p.client.Post.DM(p.botUserID, user.Id, p.resultPost(message, alert.Result)); err != nil {
+			p.API.LogError("Failed to send alert", "username", username, "error", err)
+		}
+	}
+
+	return nil
+}
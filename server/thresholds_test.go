@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAlertThresholds(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		thresholds, err := parseAlertThresholds("total_query_time_seconds > 30,\nquery_rows_per_second<1000\n")
+
+		require.NoError(t, err)
+		require.Len(t, thresholds, 2)
+		assert.Equal(t, "total_query_time_seconds", thresholds[0].Metric.Name)
+		assert.True(t, thresholds[0].Above)
+		assert.Equal(t, 30.0, thresholds[0].Limit)
+		assert.Equal(t, "query_rows_per_second", thresholds[1].Metric.Name)
+		assert.False(t, thresholds[1].Above)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		thresholds, err := parseAlertThresholds(" ")
+
+		assert.NoError(t, err)
+		assert.Empty(t, thresholds)
+	})
+
+	for _, text := range []string{"total_query_time_seconds=30", "bogus>1", "total_query_time_seconds>fast"} {
+		t.Run(text, func(t *testing.T) {
+			_, err := parseAlertThresholds(text)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAlertThresholdCheck(t *testing.T) {
+	thresholds, err := parseAlertThresholds("total_query_time_seconds>30, query_rows_per_second<1000, lookup_latency_p99_ms>5")
+	require.NoError(t, err)
+
+	result := TestResult{TotalQueryTimeSeconds: 31, QueryRowsPerSecond: 1500}
+
+	breach := thresholds[0].check(result)
+	require.NotNil(t, breach)
+	assert.Equal(t, ThresholdBreach{Metric: "total_query_time_seconds", Operator: ">", Threshold: 30, Value: 31}, *breach)
+
+	assert.Nil(t, thresholds[1].check(result))
+	assert.Nil(t, thresholds[2].check(result), "metrics the run does not report never breach")
+
+	assert.Contains(t, thresholdReason([]ThresholdBreach{*breach}), "`total_query_time_seconds` was 31")
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+)
+
+// timedQuery is a statement run over a timed connection.
+type timedQuery struct {
+	Query string
+	Args  []driver.NamedValue
+
+	// Start is when the statement was sent, and Elapsed how long it took, including reading
+	// every row it returned.
+	Start   time.Time
+	Elapsed time.Duration
+
+	// Rows is the number of rows a query returned, or the number of rows a statement affected.
+	Rows int64
+
+	Err error
+}
+
+// queryLogger is told of every statement run over a timed connection once it completes.
+type queryLogger func(query timedQuery)
+
+// timedConnector wraps the connections of a driver.Connector to time their statements.
+type timedConnector struct {
+	driver.Connector
+	log queryLogger
+}
+
+func (c timedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{Conn: conn, log: c.log}, nil
+}
+
+// timedConn times the statements run over a driver connection. It implements every optional
+// interface database/sql looks for, deferring to the wrapped connection where it implements them
+// and otherwise falling back as database/sql would, so wrapping a connection does not change how
+// its statements run.
+type timedConn struct {
+	driver.Conn
+	log queryLogger
+}
+
+func (c *timedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{Stmt: stmt, query: query, log: c.log}, nil
+}
+
+func (c *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("the driver does not support transaction options")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		logExec(c.log, timedQuery{Query: query, Args: args, Start: start}, result, err)
+	}
+	return result, err
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		return rows, err
+	}
+	return timeRows(c.log, timedQuery{Query: query, Args: args, Start: start}, rows, err)
+}
+
+func (c *timedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *timedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// timedStmt times the executions of a prepared statement.
+type timedStmt struct {
+	driver.Stmt
+	query string
+	log   queryLogger
+}
+
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedValues(args))
+	}
+	logExec(s.log, timedQuery{Query: s.query, Args: args, Start: start}, result, err)
+	return result, err
+}
+
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedValues(args))
+	}
+	return timeRows(s.log, timedQuery{Query: s.query, Args: args, Start: start}, rows, err)
+}
+
+func (s *timedStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// namedValues returns the values of args, for drivers predating named arguments.
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+// logExec tells log of a statement that has been executed, with the rows it affected.
+func logExec(log queryLogger, query timedQuery, result driver.Result, err error) {
+	query.Elapsed = time.Since(query.Start)
+	query.Err = err
+	if err == nil {
+		query.Rows, _ = result.RowsAffected()
+	}
+	log(query)
+}
+
+// timeRows returns rows timed until they are closed, or tells log of the query at once if it
+// failed.
+func timeRows(log queryLogger, query timedQuery, rows driver.Rows, err error) (driver.Rows, error) {
+	if err != nil {
+		query.Elapsed = time.Since(query.Start)
+		query.Err = err
+		log(query)
+		return nil, err
+	}
+	return &timedRows{Rows: rows, query: query, log: log}, nil
+}
+
+// timedRows counts the rows a query returns, telling log of the query once they are closed. Like
+// timedConn, it implements every optional interface database/sql looks for.
+type timedRows struct {
+	driver.Rows
+	query  timedQuery
+	log    queryLogger
+	closed bool
+}
+
+func (r *timedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.query.Rows++
+	case !errors.Is(err, io.EOF):
+		r.query.Err = err
+	}
+	return err
+}
+
+func (r *timedRows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.query.Elapsed = time.Since(r.query.Start)
+		r.log(r.query)
+	}
+	return err
+}
+
+func (r *timedRows) HasNextResultSet() bool {
+	if sets, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return sets.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *timedRows) NextResultSet() error {
+	if sets, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return sets.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (r *timedRows) ColumnTypeScanType(index int) reflect.Type {
+	if types, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return types.ColumnTypeScanType(index)
+	}
+	return reflect.TypeFor[any]()
+}
+
+func (r *timedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if types, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return types.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *timedRows) ColumnTypeLength(index int) (int64, bool) {
+	if types, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return types.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *timedRows) ColumnTypeNullable(index int) (bool, bool) {
+	if types, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return types.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *timedRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if types, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return types.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConnector connects to fakeConns, which support only prepared statements, as the oldest
+// drivers do.
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) { return &fakeRows{}, nil }
+
+type fakeRows struct{ done bool }
+
+func (*fakeRows) Columns() []string { return []string{"n"} }
+func (*fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func TestTimedConnector(t *testing.T) {
+	var timed []timedQuery
+	db := sql.OpenDB(timedConnector{Connector: fakeConnector{}, log: func(query timedQuery) {
+		timed = append(timed, query)
+	}})
+	defer db.Close()
+
+	_, err := db.Exec("UPDATE t SET n = ?", 2)
+	require.NoError(t, err)
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT n FROM t WHERE id = ?", "a").Scan(&n))
+	assert.Equal(t, 1, n)
+
+	rows, err := db.Query("SELECT n FROM t")
+	require.NoError(t, err)
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, "", types[0].DatabaseTypeName())
+	require.NoError(t, rows.Close())
+
+	require.Len(t, timed, 3)
+	assert.Equal(t, "UPDATE t SET n = ?", timed[0].Query)
+	assert.Equal(t, int64(2), timed[0].Args[0].Value)
+	assert.Equal(t, int64(1), timed[0].Rows)
+	assert.Equal(t, "SELECT n FROM t WHERE id = ?", timed[1].Query)
+	assert.Equal(t, "a", timed[1].Args[0].Value)
+	assert.Equal(t, int64(1), timed[1].Rows)
+	assert.Equal(t, "SELECT n FROM t", timed[2].Query)
+	assert.Zero(t, timed[2].Rows)
+	for _, query := range timed {
+		assert.False(t, query.Start.IsZero())
+		assert.NoError(t, query.Err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// timeoutDeadline is the deadline given to the slow query.
+	timeoutDeadline = 250 * time.Millisecond
+
+	// timeoutSleep is how long the slow query sleeps when nothing cancels it.
+	timeoutSleep = 3 * time.Second
+
+	// timeoutProbe bounds the query checking that the pool is still usable after a timeout.
+	timeoutProbe = 5 * time.Second
+)
+
+// Methods of bounding a query compared by the timeout workload.
+const (
+	timeoutContext   = "context"
+	timeoutStatement = "statement_timeout"
+)
+
+// TimeoutResult reports whether one method of bounding a slow query cut it short.
+type TimeoutResult struct {
+	Method         string  `json:"method"`
+	DeadlineMillis float64 `json:"deadline_ms"`
+	SleepMillis    float64 `json:"sleep_ms"`
+	ElapsedMillis  float64 `json:"elapsed_ms"`
+
+	// Cancelled is true when the query returned before it would have finished sleeping.
+	Cancelled bool `json:"cancelled"`
+
+	// ConnectionUsable is true when a query run right afterwards succeeded, showing the
+	// cancellation did not leave a broken connection in the pool.
+	ConnectionUsable bool   `json:"connection_usable"`
+	Error            string `json:"error,omitempty"`
+}
+
+// timeoutMethod bounds the slow query in one way.
+type timeoutMethod struct {
+	// Name identifies the method in results.
+	Name string
+
+	// Context bounds the query with a context deadline rather than a server-side setting.
+	Context bool
+
+	// Settings are statements run first in the same transaction.
+	Settings []string
+
+	// Query sleeps for timeoutSleep, returning a single integer column.
+	Query string
+}
+
+// timeoutMethods returns the ways of bounding a slow query: a context deadline, which only the
+// driver can act on, and the flavor-specific server-side statement timeout.
+func timeoutMethods(flavor string) []timeoutMethod {
+	sleep := timeoutSleep.Seconds()
+	deadline := timeoutDeadline.Milliseconds()
+
+	switch flavor {
+	case flavorPostgres:
+		query := fmt.Sprintf("SELECT 1 FROM pg_sleep(%g)", sleep)
+		return []timeoutMethod{
+			{Name: timeoutContext, Context: true, Query: query},
+			{Name: timeoutStatement, Settings: []string{fmt.Sprintf("SET LOCAL statement_timeout = %d", deadline)}, Query: query},
+		}
+	case flavorMariaDB:
+		query := fmt.Sprintf("SELECT SLEEP(%g)", sleep)
+		return []timeoutMethod{
+			{Name: timeoutContext, Context: true, Query: query},
+			{Name: timeoutStatement, Query: fmt.Sprintf("SET STATEMENT max_statement_time = %g FOR %s", timeoutDeadline.Seconds(), query)},
+		}
+	default:
+		return []timeoutMethod{
+			{Name: timeoutContext, Context: true, Query: fmt.Sprintf("SELECT SLEEP(%g)", sleep)},
+			{Name: timeoutStatement, Query: fmt.Sprintf("SELECT /*+ MAX_EXECUTION_TIME(%d) */ SLEEP(%g)", deadline, sleep)},
+		}
+	}
+}
+
+// runTimeoutTest runs a deliberately slow query under each timeout method and reports whether
+// it was cut short, since whether cancellation propagates over the RPC driver is unclear. It
+// needs no data and never writes.
+func (p *Plugin) runTimeoutTest(db *sql.DB, _ string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label: opts.Label,
+		Mode:  modeTimeout,
+		Phase: opts.Phase,
+	}
+
+	if opts.Phase == phaseSeed {
+		return result, nil
+	}
+
+	start := time.Now()
+	for _, method := range timeoutMethods(opts.Flavor) {
+		result.Timeouts = append(result.Timeouts, p.runTimeoutMethod(db, opts, method))
+	}
+	result.TotalQueryTimeSeconds = time.Since(start).Seconds()
+
+	return result, nil
+}
+
+// runTimeoutMethod runs the slow query bounded by method and then checks the pool still works.
+func (p *Plugin) runTimeoutMethod(db *sql.DB, opts testOptions, method timeoutMethod) TimeoutResult {
+	result := TimeoutResult{
+		Method:         method.Name,
+		DeadlineMillis: millis(timeoutDeadline),
+		SleepMillis:    millis(timeoutSleep),
+	}
+
+	ctx := context.Background()
+	if method.Context {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeoutDeadline)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := p.runSlowQuery(ctx, db, opts, method)
+	elapsed := time.Since(start)
+
+	result.ElapsedMillis = millis(elapsed)
+	result.Cancelled = elapsed < timeoutSleep
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	probeCtx, cancel := context.WithTimeout(context.Background(), timeoutProbe)
+	defer cancel()
+	var one int
+	result.ConnectionUsable = db.QueryRowContext(probeCtx, opts.tagSQL("SELECT 1")).Scan(&one) == nil
+
+	return result
+}
+
+// runSlowQuery runs method's settings and slow query with ctx in a transaction that is rolled
+// back afterwards.
+func (p *Plugin) runSlowQuery(ctx context.Context, db *sql.DB, opts testOptions, method timeoutMethod) error {
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: opts.isolationLevel()})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		// A cancelled query may already have ended the transaction.
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			p.API.LogWarn("Failed to rollback transaction", "error", err)
+		}
+	}()
+
+	for _, setting := range method.Settings {
+		if _, err := tx.ExecContext(ctx, opts.tagSQL(setting)); err != nil {
+			return fmt.Errorf("failed to apply %q: %v", setting, err)
+		}
+	}
+
+	var slept int
+	return tx.QueryRowContext(ctx, opts.tagSQL(method.Query)).Scan(&slept)
+}
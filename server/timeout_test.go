@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutMethods(t *testing.T) {
+	postgres := timeoutMethods(flavorPostgres)
+	require.Len(t, postgres, 2)
+	assert.True(t, postgres[0].Context)
+	assert.Equal(t, "SELECT 1 FROM pg_sleep(3)", postgres[0].Query)
+	assert.Equal(t, []string{"SET LOCAL statement_timeout = 250"}, postgres[1].Settings)
+
+	mysql := timeoutMethods(flavorMySQL)
+	require.Len(t, mysql, 2)
+	assert.Equal(t, "SELECT /*+ MAX_EXECUTION_TIME(250) */ SLEEP(3)", mysql[1].Query)
+
+	mariadb := timeoutMethods(flavorMariaDB)
+	require.Len(t, mariadb, 2)
+	assert.Equal(t, "SET STATEMENT max_statement_time = 0.25 FOR SELECT SLEEP(3)", mariadb[1].Query)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// defaultTimestampRecords keeps the default timestamp dataset small, since every value is checked.
+const defaultTimestampRecords = 1000
+
+// timestampInsertBatch is the number of rows per multi-row INSERT when seeding
+// plugin_test_rpc_time.
+const timestampInsertBatch = 100
+
+// timestampZones are the time zones values are written in, including offsets of half and
+// three quarters of an hour and both sides of UTC. Fixed offsets keep the values independent of
+// the tz database installed alongside the server.
+var timestampZones = []*time.Location{
+	time.UTC,
+	time.FixedZone("-05:00", -5*60*60),
+	time.FixedZone("+05:30", 5*60*60+30*60),
+	time.FixedZone("+12:45", 12*60*60+45*60),
+	time.FixedZone("-03:30", -(3*60*60 + 30*60)),
+	time.FixedZone("+14:00", 14*60*60),
+}
+
+// timestampBase is the earliest value written, well inside the range of MySQL's TIMESTAMP.
+var timestampBase = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Columns of plugin_test_rpc_time compared by the timestamps workload.
+const (
+	// timestampNaive holds wall clock times without a zone: TIMESTAMP on Postgres and DATETIME
+	// on MySQL. It must read back with the wall clock it was written with.
+	timestampNaive = "naive"
+
+	// timestampZoned holds instants: TIMESTAMPTZ on Postgres and TIMESTAMP on MySQL. It must
+	// read back as the same instant, in whatever zone.
+	timestampZoned = "zoned"
+)
+
+// naiveLayout parses timestamps returned as text, as the MySQL driver does without parseTime.
+const naiveLayout = "2006-01-02 15:04:05.999999"
+
+// TimestampResult reports how the values of one timestamp column read back.
+type TimestampResult struct {
+	Column     string `json:"column"`
+	ColumnType string `json:"column_type"`
+
+	// ScanType is the Go type the driver returned the values as.
+	ScanType   string `json:"scan_type,omitempty"`
+	Values     int    `json:"values"`
+	Mismatches int    `json:"mismatches"`
+
+	// MaxSkewSeconds is the largest difference between a value read and the one written,
+	// signed, positive when values read back later than written.
+	MaxSkewSeconds float64 `json:"max_skew_seconds,omitempty"`
+	SampleMismatch string  `json:"sample_mismatch,omitempty"`
+}
+
+// runTimestampsTest seeds plugin_test_rpc_time with opts.Records values written in a range of
+// time zones and pages through them, reporting under Timestamps whether each column reads back
+// exactly as written and any skew, since time handling differs between drivers.
+func (p *Plugin) runTimestampsTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeTimestamps,
+		Phase:    opts.Phase,
+		PageSize: opts.PageSize,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedTimestampTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryTimestampTable(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// timestampValue returns the value written to row seq, an instant with microsecond precision
+// expressed in one of timestampZones.
+func timestampValue(seq int) time.Time {
+	offset := time.Duration(seq%100000)*97*time.Minute + time.Duration(seq%1000000)*time.Microsecond
+	return timestampBase.Add(offset).In(timestampZones[seq%len(timestampZones)])
+}
+
+// timestampColumnTypes returns the SQL types of the naive and zoned columns for driverName.
+func timestampColumnTypes(driverName string) (string, string) {
+	if driverName == "postgres" {
+		return "TIMESTAMP(6)", "TIMESTAMPTZ(6)"
+	}
+
+	return "DATETIME(6)", "TIMESTAMP(6)"
+}
+
+// seedTimestampTable creates plugin_test_rpc_time if needed and tops it up to opts.Records rows,
+// returning the number of rows inserted and the time spent inserting.
+func (p *Plugin) seedTimestampTable(db *sql.DB, driverName string, opts testOptions) (int, time.Duration, error) {
+	naiveType, zonedType := timestampColumnTypes(driverName)
+	id := "id INT AUTO_INCREMENT PRIMARY KEY"
+	if driverName == "postgres" {
+		id = "id SERIAL PRIMARY KEY"
+	}
+	// The zoned column is nullable so MySQL does not give it an automatic default.
+	createTableSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS plugin_test_rpc_time (%s, seq INT NOT NULL UNIQUE, naive %s NOT NULL, zoned %s NULL)",
+		id, naiveType, zonedType)
+	if _, err := db.Exec(opts.tagSQL(createTableSQL)); err != nil {
+		return 0, 0, fmt.Errorf("failed to create timestamp table: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(opts.tagSQL("SELECT COUNT(*) FROM plugin_test_rpc_time")).Scan(&count); err != nil {
+		return 0, 0, fmt.Errorf("failed to check record count: %v", err)
+	}
+	if count >= opts.Records {
+		return 0, 0, nil
+	}
+
+	p.API.LogInfo(fmt.Sprintf("Inserting timestamp records: %d of %d", count, opts.Records))
+	startInsert := time.Now()
+
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	columns := []string{"seq", timestampNaive, timestampZoned}
+	for start := count; start < opts.Records; start += timestampInsertBatch {
+		rows := min(timestampInsertBatch, opts.Records-start)
+
+		args := make([]any, 0, rows*len(columns))
+		for seq := start; seq < start+rows; seq++ {
+			args = append(args, seq, timestampValue(seq), timestampValue(seq))
+		}
+
+		if _, err := tx.Exec(opts.tagSQL(multiRowInsert(driverName, "plugin_test_rpc_time", columns, rows)), args...); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				p.API.LogError("Failed to rollback transaction", "error", rbErr)
+			}
+			return 0, 0, fmt.Errorf("failed to insert rows %d-%d: %v", start, start+rows-1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return opts.Records - count, time.Since(startInsert), nil
+}
+
+// timestampPageSQL reads one page of plugin_test_rpc_time.
+const timestampPageSQL = "SELECT seq, naive, zoned FROM plugin_test_rpc_time ORDER BY seq LIMIT ? OFFSET ?"
+
+// queryTimestampTable pages through the first opts.Records rows of plugin_test_rpc_time,
+// comparing every value with the one written and recording the outcome per column on result.
+func queryTimestampTable(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	naiveType, zonedType := timestampColumnTypes(driverName)
+	naive := TimestampResult{Column: timestampNaive, ColumnType: naiveType}
+	zoned := TimestampResult{Column: timestampZoned, ColumnType: zonedType}
+
+	startTotalQuery := time.Now()
+
+	querySQL := opts.tagSQL(rebind(driverName, timestampPageSQL))
+	for offset := 0; offset < opts.Records; offset += opts.PageSize {
+		limit := min(opts.PageSize, opts.Records-offset)
+
+		rows, err := db.Query(querySQL, limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to query rows at offset %d: %v", offset, err)
+		}
+
+		for rows.Next() {
+			var seq int
+			var naiveValue, zonedValue any
+			if err := rows.Scan(&seq, &naiveValue, &zonedValue); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan row: %v", err)
+			}
+			naive.check(seq, naiveValue)
+			zoned.check(seq, zonedValue)
+			result.RecordsQueried++
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read rows at offset %d: %v", offset, err)
+		}
+		rows.Close()
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+	result.Timestamps = []TimestampResult{naive, zoned}
+
+	return nil
+}
+
+// check compares value, as scanned from the column of row seq, with the value written to it,
+// counting a mismatch and tracking the skew when they differ.
+func (r *TimestampResult) check(seq int, value any) {
+	r.Values++
+	if r.ScanType == "" {
+		r.ScanType = fmt.Sprintf("%T", value)
+	}
+
+	expected := timestampValue(seq)
+	scanned, err := scannedTime(value)
+	if err != nil {
+		r.mismatch(fmt.Sprintf("row %d: %v", seq, err))
+		return
+	}
+
+	var skew time.Duration
+	if r.Column == timestampNaive {
+		skew = wallClock(scanned).Sub(wallClock(expected))
+	} else {
+		skew = scanned.Sub(expected)
+	}
+	if skew == 0 {
+		return
+	}
+
+	r.mismatch(fmt.Sprintf("row %d: wrote %s, read %s", seq, expected.Format(time.RFC3339Nano), scanned.Format(time.RFC3339Nano)))
+	if math.Abs(skew.Seconds()) > math.Abs(r.MaxSkewSeconds) {
+		r.MaxSkewSeconds = skew.Seconds()
+	}
+}
+
+// mismatch counts a value that did not read back as written, keeping the first as a sample.
+func (r *TimestampResult) mismatch(sample string) {
+	r.Mismatches++
+	if r.SampleMismatch == "" {
+		r.SampleMismatch = sample
+	}
+}
+
+// scannedTime converts a scanned timestamp to a time.Time. Drivers that return text, such as
+// the MySQL driver without parseTime, are parsed as UTC.
+func scannedTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case []byte:
+		return time.ParseInLocation(naiveLayout, string(v), time.UTC)
+	case string:
+		return time.ParseInLocation(naiveLayout, v, time.UTC)
+	default:
+		return time.Time{}, fmt.Errorf("unsupported timestamp type %T", value)
+	}
+}
+
+// wallClock returns the wall clock reading of t as if it were in UTC, so readings taken in
+// different zones can be compared.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
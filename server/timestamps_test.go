@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampValue(t *testing.T) {
+	for seq := 0; seq < 2*len(timestampZones); seq++ {
+		value := timestampValue(seq)
+		assert.Equal(t, timestampZones[seq%len(timestampZones)], value.Location())
+		assert.Zero(t, value.Nanosecond()%1000, "values must fit microsecond precision")
+	}
+
+	assert.True(t, timestampValue(99999).Before(time.Date(2038, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestScannedTime(t *testing.T) {
+	parsed, err := scannedTime([]byte("2000-01-01 05:30:00.000001"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2000, 1, 1, 5, 30, 0, 1000, time.UTC), parsed)
+
+	_, err = scannedTime(int64(0))
+	assert.Error(t, err)
+}
+
+func TestTimestampResultCheck(t *testing.T) {
+	// Row 2 is written at +05:30.
+	written := timestampValue(2)
+
+	t.Run("zoned", func(t *testing.T) {
+		result := TimestampResult{Column: timestampZoned}
+
+		result.check(2, written.UTC())
+		assert.Zero(t, result.Mismatches)
+
+		result.check(2, written.Add(-time.Hour))
+		assert.Equal(t, 1, result.Mismatches)
+		assert.Equal(t, -3600.0, result.MaxSkewSeconds)
+		assert.Equal(t, 2, result.Values)
+		assert.Equal(t, "time.Time", result.ScanType)
+	})
+
+	t.Run("naive", func(t *testing.T) {
+		result := TimestampResult{Column: timestampNaive}
+
+		result.check(2, []byte(written.Format(naiveLayout)))
+		assert.Zero(t, result.Mismatches)
+
+		// A driver converting to UTC before writing shifts the wall clock by the zone offset.
+		result.check(2, written.UTC())
+		assert.Equal(t, 1, result.Mismatches)
+		assert.Equal(t, -5.5*3600, result.MaxSkewSeconds)
+		assert.NotEmpty(t, result.SampleMismatch)
+	})
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxLatencyPoints caps the points of a latency trend, so that the trend of a long scan stays
+// small enough to store with every run.
+const maxLatencyPoints = 200
+
+// Dimensions in pixels of latency trend charts and the margins holding their axis labels.
+const (
+	trendChartWidth  = 720
+	trendChartHeight = 320
+	trendMarginLeft  = 70
+	trendMarginRight = 20
+	trendMarginTop   = 30
+	trendMarginBot   = 40
+)
+
+// LatencyPoint is the latency of consecutive pages of a scan, starting from the page that
+// began Offset records into the scan.
+type LatencyPoint struct {
+	Offset int     `json:"offset"`
+	Pages  int     `json:"pages"`
+	Avg    float64 `json:"avg_ms"`
+	Max    float64 `json:"max_ms"`
+}
+
+// latencyTrend summarizes the latency of each page of a scan, where offsets[i] is the number of
+// records read before the page that took durations[i], into at most maxLatencyPoints points of
+// consecutive pages, in order.
+func latencyTrend(durations []time.Duration, offsets []int) []LatencyPoint {
+	if len(durations) == 0 {
+		return nil
+	}
+
+	perPoint := (len(durations) + maxLatencyPoints - 1) / maxLatencyPoints
+	points := make([]LatencyPoint, 0, (len(durations)+perPoint-1)/perPoint)
+	for start := 0; start < len(durations); start += perPoint {
+		end := min(start+perPoint, len(durations))
+
+		point := LatencyPoint{Offset: offsets[start], Pages: end - start}
+		var total time.Duration
+		for _, duration := range durations[start:end] {
+			total += duration
+			point.Max = max(point.Max, millis(duration))
+		}
+		point.Avg = millis(total / time.Duration(point.Pages))
+		points = append(points, point)
+	}
+	return points
+}
+
+// latencyTrendSVG draws points as a line chart of average page latency, with the maximum
+// drawn above it, against how far into the scan each page began. It returns nil for fewer
+// than two points, which show no trend.
+func latencyTrendSVG(title string, points []LatencyPoint) []byte {
+	if len(points) < 2 {
+		return nil
+	}
+
+	maxOffset := max(points[len(points)-1].Offset, 1)
+	maxMillis := 0.0
+	for _, point := range points {
+		maxMillis = max(maxMillis, point.Max)
+	}
+	if maxMillis <= 0 {
+		maxMillis = 1
+	}
+
+	plotWidth := float64(trendChartWidth - trendMarginLeft - trendMarginRight)
+	plotHeight := float64(trendChartHeight - trendMarginTop - trendMarginBot)
+	x := func(offset int) float64 {
+		return trendMarginLeft + float64(offset)/float64(maxOffset)*plotWidth
+	}
+	y := func(ms float64) float64 {
+		return trendMarginTop + plotHeight - ms/maxMillis*plotHeight
+	}
+
+	var avg, peak bytes.Buffer
+	for i, point := range points {
+		command := "L"
+		if i == 0 {
+			command = "M"
+		}
+		fmt.Fprintf(&avg, "%s%.1f,%.1f ", command, x(point.Offset), y(point.Avg))
+		fmt.Fprintf(&peak, "%s%.1f,%.1f ", command, x(point.Offset), y(point.Max))
+	}
+
+	var svg bytes.Buffer
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`,
+		trendChartWidth, trendChartHeight, trendChartWidth, trendChartHeight)
+	fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="#ffffff"/>`, trendChartWidth, trendChartHeight)
+	fmt.Fprintf(&svg, `<text x="%d" y="18" font-size="14">%s</text>`, trendMarginLeft, html.EscapeString(title))
+
+	// Axes, labelled at their ends.
+	bottom, right := trendMarginTop+plotHeight, trendMarginLeft+plotWidth
+	fmt.Fprintf(&svg, `<path d="M%d,%d V%.1f H%.1f" fill="none" stroke="#5d6470"/>`, trendMarginLeft, trendMarginTop, bottom, right)
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="end" dx="-6" dy="4">%.4g ms</text>`, trendMarginLeft, trendMarginTop, maxMillis)
+	fmt.Fprintf(&svg, `<text x="%d" y="%.1f" text-anchor="end" dx="-6" dy="4">0 ms</text>`, trendMarginLeft, bottom)
+	fmt.Fprintf(&svg, `<text x="%d" y="%.1f" dy="16">0</text>`, trendMarginLeft, bottom)
+	fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" dy="16" text-anchor="end">%d records</text>`, right, bottom, maxOffset)
+
+	fmt.Fprintf(&svg, `<path d="%s" fill="none" stroke="#f5a623" stroke-width="1"/>`, bytes.TrimSpace(peak.Bytes()))
+	fmt.Fprintf(&svg, `<path d="%s" fill="none" stroke="#1c58d9" stroke-width="2"/>`, bytes.TrimSpace(avg.Bytes()))
+	fmt.Fprintf(&svg, `<text x="%.1f" y="%d" text-anchor="end" fill="#1c58d9">avg</text>`, right, trendMarginTop-14)
+	fmt.Fprintf(&svg, `<text x="%.1f" y="%d" text-anchor="end" fill="#f5a623">max</text>`, right-36, trendMarginTop-14)
+	svg.WriteString(`</svg>`)
+
+	return svg.Bytes()
+}
+
+// latencyTrendTitle titles the latency trend chart of result.
+func latencyTrendTitle(result TestResult) string {
+	title := fmt.Sprintf("%s page latency over the scan", result.ConnType)
+	if result.Label != "" {
+		title += " (" + result.Label + ")"
+	}
+	return title
+}
+
+// uploadLatencyTrend uploads the latency trend chart of result to channelID, returning the ID of
+// the file to attach to a post there, or an empty string if the run has no trend or the upload
+// failed.
+func (p *Plugin) uploadLatencyTrend(result TestResult, channelID string) string {
+	svg := latencyTrendSVG(latencyTrendTitle(result), result.LatencyTrend)
+	if svg == nil {
+		return ""
+	}
+
+	name := "latency-trend.svg"
+	if result.RunID != "" {
+		name = "latency-trend-" + result.RunID + ".svg"
+	}
+	info, err := p.client.File.Upload(bytes.NewReader(svg), name, channelID)
+	if err != nil {
+		p.API.LogError("Failed to upload latency trend chart", "channel_id", channelID, "error", err)
+		return ""
+	}
+	return info.Id
+}
+
+// LatencyTrendChart serves the latency trend of the stored run with the ID given in the path as
+// an SVG chart, or 404 Not Found if the run recorded no trend.
+func (p *Plugin) LatencyTrendChart(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	_, result, status, err := p.getStoredResult(id)
+	if err != nil {
+		respondWithJSON(w, status, TestResult{Error: err.Error()})
+		return
+	}
+
+	svg := latencyTrendSVG(latencyTrendTitle(result), result.LatencyTrend)
+	if svg == nil {
+		respondWithJSON(w, http.StatusNotFound, TestResult{Error: fmt.Sprintf("run %s recorded no latency trend", id)})
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	if _, err := w.Write(svg); err != nil {
+		p.API.LogError("Failed to write latency trend chart", "error", err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-starter-template/server/store/kvstore"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyTrend(t *testing.T) {
+	assert.Nil(t, latencyTrend(nil, nil))
+
+	points := latencyTrend([]time.Duration{time.Millisecond, 3 * time.Millisecond}, []int{0, 100})
+	assert.Equal(t, []LatencyPoint{{Offset: 0, Pages: 1, Avg: 1, Max: 1}, {Offset: 100, Pages: 1, Avg: 3, Max: 3}}, points)
+
+	// Long scans are summarized into at most maxLatencyPoints points of consecutive pages.
+	durations := make([]time.Duration, 3*maxLatencyPoints-1)
+	offsets := make([]int, len(durations))
+	for i := range durations {
+		durations[i] = time.Duration(i%3+1) * time.Millisecond
+		offsets[i] = i * 10
+	}
+	points = latencyTrend(durations, offsets)
+	require.Len(t, points, maxLatencyPoints)
+	assert.Equal(t, LatencyPoint{Offset: 30, Pages: 3, Avg: 2, Max: 3}, points[1])
+	assert.Equal(t, 2, points[len(points)-1].Pages)
+}
+
+func TestLatencyTrendSVG(t *testing.T) {
+	assert.Nil(t, latencyTrendSVG("one point", []LatencyPoint{{Avg: 1, Max: 1}}))
+
+	svg := string(latencyTrendSVG("rpc <scan>", []LatencyPoint{{Offset: 0, Avg: 1, Max: 2}, {Offset: 1000, Avg: 4, Max: 8}}))
+	assert.Contains(t, svg, `<svg xmlns="http://www.w3.org/2000/svg"`)
+	assert.Contains(t, svg, "rpc &lt;scan&gt;")
+	assert.Contains(t, svg, "8 ms")
+	assert.Contains(t, svg, "1000 records")
+}
+
+func TestLatencyTrendChart(t *testing.T) {
+	p := Plugin{kvstore: fakeRunStore{KVStore: &fakeRunLockStore{}, runs: map[string]kvstore.Run{
+		"trend":    {ID: "trend", Result: []byte(`{"conn_type": "raw", "latency_trend": [{"offset": 0, "avg_ms": 1, "max_ms": 1}, {"offset": 10, "avg_ms": 2, "max_ms": 2}]}`)},
+		"no-trend": {ID: "no-trend", Result: []byte(`{"conn_type": "raw"}`)},
+	}}}
+	p.SetAPI(adminAPI())
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, adminRequest(http.MethodGet, target))
+		return w
+	}
+
+	w := serve("/api/v1/results/trend/latency.svg")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "raw page latency over the scan")
+
+	assert.Equal(t, http.StatusNotFound, serve("/api/v1/results/no-trend/latency.svg").Code)
+	assert.Equal(t, http.StatusNotFound, serve("/api/v1/results/missing/latency.svg").Code)
+}
+
+func TestPostRegressionAlertAttachesLatencyTrend(t *testing.T) {
+	api := adminAPI()
+	api.On("GetConfig").Return(&model.Config{})
+	api.On("UploadFile", mock.Anything, "alerts", "latency-trend-run1.svg").Return(&model.FileInfo{Id: "file1"}, nil)
+
+	var posted *model.Post
+	api.On("CreatePost", mock.Anything).Run(func(args mock.Arguments) {
+		posted = args.Get(0).(*model.Post).Clone()
+	}).Return(&model.Post{}, nil)
+
+	p := Plugin{botUserID: "bot-id"}
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(api, nil)
+	p.setConfiguration(&configuration{AlertChannelID: "alerts"})
+
+	result := TestResult{RunID: "run1", ConnType: "rpc", LatencyTrend: []LatencyPoint{{Offset: 0, Avg: 1, Max: 1}, {Offset: 10, Avg: 2, Max: 2}}}
+	require.NoError(t, p.postRegressionAlert(regressionAlert{Reason: "slower", Result: result}))
+
+	require.NotNil(t, posted)
+	assert.Equal(t, model.StringArray{"file1"}, posted.FileIds)
+	require.Len(t, posted.Attachments(), 1)
+	assert.Equal(t, "rpc benchmark", posted.Attachments()[0].Title)
+}
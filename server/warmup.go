@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// maxWarmupBatches caps warmup_batches.
+const maxWarmupBatches = 1000
+
+// warmUp runs opts.WarmupBatches untimed batches of the workload's representative queries ahead
+// of the timed queries, so that cold caches and lazily opened connections do not pollute the
+// reported numbers. With opts.Analyze, the workload's tables are analyzed first. The time spent
+// is reported on result apart from the query time.
+func (p *Plugin) warmUp(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	if opts.Analyze != "" {
+		if err := analyzeTables(db, driverName, opts, result); err != nil {
+			return err
+		}
+	}
+
+	if opts.WarmupBatches == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	queries := representativeQueries(driverName, opts)
+	for batch := 0; batch < opts.WarmupBatches; batch++ {
+		for _, query := range queries {
+			if err := p.warmUpQuery(db, driverName, opts, query); err != nil {
+				return fmt.Errorf("failed to warm up %s: %v", query.Name, err)
+			}
+		}
+	}
+
+	result.WarmupBatches = opts.WarmupBatches
+	result.WarmupTimeSeconds = time.Since(start).Seconds()
+
+	return nil
+}
+
+// warmUpQuery runs query once and reads every row it returns. Queries with settings run in a
+// transaction that is rolled back afterwards, as when they are explained.
+func (p *Plugin) warmUpQuery(db *sql.DB, driverName string, opts testOptions, query explainQuery) error {
+	querySQL := opts.tagSQL(rebind(driverName, query.SQL))
+
+	if len(query.Settings) == 0 {
+		rows, err := db.Query(querySQL, query.Args...)
+		if err != nil {
+			return err
+		}
+		return drainRows(rows)
+	}
+
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil {
+			p.API.LogError("Failed to rollback transaction", "error", err)
+		}
+	}()
+
+	for _, setting := range query.Settings {
+		if _, err := tx.Exec(opts.tagSQL(setting)); err != nil {
+			return fmt.Errorf("failed to apply %q: %v", setting, err)
+		}
+	}
+
+	rows, err := tx.Query(querySQL, query.Args...)
+	if err != nil {
+		return err
+	}
+	return drainRows(rows)
+}
+
+// drainRows reads and closes rows, discarding the values.
+func drainRows(rows *sql.Rows) error {
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultWideColumns and maxWideColumns bound the columns query param.
+	defaultWideColumns = 32
+	maxWideColumns     = 256
+
+	// wideInsertBatch is the number of rows per multi-row INSERT when seeding a wide table.
+	wideInsertBatch = 100
+)
+
+// wideTable names the wide table with the given number of data columns. Each width has its own
+// table, so runs of different widths never disturb one another.
+func wideTable(columns int) string {
+	return fmt.Sprintf("plugin_test_rpc_wide_%d", columns)
+}
+
+// wideColumns names the data columns of a wide table.
+func wideColumns(columns int) []string {
+	names := make([]string, columns)
+	for i := range names {
+		names[i] = fmt.Sprintf("c%d", i+1)
+	}
+
+	return names
+}
+
+// runWideTest seeds a table of opts.Columns text columns and pages through every column of it,
+// measuring how per-column marshaling scales on each connection type, since Mattermost tables
+// such as Posts have dozens of columns.
+func (p *Plugin) runWideTest(db *sql.DB, driverName string, opts testOptions) (TestResult, error) {
+	result := TestResult{
+		Label:    opts.Label,
+		Mode:     modeWide,
+		Phase:    opts.Phase,
+		PageSize: opts.PageSize,
+		RowBytes: opts.RowBytes,
+		Columns:  opts.Columns,
+	}
+
+	if opts.Phase != phaseQuery {
+		inserted, insertTime, err := p.seedWideTable(db, driverName, opts)
+		if err != nil {
+			return result, err
+		}
+		result.setInsertThroughput(inserted, insertTime)
+	}
+
+	if opts.Phase != phaseSeed {
+		if err := p.warmUp(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+		if err := queryWideTable(db, driverName, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// seedWideTable creates the wide table for opts.Columns if needed and tops it up to opts.Records
+// rows, every column holding the row's test data, returning the number of rows inserted and the
+// time spent inserting.
+func (p *Plugin) seedWideTable(db *sql.DB, driverName string, opts testOptions) (int, time.Duration, error) {
+	table := wideTable(opts.Columns)
+	columns := wideColumns(opts.Columns)
+
+	id := "id INT AUTO_INCREMENT PRIMARY KEY"
+	if driverName == "postgres" {
+		id = "id SERIAL PRIMARY KEY"
+	}
+	// TEXT keeps wide rows clear of MySQL's 64KB row size limit, which VARCHAR counts against.
+	createTableSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s, %s TEXT NOT NULL)", table, id, strings.Join(columns, " TEXT NOT NULL, "))
+	if _, err := db.Exec(opts.tagSQL(createTableSQL)); err != nil {
+		return 0, 0, fmt.Errorf("failed to create wide table: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(opts.tagSQL("SELECT COUNT(*) FROM " + table)).Scan(&count); err != nil {
+		return 0, 0, fmt.Errorf("failed to check record count: %v", err)
+	}
+	if count >= opts.Records {
+		return 0, 0, nil
+	}
+
+	p.API.LogInfo(fmt.Sprintf("Inserting wide records: %d of %d", count, opts.Records))
+	startInsert := time.Now()
+
+	tx, err := opts.beginTx(db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	for start := count; start < opts.Records; start += wideInsertBatch {
+		rows := min(wideInsertBatch, opts.Records-start)
+
+		args := make([]any, 0, rows*opts.Columns)
+		for i := start; i < start+rows; i++ {
+			data := testData(i, opts.RowBytes)
+			for range columns {
+				args = append(args, data)
+			}
+		}
+
+		if _, err := tx.Exec(opts.tagSQL(multiRowInsert(driverName, table, columns, rows)), args...); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				p.API.LogError("Failed to rollback transaction", "error", rbErr)
+			}
+			return 0, 0, fmt.Errorf("failed to insert rows %d-%d: %v", start, start+rows-1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return opts.Records - count, time.Since(startInsert), nil
+}
+
+// widePageSQL reads one page of every column of the wide table for columns.
+func widePageSQL(columns int) string {
+	return fmt.Sprintf("SELECT id, %s FROM %s ORDER BY id LIMIT ? OFFSET ?", strings.Join(wideColumns(columns), ", "), wideTable(columns))
+}
+
+// queryWideTable pages through the first opts.Records rows of the wide table, scanning every
+// column, and records the total time and the rows and bytes read on result.
+func queryWideTable(db *sql.DB, driverName string, opts testOptions, result *TestResult) error {
+	startTotalQuery := time.Now()
+
+	stmt, err := db.Prepare(opts.tagSQL(rebind(driverName, widePageSQL(opts.Columns))))
+	if err != nil {
+		return fmt.Errorf("failed to prepare page query: %v", err)
+	}
+	defer stmt.Close()
+
+	var id int
+	values := make([]string, opts.Columns)
+	dest := make([]any, 0, opts.Columns+1)
+	dest = append(dest, &id)
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+
+	for offset := 0; offset < opts.Records; offset += opts.PageSize {
+		limit := min(opts.PageSize, opts.Records-offset)
+
+		rows, err := stmt.Query(limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to query rows at offset %d: %v", offset, err)
+		}
+
+		for rows.Next() {
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan row: %v", err)
+			}
+			result.RecordsQueried++
+			for _, value := range values {
+				result.BytesQueried += int64(len(value))
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read rows at offset %d: %v", offset, err)
+		}
+		rows.Close()
+	}
+
+	result.TotalQueryTimeSeconds = time.Since(startTotalQuery).Seconds()
+	result.setQueryThroughput()
+
+	return nil
+}
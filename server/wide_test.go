@@ -0,0 +1,16 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWideColumns(t *testing.T) {
+	assert.Equal(t, []string{"c1", "c2", "c3"}, wideColumns(3))
+	assert.Len(t, wideColumns(maxWideColumns), maxWideColumns)
+}
+
+func TestWidePageSQL(t *testing.T) {
+	assert.Equal(t, "SELECT id, c1, c2, c3 FROM plugin_test_rpc_wide_3 ORDER BY id LIMIT ? OFFSET ?", widePageSQL(3))
+}
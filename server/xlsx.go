@@ -0,0 +1,141 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xlsxSheet is a worksheet of an xlsx workbook. Cells are strings, numbers or booleans; a nil
+// cell is left empty.
+type xlsxSheet struct {
+	Name string
+	Rows [][]any
+}
+
+// Limits spreadsheet applications place on the length of worksheet names and cell text.
+const (
+	xlsxMaxSheetName  = 31
+	xlsxMaxCellLength = 32767
+)
+
+// xlsxSheetName returns name with the characters worksheet names may not contain replaced, cut to
+// the length they may have.
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > xlsxMaxSheetName {
+		name = name[:xlsxMaxSheetName]
+	}
+	return name
+}
+
+// xlsxColumn returns the letters naming the column with zero-based index i, such as AA for 26.
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// writeXLSX writes sheets as an Office Open XML workbook. Only the parts a spreadsheet
+// application requires are written, with strings inline rather than in a shared string table.
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	archive := zip.NewWriter(w)
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, sheet := range sheets {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(xlsxSheetName(sheet.Name)), n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+	}
+	for i, sheet := range sheets {
+		parts = append(parts, struct{ name, content string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxWorksheet(sheet.Rows)})
+	}
+
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %v", part.name, err)
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return fmt.Errorf("failed to write %s: %v", part.name, err)
+		}
+	}
+
+	return archive.Close()
+}
+
+// xlsxWorksheet renders rows as the XML of a worksheet.
+func xlsxWorksheet(rows [][]any) string {
+	var sheet strings.Builder
+	sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, r+1)
+		for c, value := range row {
+			ref := xlsxColumn(c) + strconv.Itoa(r+1)
+			switch value := value.(type) {
+			case nil:
+			case string:
+				if len(value) > xlsxMaxCellLength {
+					value = strings.ToValidUTF8(value[:xlsxMaxCellLength], "")
+				}
+				fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(value))
+			case bool:
+				b := 0
+				if value {
+					b = 1
+				}
+				fmt.Fprintf(&sheet, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+			case int:
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%d</v></c>`, ref, value)
+			case int64:
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%d</v></c>`, ref, value)
+			case float64:
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(value, 'g', -1, 64))
+			default:
+				fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(fmt.Sprint(value)))
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+	return sheet.String()
+}
+
+// xmlEscape escapes s for XML text and attribute values.
+func xmlEscape(s string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(s))
+	return escaped.String()
+}
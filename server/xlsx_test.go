@@ -0,0 +1,69 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readXLSX returns the contents of each part of the xlsx workbook in data.
+func readXLSX(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		parts[file.Name] = string(content)
+	}
+	return parts
+}
+
+func TestXLSXColumn(t *testing.T) {
+	assert.Equal(t, "A", xlsxColumn(0))
+	assert.Equal(t, "Z", xlsxColumn(25))
+	assert.Equal(t, "AA", xlsxColumn(26))
+	assert.Equal(t, "AZ", xlsxColumn(51))
+	assert.Equal(t, "BA", xlsxColumn(52))
+	assert.Equal(t, "AAA", xlsxColumn(702))
+}
+
+func TestXLSXSheetName(t *testing.T) {
+	assert.Equal(t, "Summary", xlsxSheetName("Summary"))
+	assert.Equal(t, "a_b_c", xlsxSheetName("a/b?c"))
+	assert.Len(t, xlsxSheetName(strings.Repeat("x", 40)), xlsxMaxSheetName)
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var workbook bytes.Buffer
+	require.NoError(t, writeXLSX(&workbook, []xlsxSheet{
+		{Name: "First", Rows: [][]any{{"name", "value"}, {"<rows>", 1.5, nil, true, 7}}},
+		{Name: "Second"},
+	}))
+
+	parts := readXLSX(t, workbook.Bytes())
+	assert.Contains(t, parts["[Content_Types].xml"], `PartName="/xl/worksheets/sheet2.xml"`)
+	assert.Contains(t, parts["_rels/.rels"], `Target="xl/workbook.xml"`)
+	assert.Contains(t, parts["xl/workbook.xml"], `<sheet name="First" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, parts["xl/workbook.xml"], `<sheet name="Second" sheetId="2" r:id="rId2"/>`)
+	assert.Contains(t, parts["xl/_rels/workbook.xml.rels"], `Target="worksheets/sheet2.xml"`)
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">&lt;rows&gt;</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>1.5</v></c>`)
+	assert.NotContains(t, sheet, `r="C2"`)
+	assert.Contains(t, sheet, `<c r="D2" t="b"><v>1</v></c>`)
+	assert.Contains(t, sheet, `<c r="E2"><v>7</v></c>`)
+	assert.Contains(t, parts["xl/worksheets/sheet2.xml"], `<sheetData></sheetData>`)
+}